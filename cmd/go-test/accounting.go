@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math/big"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/sirupsen/logrus"
+)
+
+// GasAccountingResult is the result of verifying block gas fields against receipts.
+type GasAccountingResult struct {
+	Checks           int      // blocks checked
+	Mismatches       int      // blocks of which gas accounting doesn't add up
+	MismatchedEpochs []uint64 `json:",omitempty"`
+}
+
+func (result *GasAccountingResult) Add(other GasAccountingResult) {
+	result.Checks += other.Checks
+	result.Mismatches += other.Mismatches
+	result.MismatchedEpochs = append(result.MismatchedEpochs, other.MismatchedEpochs...)
+}
+
+// VerifyGasAccounting verifies that the sum of receipt gasUsed of each block equals the gasUsed of block,
+// which is consistent with the accumulatedGasUsed of the last receipt and within the gas limit of block.
+func VerifyGasAccounting(epochNumber uint64, blocks []*types.Block, receipts [][]types.TransactionReceipt) (result GasAccountingResult) {
+	for i, block := range blocks {
+		// gas used is unavailable if block not executed yet
+		if block.GasUsed == nil || i >= len(receipts) {
+			continue
+		}
+
+		result.Checks++
+
+		sum := new(big.Int)
+		for _, receipt := range receipts[i] {
+			if receipt.GasUsed != nil {
+				sum.Add(sum, receipt.GasUsed.ToInt())
+			}
+		}
+
+		var reason string
+		switch {
+		case sum.Cmp(block.GasUsed.ToInt()) != 0:
+			reason = "sum of receipt gasUsed mismatch with block gasUsed"
+		case block.GasLimit != nil && sum.Cmp(block.GasLimit.ToInt()) > 0:
+			reason = "sum of receipt gasUsed exceeds block gasLimit"
+		case len(receipts[i]) > 0 && receipts[i][len(receipts[i])-1].AccumulatedGasUsed != nil &&
+			sum.Cmp(receipts[i][len(receipts[i])-1].AccumulatedGasUsed.ToInt()) != 0:
+			reason = "sum of receipt gasUsed mismatch with accumulatedGasUsed of last receipt"
+		default:
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"epoch":    epochNumber,
+			"block":    block.Hash,
+			"receipts": len(receipts[i]),
+			"sum":      sum,
+			"gasUsed":  block.GasUsed,
+			"gasLimit": block.GasLimit,
+		}).Warn("Block gas accounting doesn't add up: " + reason)
+
+		if result.Mismatches == 0 {
+			result.MismatchedEpochs = append(result.MismatchedEpochs, epochNumber)
+		}
+		result.Mismatches++
+	}
+
+	return result
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/pkg/errors"
+)
+
+// ContractActivity is the activity of a contract in the scanned range.
+type ContractActivity struct {
+	Address string
+	Logs    int    // logs emitted by contract
+	Calls   int    // calls to contract in traces, including internal calls
+	GasUsed uint64 // of transactions sent to contract directly
+}
+
+func (activity *ContractActivity) add(other ContractActivity) {
+	activity.Logs += other.Logs
+	activity.Calls += other.Calls
+	activity.GasUsed += other.GasUsed
+}
+
+// isContract returns true for contract and builtin addresses in core space.
+func isContract(address *types.Address) bool {
+	addressType := address.GetAddressType()
+	return addressType == cfxaddress.AddressTypeContract || addressType == cfxaddress.AddressTypeBuiltin
+}
+
+// contractActivitiesOf aggregates activities of contracts by address in an epoch.
+func contractActivitiesOf(traces []*types.LocalizedBlockTrace, receipts [][]types.TransactionReceipt) map[string]ContractActivity {
+	activities := make(map[string]ContractActivity)
+	update := func(address *types.Address, f func(activity *ContractActivity)) {
+		if !isContract(address) {
+			return
+		}
+
+		key := address.String()
+		activity := activities[key]
+		activity.Address = key
+		f(&activity)
+		activities[key] = activity
+	}
+
+	for _, blockReceipts := range receipts {
+		for _, receipt := range blockReceipts {
+			if receipt.To != nil && receipt.GasUsed != nil {
+				update(receipt.To, func(activity *ContractActivity) { activity.GasUsed += receipt.GasUsed.ToInt().Uint64() })
+			}
+
+			for _, log := range receipt.Logs {
+				if log.Space == nil || *log.Space == types.SPACE_NATIVE {
+					update(&log.Address, func(activity *ContractActivity) { activity.Logs++ })
+				}
+			}
+		}
+	}
+
+	for _, blockTraces := range traces {
+		if blockTraces == nil {
+			continue
+		}
+
+		for _, txTraces := range blockTraces.TransactionTraces {
+			for _, trace := range txTraces.Traces {
+				if call, ok := trace.Action.(types.Call); ok && call.Space == types.SPACE_NATIVE {
+					update(&call.To, func(activity *ContractActivity) { activity.Calls++ })
+				}
+			}
+		}
+	}
+
+	return activities
+}
+
+// TopContractStat ranks contracts by activity across the scanned range.
+type TopContractStat struct {
+	limit     int
+	contracts map[string]*ContractActivity
+}
+
+func NewTopContractStat(limit int) *TopContractStat {
+	return &TopContractStat{
+		limit:     limit,
+		contracts: make(map[string]*ContractActivity),
+	}
+}
+
+func (stat *TopContractStat) Add(activities map[string]ContractActivity) {
+	for address, activity := range activities {
+		if _, ok := stat.contracts[address]; !ok {
+			stat.contracts[address] = &ContractActivity{Address: address}
+		}
+
+		stat.contracts[address].add(activity)
+	}
+}
+
+// top returns the top contracts in descending order of the given metric, and ties are broken by address.
+func (stat *TopContractStat) top(metric func(activity *ContractActivity) uint64) []ContractActivity {
+	var contracts []*ContractActivity
+	for _, activity := range stat.contracts {
+		if metric(activity) > 0 {
+			contracts = append(contracts, activity)
+		}
+	}
+
+	slices.SortFunc(contracts, func(a, b *ContractActivity) int {
+		if c := cmp.Compare(metric(b), metric(a)); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Address, b.Address)
+	})
+
+	var result []ContractActivity
+	for _, activity := range contracts[:min(len(contracts), stat.limit)] {
+		result = append(result, *activity)
+	}
+
+	return result
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the top contracts by each metric.
+func (stat *TopContractStat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		NumContracts int
+		ByLogs       []ContractActivity
+		ByCalls      []ContractActivity
+		ByGasUsed    []ContractActivity
+	}{
+		len(stat.contracts),
+		stat.top(func(activity *ContractActivity) uint64 { return uint64(activity.Logs) }),
+		stat.top(func(activity *ContractActivity) uint64 { return uint64(activity.Calls) }),
+		stat.top(func(activity *ContractActivity) uint64 { return activity.GasUsed }),
+	})
+}
+
+// SenderActivity is the transactions sent by an account in the scanned range.
+type SenderActivity struct {
+	Address string
+	Txs     int
+	GasUsed uint64
+	GasFee  *big.Int // in drip, excluding gas covered by sponsor
+}
+
+// senderActivitiesOf aggregates transactions by sender in an epoch.
+func senderActivitiesOf(receipts [][]types.TransactionReceipt) map[string]SenderActivity {
+	activities := make(map[string]SenderActivity)
+
+	for _, blockReceipts := range receipts {
+		for _, receipt := range blockReceipts {
+			key := receipt.From.String()
+
+			activity, ok := activities[key]
+			if !ok {
+				activity = SenderActivity{Address: key, GasFee: new(big.Int)}
+			}
+
+			activity.Txs++
+			if receipt.GasUsed != nil {
+				activity.GasUsed += receipt.GasUsed.ToInt().Uint64()
+			}
+			if receipt.GasFee != nil && !receipt.GasCoveredBySponsor {
+				activity.GasFee.Add(activity.GasFee, receipt.GasFee.ToInt())
+			}
+
+			activities[key] = activity
+		}
+	}
+
+	return activities
+}
+
+// TopSenderStat ranks transaction senders across the scanned range.
+type TopSenderStat struct {
+	limit   int
+	senders map[string]*SenderActivity
+}
+
+func NewTopSenderStat(limit int) *TopSenderStat {
+	return &TopSenderStat{
+		limit:   limit,
+		senders: make(map[string]*SenderActivity),
+	}
+}
+
+func (stat *TopSenderStat) Add(activities map[string]SenderActivity) {
+	for address, activity := range activities {
+		sender, ok := stat.senders[address]
+		if !ok {
+			sender = &SenderActivity{Address: address, GasFee: new(big.Int)}
+			stat.senders[address] = sender
+		}
+
+		sender.Txs += activity.Txs
+		sender.GasUsed += activity.GasUsed
+		sender.GasFee.Add(sender.GasFee, activity.GasFee)
+	}
+}
+
+// sorted returns all senders in descending order by the given comparison, and ties are broken by address.
+func (stat *TopSenderStat) sorted(compare func(a, b *SenderActivity) int) []*SenderActivity {
+	senders := make([]*SenderActivity, 0, len(stat.senders))
+	for _, sender := range stat.senders {
+		senders = append(senders, sender)
+	}
+
+	slices.SortFunc(senders, func(a, b *SenderActivity) int {
+		if c := compare(b, a); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Address, b.Address)
+	})
+
+	return senders
+}
+
+func (stat *TopSenderStat) top(compare func(a, b *SenderActivity) int) []SenderActivity {
+	var result []SenderActivity
+	for _, sender := range stat.sorted(compare)[:min(len(stat.senders), stat.limit)] {
+		result = append(result, *sender)
+	}
+
+	return result
+}
+
+func compareSenderTxs(a, b *SenderActivity) int    { return cmp.Compare(a.Txs, b.Txs) }
+func compareSenderGasFee(a, b *SenderActivity) int { return a.GasFee.Cmp(b.GasFee) }
+
+// WriteCSV writes the full aggregation of all senders to CSV file in descending order of transactions.
+func (stat *TopSenderStat) WriteCSV(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to create file")
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"address", "txs", "gas_used", "gas_fee"})
+
+	for _, v := range stat.sorted(compareSenderTxs) {
+		w.Write([]string{v.Address, strconv.Itoa(v.Txs), strconv.FormatUint(v.GasUsed, 10), v.GasFee.String()})
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the top senders by transactions and gas fee.
+func (stat *TopSenderStat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		NumSenders int
+		ByTxs      []SenderActivity
+		ByGasFee   []SenderActivity
+	}{len(stat.senders), stat.top(compareSenderTxs), stat.top(compareSenderGasFee)})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/boqiu/go-test/pkg/report"
+)
+
+// maxAnnotations is the maximum number of annotations of each kind, since GitHub only displays a limited
+// number of annotations per step.
+const maxAnnotations = 10
+
+// printAnnotations prints GitHub Actions workflow commands to stdout to annotate the failed assertions,
+// verifications and epochs of test run.
+func printAnnotations(stat *RpcStat) {
+	annotate := func(level, title, message string) {
+		fmt.Println(report.GithubAnnotation(level, title, message))
+	}
+
+	if stat.Assert != nil {
+		for _, v := range stat.Assert.Failures[:min(len(stat.Assert.Failures), maxAnnotations)] {
+			message := fmt.Sprintf("%v at epoch %v", v.Expr, v.Epoch)
+			if len(v.Error) > 0 {
+				message += ": " + v.Error
+			}
+			annotate("error", "Assertion failed", message)
+		}
+
+		if stat.Assert.NumFailed > maxAnnotations {
+			annotate("error", "Assertion failed", fmt.Sprintf("%v of %v assertion checks failed in total", stat.Assert.NumFailed, stat.Assert.NumChecks))
+		}
+	}
+
+	for _, v := range verificationsOf(stat) {
+		if v.failures > 0 {
+			annotate("error", "Verification failed", fmt.Sprintf("%v of %v %v checks failed", v.failures, v.checks, v.name))
+		}
+	}
+
+	for _, v := range stat.FailedEpochs[:min(len(stat.FailedEpochs), maxAnnotations)] {
+		annotate("error", "Epoch failed", fmt.Sprintf("epoch %v: %v", v.Epoch, v.Error))
+	}
+
+	if stat.NumErrors > maxAnnotations {
+		annotate("error", "Epoch failed", fmt.Sprintf("%v of %v epochs failed in total", stat.NumErrors, flags.NumEpochs))
+	}
+
+	if stat.NumRecovered > 0 {
+		annotate("warning", "Epoch recovered", fmt.Sprintf("%v epochs failed at first and recovered in retry", stat.NumRecovered))
+	}
+}
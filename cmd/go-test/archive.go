@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// archive of unexpected responses, nil if disabled
+var archive *report.Archive
+
+// setupArchive creates the archive directory if required.
+func setupArchive() error {
+	if len(flags.ArchiveDir) == 0 {
+		return nil
+	}
+
+	var err error
+	if archive, err = report.NewArchive(flags.ArchiveDir); err != nil {
+		return errors.WithMessagef(err, "Failed to create archive %v", flags.ArchiveDir)
+	}
+
+	return nil
+}
+
+// archiveResponse saves the unexpected response if archive enabled.
+func archiveResponse(entry report.ArchiveEntry, raw []byte) {
+	if archive == nil {
+		return
+	}
+
+	if len(entry.Endpoint) == 0 {
+		entry.Endpoint = flags.Url
+	}
+
+	file, err := archive.Save(entry, raw)
+	if err != nil {
+		logrus.WithError(err).WithField("method", entry.Method).Warn("Failed to archive response")
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"method": entry.Method,
+		"file":   file,
+	}).Info("Unexpected response archived")
+}
+
+// archiveMismatch queries the RPC again to archive the raw response that fails verification, since the
+// response has already been decoded.
+func archiveMismatch(client *sdk.Client, epoch uint64, reason, method string, params ...any) {
+	if archive == nil {
+		return
+	}
+
+	var raw json.RawMessage
+	if err := client.CallRPC(&raw, method, params...); err != nil {
+		logrus.WithError(err).WithField("method", method).Warn("Failed to query response to archive")
+		return
+	}
+
+	archiveResponse(report.ArchiveEntry{
+		Method: method,
+		Params: params,
+		Epoch:  epoch,
+		Reason: reason,
+	}, raw)
+}
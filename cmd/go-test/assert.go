@@ -0,0 +1,148 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/boqiu/go-test/pkg/expr"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxAssertFailures is the maximum number of assertion failures to report in detail.
+const maxAssertFailures = 100
+
+// epochMethods is the RPC methods that may be measured per epoch, whose latency could be asserted.
+var epochMethods = map[string]bool{
+	"cfx_getBlocksByEpoch":               true,
+	"cfx_getBlockByHash":                 true,
+	"cfx_getEpochReceipts":               true,
+	methodReceiptsByPivot:                true,
+	"trace_block":                        true,
+	"cfx_getSupplyInfo":                  true,
+	"cfx_getInterestRate":                true,
+	"cfx_getAccumulateInterestRate":      true,
+	"cfx_getBalance":                     true,
+	"cfx_getNextNonce":                   true,
+	"cfx_getDepositList":                 true,
+	"cfx_getVoteList":                    true,
+	"cfx_getCode":                        true,
+	"cfx_getStorageAt":                   true,
+	"cfx_estimateGasAndCollateral":       true,
+	"cfx_call":                           true,
+	"cfx_checkBalanceAgainstTransaction": true,
+	"cfx_getLogs":                        true,
+	"cfx_getSponsorInfo":                 true,
+	"eth_getBlockByNumber":               true,
+	"eth_getBlockReceipts":               true,
+	"eth_getLogs":                        true,
+}
+
+// epochVars resolves variables of an epoch to evaluate assertions, in which durations are in nanoseconds.
+func epochVars(epoch uint64, summary *EpochSummary) expr.Vars {
+	return func(name string) (float64, bool) {
+		// max latency of RPC method within epoch, e.g. latency.cfx_getBlockByHash, which is 0 if the method
+		// is not called in epoch, e.g. traces of empty blocks skipped
+		if method, ok := strings.CutPrefix(name, "latency."); ok {
+			if !epochMethods[method] {
+				return 0, false
+			}
+
+			latency, _ := summary.Latency.Max(method)
+			return float64(latency), true
+		}
+
+		switch name {
+		case "epoch.number":
+			return float64(epoch), true
+		case "epoch.numBlocks":
+			return float64(summary.NumBlocks), true
+		case "epoch.numTxs":
+			return float64(summary.NumTxs), true
+		case "epoch.numReceipts":
+			return float64(summary.NumReceipts), true
+		case "epoch.numLogs":
+			return float64(summary.NumLogs), true
+		case "epoch.numTraces":
+			return float64(summary.NumTraces), true
+		case "epoch.latency":
+			return float64(summary.Fetch), true
+		case "epoch.elapsed":
+			return float64(summary.Elapsed), true
+		}
+
+		return 0, false
+	}
+}
+
+// AssertFailure is an assertion failed at some epoch.
+type AssertFailure struct {
+	Epoch uint64
+	Expr  string
+	Error string `json:",omitempty"`
+}
+
+// AssertStat evaluates assertions per epoch.
+type AssertStat struct {
+	exprs []*expr.Expr
+
+	NumChecks int
+	NumPassed int
+	NumFailed int
+	Failures  []AssertFailure `json:",omitempty"`
+}
+
+func NewAssertStat(sources []string) (*AssertStat, error) {
+	var stat AssertStat
+
+	// validate variables with an empty epoch
+	vars := epochVars(0, &EpochSummary{})
+
+	for _, source := range sources {
+		e, err := expr.Parse(source)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Failed to parse assertion %v", source)
+		}
+
+		for _, name := range e.Vars() {
+			if _, ok := vars(name); !ok {
+				return nil, errors.Errorf("Unknown variable %v in assertion %v", name, source)
+			}
+		}
+
+		stat.exprs = append(stat.exprs, e)
+	}
+
+	return &stat, nil
+}
+
+// Check evaluates all assertions against the epoch.
+func (stat *AssertStat) Check(epoch uint64, summary *EpochSummary) {
+	vars := epochVars(epoch, summary)
+
+	for _, e := range stat.exprs {
+		stat.NumChecks++
+
+		passed, err := e.Eval(vars)
+		if err == nil && passed {
+			stat.NumPassed++
+			continue
+		}
+
+		stat.NumFailed++
+
+		failure := AssertFailure{Epoch: epoch, Expr: e.String()}
+		if err != nil {
+			failure.Error = err.Error()
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"epoch": epoch,
+			"expr":  e.String(),
+			"error": failure.Error,
+		}).Warn("Assertion failed")
+
+		if len(stat.Failures) < maxAssertFailures {
+			stat.Failures = append(stat.Failures, failure)
+		}
+	}
+}
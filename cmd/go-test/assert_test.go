@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestNewAssertStat(t *testing.T) {
+	for _, source := range []string{
+		"epoch.numBlocks >= 1 && epoch.latency < 2s",
+		"latency.cfx_getBlockByHash < 500ms",
+		"latency.trace_block < 1s || epoch.numTxs == 0",
+	} {
+		if _, err := NewAssertStat([]string{source}); err != nil {
+			t.Errorf("Failed to parse assertion %v: %v", source, err)
+		}
+	}
+
+	for _, source := range []string{
+		"latency.cfx_getBlockByHsh < 500ms", // typo of method
+		"latency. < 1s",
+		"epoch.numBlock >= 1",
+		"epoch.numBlocks >=",
+	} {
+		if _, err := NewAssertStat([]string{source}); err == nil {
+			t.Errorf("Expected error of assertion %v", source)
+		}
+	}
+}
+
+func TestAssertStatCheck(t *testing.T) {
+	stat, err := NewAssertStat([]string{"latency.trace_block < 1s", "epoch.numTxs > 0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// trace_block is not called for the epoch without transactions
+	stat.Check(1, &EpochSummary{})
+
+	if stat.NumChecks != 2 || stat.NumPassed != 1 || stat.NumFailed != 1 || stat.Failures[0].Expr != "epoch.numTxs > 0" {
+		t.Fatalf("Unexpected assertion result %+v", stat)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/sirupsen/logrus"
+)
+
+// ReceiptRefResult is the result of validating back-reference fields of receipts.
+type ReceiptRefResult struct {
+	Checks     int // receipts checked
+	Mismatches int // receipts attributed to wrong epoch, block, transaction or index
+}
+
+func (result *ReceiptRefResult) Add(other ReceiptRefResult) {
+	result.Checks += other.Checks
+	result.Mismatches += other.Mismatches
+}
+
+// receiptRefError returns the first inconsistent back-reference field of receipt against the block it was
+// returned under, or empty if consistent.
+func receiptRefError(epochNumber uint64, block *types.Block, receipt *types.TransactionReceipt, lastIndex int) string {
+	switch {
+	case receipt.EpochNumber == nil || uint64(*receipt.EpochNumber) != epochNumber:
+		return "epochNumber"
+	case receipt.BlockHash != block.Hash:
+		return "blockHash"
+	case int(receipt.Index) >= len(block.Transactions) || int(receipt.Index) <= lastIndex:
+		return "index"
+	case block.Transactions[receipt.Index].Hash != receipt.TransactionHash:
+		return "transactionHash"
+	}
+
+	for _, log := range receipt.Logs {
+		switch {
+		case log.BlockHash != nil && *log.BlockHash != block.Hash:
+			return "log.blockHash"
+		case log.TransactionHash != nil && *log.TransactionHash != receipt.TransactionHash:
+			return "log.transactionHash"
+		case log.EpochNumber != nil && log.EpochNumber.ToInt().Uint64() != epochNumber:
+			return "log.epochNumber"
+		}
+	}
+
+	return ""
+}
+
+// VerifyReceiptRefs validates the epochNumber, blockHash, transactionHash and index fields of epoch receipts
+// against the blocks they were returned under, since misattributed receipts break indexers silently.
+func VerifyReceiptRefs(epochNumber uint64, blocks []*types.Block, receipts [][]types.TransactionReceipt) (result ReceiptRefResult) {
+	if len(receipts) != len(blocks) {
+		logrus.WithFields(logrus.Fields{
+			"epoch":    epochNumber,
+			"blocks":   len(blocks),
+			"receipts": len(receipts),
+		}).Warn("Number of block receipts mismatch with blocks of epoch")
+		result.Checks++
+		result.Mismatches++
+		return
+	}
+
+	for i, block := range blocks {
+		lastIndex := -1
+
+		for j := range receipts[i] {
+			receipt := &receipts[i][j]
+			result.Checks++
+
+			if field := receiptRefError(epochNumber, block, receipt, lastIndex); field != "" {
+				logrus.WithFields(logrus.Fields{
+					"epoch": epochNumber,
+					"block": block.Hash,
+					"tx":    receipt.TransactionHash,
+					"index": uint64(receipt.Index),
+					"field": field,
+				}).Warn("Receipt back-reference mismatch with the block returned under")
+				result.Mismatches++
+			}
+
+			lastIndex = int(receipt.Index)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"math/big"
+	"slices"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+)
+
+// BaseFeeSample is the base fee of pivot block along with the gas usage of an epoch.
+type BaseFeeSample struct {
+	Epoch    uint64
+	BaseFee  *big.Int // of pivot block in drip
+	GasUsed  uint64   // of all blocks in epoch
+	GasLimit uint64   // of pivot block
+}
+
+// baseFeeSampleOf returns the base fee sample of epoch, or nil if base fee is not available, e.g. before CIP-1559.
+func baseFeeSampleOf(epoch uint64, blocks []*types.Block) *BaseFeeSample {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	// pivot block is the last one in epoch
+	pivot := blocks[len(blocks)-1]
+	if pivot.BaseFeePerGas == nil {
+		return nil
+	}
+
+	sample := BaseFeeSample{
+		Epoch:   epoch,
+		BaseFee: pivot.BaseFeePerGas.ToInt(),
+	}
+
+	if pivot.GasLimit != nil {
+		sample.GasLimit = pivot.GasLimit.ToInt().Uint64()
+	}
+
+	for _, block := range blocks {
+		if block.GasUsed != nil {
+			sample.GasUsed += block.GasUsed.ToInt().Uint64()
+		}
+	}
+
+	return &sample
+}
+
+// BaseFeeStat tracks the base fee trajectory across the scanned range, and correlates it with gas usage.
+type BaseFeeStat struct {
+	samples []BaseFeeSample
+}
+
+func (stat *BaseFeeStat) Add(sample *BaseFeeSample) {
+	if sample != nil {
+		stat.samples = append(stat.samples, *sample)
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the trajectory in epoch order, along with the
+// Pearson correlation coefficients of base fee with gas used of the same epoch, and of base fee change with
+// gas used of the previous epoch, which is what drives the base fee adjustment.
+func (stat *BaseFeeStat) MarshalJSON() ([]byte, error) {
+	samples := slices.Clone(stat.samples)
+	slices.SortFunc(samples, func(a, b BaseFeeSample) int { return cmp.Compare(a.Epoch, b.Epoch) })
+
+	var lowest, highest *big.Int
+	var baseFees, gasUsed, changes, prevGasUsed []float64
+
+	for i, v := range samples {
+		if lowest == nil || v.BaseFee.Cmp(lowest) < 0 {
+			lowest = v.BaseFee
+		}
+		if highest == nil || v.BaseFee.Cmp(highest) > 0 {
+			highest = v.BaseFee
+		}
+
+		baseFee, _ := new(big.Float).SetInt(v.BaseFee).Float64()
+		baseFees = append(baseFees, baseFee)
+		gasUsed = append(gasUsed, float64(v.GasUsed))
+
+		if i > 0 && samples[i-1].Epoch+1 == v.Epoch {
+			changes = append(changes, baseFee-baseFees[i-1])
+			prevGasUsed = append(prevGasUsed, gasUsed[i-1])
+		}
+	}
+
+	return json.Marshal(struct {
+		NumSamples            int
+		Min                   *big.Int        `json:",omitempty"`
+		Max                   *big.Int        `json:",omitempty"`
+		GasUsedCorrelation    float64         // between base fee and gas used of the same epoch
+		AdjustmentCorrelation float64         // between base fee change and gas used of the previous epoch
+		Epochs                []BaseFeeSample `json:",omitempty"`
+	}{len(samples), lowest, highest, pearson(gasUsed, baseFees), pearson(prevGasUsed, changes), samples})
+}
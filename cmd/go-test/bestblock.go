@@ -0,0 +1,117 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var bestBlockFlags struct {
+	Interval       time.Duration
+	Duration       time.Duration
+	StallThreshold time.Duration
+}
+
+func newBestBlockCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "bestblock",
+		Short: "Poll the best block at a fixed interval to measure how block arrivals are distributed from the endpoint's perspective",
+		Long: `Poll the best block hash and latest mined epoch at a fixed interval to measure how block arrivals are
+distributed from the endpoint's perspective, so as to detect bursty or stalled block delivery, e.g. behind caches.`,
+		Run: pollBestBlock,
+	}
+
+	cmd.Flags().DurationVar(&bestBlockFlags.Interval, "interval", 100*time.Millisecond, "Interval to poll the best block")
+	cmd.Flags().DurationVar(&bestBlockFlags.Duration, "duration", time.Minute, "Duration to poll the best block")
+	cmd.Flags().DurationVar(&bestBlockFlags.StallThreshold, "stall-threshold", 5*time.Second, "Arrival interval regarded as stalled delivery")
+
+	return &cmd
+}
+
+// BestBlockStat is the distribution of best block arrivals observed by polling.
+type BestBlockStat struct {
+	NumPolls   int
+	NumErrors  int
+	NumArrived int // number of times best block changed
+
+	Intervals   stats.LatencyStat // intervals between arrivals
+	Latency     stats.LatencyStat // latency of polling
+	EpochJumps  map[uint64]int    // number of arrivals by epochs advanced, where more than 1 indicates bursty delivery
+	NumBursts   int               // arrivals that advanced more than 1 epoch
+	NumStalls   int               // arrival intervals beyond stall threshold
+	NumReverted int               // arrivals that the latest mined epoch decreased
+}
+
+func pollBestBlock(*cobra.Command, []string) {
+	client := mustNewClient()
+	defer client.Close()
+
+	stat := BestBlockStat{EpochJumps: make(map[uint64]int)}
+
+	var lastHash types.Hash
+	var lastEpoch uint64
+	var lastArrival time.Time
+
+	ticker := time.NewTicker(bestBlockFlags.Interval)
+	defer ticker.Stop()
+
+	for start := time.Now(); time.Since(start) < bestBlockFlags.Duration; <-ticker.C {
+		stat.NumPolls++
+
+		pollStart := time.Now()
+
+		hash, err := client.GetBestBlockHash()
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to get best block hash")
+			stat.NumErrors++
+			continue
+		}
+
+		epoch, err := client.GetEpochNumber(types.EpochLatestMined)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to get latest mined epoch")
+			stat.NumErrors++
+			continue
+		}
+
+		now := time.Now()
+		stat.Latency.Add(now.Sub(pollStart))
+
+		if hash == lastHash {
+			continue
+		}
+
+		epochNumber := epoch.ToInt().Uint64()
+
+		if !lastArrival.IsZero() {
+			interval := now.Sub(lastArrival)
+			stat.NumArrived++
+			stat.Intervals.Add(interval)
+
+			if interval > bestBlockFlags.StallThreshold {
+				logrus.WithField("interval", interval).Warn("Block delivery stalled")
+				stat.NumStalls++
+			}
+
+			if epochNumber < lastEpoch {
+				logrus.WithFields(logrus.Fields{
+					"from": lastEpoch,
+					"to":   epochNumber,
+				}).Warn("Latest mined epoch reverted")
+				stat.NumReverted++
+			} else if jump := epochNumber - lastEpoch; jump > 1 {
+				stat.EpochJumps[jump]++
+				stat.NumBursts++
+			} else {
+				stat.EpochJumps[jump]++
+			}
+		}
+
+		lastHash, lastEpoch, lastArrival = hash, epochNumber, now
+	}
+
+	printResult(&stat)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sirupsen/logrus"
+)
+
+const bloomBytes = 256
+
+// logsBloom is the 2048-bit bloom filter of log addresses and topics.
+type logsBloom [bloomBytes]byte
+
+// add sets the 3 bits of data, which are taken from the first 6 bytes of keccak256 hash.
+func (b *logsBloom) add(data []byte) {
+	hash := crypto.Keccak256(data)
+
+	for i := 0; i < 6; i += 2 {
+		bit := (uint(hash[i])<<8 | uint(hash[i+1])) & (bloomBytes*8 - 1)
+		b[bloomBytes-1-bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// contains returns true if all bits of the other bloom are set.
+func (b *logsBloom) contains(other *logsBloom) bool {
+	for i := range b {
+		if b[i]&other[i] != other[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bloomOf computes the bloom of receipt logs.
+func bloomOf(logs []types.Log) logsBloom {
+	var bloom logsBloom
+
+	for i := range logs {
+		bloom.add(logs[i].Address.MustGetCommonAddress().Bytes())
+
+		for _, topic := range logs[i].Topics {
+			bloom.add(common.HexToHash(string(topic)).Bytes())
+		}
+	}
+
+	return bloom
+}
+
+// BloomResult is the result of verifying logs bloom of receipts.
+type BloomResult struct {
+	Checks     int
+	Mismatches int // blooms missing bits of logs, which breaks log filtering
+	Inexact    int // blooms with extra bits than recomputed, which only causes false positives
+}
+
+func (result *BloomResult) Add(other BloomResult) {
+	result.Checks += other.Checks
+	result.Mismatches += other.Mismatches
+	result.Inexact += other.Inexact
+}
+
+// VerifyLogsBloom recomputes the logs bloom of each receipt from its logs, and compares it against the bloom
+// served by fullnode. Note, block header only has the hash of deferred logs bloom, so blooms are verified
+// against receipts instead.
+func VerifyLogsBloom(epochNumber uint64, receipts [][]types.TransactionReceipt) (result BloomResult) {
+	for _, blockReceipts := range receipts {
+		for _, receipt := range blockReceipts {
+			result.Checks++
+
+			expected := bloomOf(receipt.Logs)
+
+			var actual logsBloom
+			served, err := hexutil.Decode(string(receipt.LogsBloom))
+			if err == nil && len(served) == bloomBytes {
+				copy(actual[:], served)
+			}
+
+			if bytes.Equal(actual[:], expected[:]) {
+				continue
+			}
+
+			if !actual.contains(&expected) {
+				logrus.WithFields(logrus.Fields{
+					"epoch": epochNumber,
+					"block": receipt.BlockHash,
+					"tx":    receipt.TransactionHash,
+					"logs":  len(receipt.Logs),
+					"curl":  curl("cfx_getTransactionReceipt", receipt.TransactionHash),
+				}).Warn("Logs bloom of receipt missing bits of logs")
+				result.Mismatches++
+			} else {
+				result.Inexact++
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// testBloomReceipt is a mainnet receipt with 7 logs of 3 contracts.
+const testBloomReceipt = `{"transactionHash":"0xa2c678cc97e07ce060b71f87ac65e68d482abf8e1a93b7d1bc425504c4584ca7","logs":[{"address":"cfx:acg158kvr8zanb1bs048ryb6rtrhr283ma70vz70tx","topics":["0x2fe5be0146f74c5bce36c0b80911af6c7d86ff27e89d5cfa61fc681327954e5d","0x00000000000000000000000080ae6a88ce3351e9f729e8199f2871ba786ad7c5","0x0000000000000000000000008d545118d91c027c805c552f63a5c00a20ae6aca"],"data":"0x00000000000000000000000000000000000000000000003b16c9e8eeb7c800000000000000000000000000000000000000000000000000000000000000000060000000000000000000000000000000000000000000000000000000000000008000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"},{"address":"cfx:acg158kvr8zanb1bs048ryb6rtrhr283ma70vz70tx","topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef","0x0000000000000000000000000000000000000000000000000000000000000000","0x0000000000000000000000008d545118d91c027c805c552f63a5c00a20ae6aca"],"data":"0x00000000000000000000000000000000000000000000003b16c9e8eeb7c80000"},{"address":"cfx:acg158kvr8zanb1bs048ryb6rtrhr283ma70vz70tx","topics":["0x68051bc50b1ef1654bf1e6204b5f8fa9badcd038e00fa5b43f21f898fc2728ca","0x0000000000000000000000008d545118d91c027c805c552f63a5c00a20ae6aca","0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"],"data":"0x00000000000000000000000000000000000000000000003b16c9e8eeb7c80000"},{"address":"cfx:acf2rcsh8payyxpg6xj7b0ztswwh81ute60tsw35j7","topics":["0x06b541ddaa720db2b10a4d0cdac39b8d360425fc073085fac19bc82614677987","0x0000000000000000000000008d545118d91c027c805c552f63a5c00a20ae6aca","0x0000000000000000000000008d545118d91c027c805c552f63a5c00a20ae6aca","0x0000000000000000000000001cc102d68778496087036aea677b745597f292d3"],"data":"0x000000000000000000000000000000000000000000000019ceb8990635656bbf0000000000000000000000000000000000000000000000000000000000000060000000000000000000000000000000000000000000000000000000000000008000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"},{"address":"cfx:acf2rcsh8payyxpg6xj7b0ztswwh81ute60tsw35j7","topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef","0x0000000000000000000000008d545118d91c027c805c552f63a5c00a20ae6aca","0x0000000000000000000000001cc102d68778496087036aea677b745597f292d3"],"data":"0x000000000000000000000000000000000000000000000019ceb8990635656bbf"},{"address":"cfx:acgzjyj25esae9eanvmw827f2afcbnxm3jr3784tyu","topics":["0x1c411e9a96e071241c2f21f7726b17ae89e3cab4c78be50e062b03a9fffbbad1"],"data":"0x000000000000000000000000000000000000000000003a4a9c46eba30a4fb89e00000000000000000000000000000000000000000000854b7ce25c8407e77655"},{"address":"cfx:acgzjyj25esae9eanvmw827f2afcbnxm3jr3784tyu","topics":["0xd78ad95fa46c994b6551d0da85fc275fe613ce37657fb8d5e3d130840159d822","0x00000000000000000000000080ae6a88ce3351e9f729e8199f2871ba786ad7c5","0x0000000000000000000000001cc102d68778496087036aea677b745597f292d3"],"data":"0x000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000003b16c9e8eeb7c80000000000000000000000000000000000000000000000000019ceb8990635656bbf0000000000000000000000000000000000000000000000000000000000000000"}],"logsBloom":"0x0020000000000000000000008000000000000808000000000000000000000000804000000000200040080000009000000000000000000000000000000008000000000000000010000000000a00000020000000000000000000000000000000000000000002004000000000000000080004000040000000000000001000000000000008000020000000001100000002000000000000000018000000400000000000000000012000000000000000000000000000000000000000000000000000000000000200000010000004000000000000000000000000100000a000000020000000000000000400000000000000000000000000100000000000000000040000"}`
+
+func TestVerifyLogsBloom(t *testing.T) {
+	var receipt types.TransactionReceipt
+	if err := json.Unmarshal([]byte(testBloomReceipt), &receipt); err != nil {
+		t.Fatal(err)
+	}
+
+	served, err := hexutil.Decode(string(receipt.LogsBloom))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withBloom := func(bloom []byte) types.TransactionReceipt {
+		result := receipt
+		result.LogsBloom = types.Bloom(hexutil.Encode(bloom))
+		return result
+	}
+
+	extraBit := append([]byte(nil), served...)
+	extraBit[0] |= 0x80 // not set by logs of receipt
+
+	missingBit := append([]byte(nil), served...)
+	for i := range missingBit {
+		if missingBit[i] != 0 {
+			missingBit[i] &= missingBit[i] - 1 // clear the lowest bit set
+			break
+		}
+	}
+
+	noLogs := types.TransactionReceipt{LogsBloom: types.Bloom(hexutil.Encode(make([]byte, bloomBytes)))}
+
+	for _, tc := range []struct {
+		name     string
+		receipt  types.TransactionReceipt
+		expected BloomResult
+	}{
+		{"served", receipt, BloomResult{Checks: 1}},
+		{"no logs", noLogs, BloomResult{Checks: 1}},
+		{"extra bit", withBloom(extraBit), BloomResult{Checks: 1, Inexact: 1}},
+		{"missing bit", withBloom(missingBit), BloomResult{Checks: 1, Mismatches: 1}},
+		{"empty", withBloom(make([]byte, bloomBytes)), BloomResult{Checks: 1, Mismatches: 1}},
+		{"malformed", withBloom(served[1:]), BloomResult{Checks: 1, Mismatches: 1}},
+	} {
+		if actual := VerifyLogsBloom(1, [][]types.TransactionReceipt{{tc.receipt}}); actual != tc.expected {
+			t.Errorf("%v: expected %+v, but got %+v", tc.name, tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"text/template"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+	"github.com/boqiu/go-test/pkg/expr"
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var callFlags struct {
+	File        string
+	Script      string
+	NumRequests int
+	EpochRange  uint64
+	Total       int
+	QPS         float64
+}
+
+func newCallCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "call",
+		Short: "Benchmark arbitrary JSON-RPC methods defined in file",
+		Long: `Benchmark arbitrary JSON-RPC methods defined in a JSON file, e.g.
+
+[
+    {"method": "cfx_getBlockByEpochNumber", "params": ["{{hex .Epoch}}", false]},
+    {"method": "cfx_getEpochReceipts", "params": ["{{hex .Epoch}}"]}
+]
+
+Params are rendered as Go template for each request, where .Epoch is a random epoch
+number within the range that ends at the latest finalized epoch.
+
+Methods could be assigned with weights to simulate a mixed workload, e.g.
+
+[
+    {"method": "cfx_getLogs", "weight": 70, "params": [...]},
+    {"method": "cfx_getBlockByHash", "weight": 20, "params": [...]},
+    {"method": "cfx_getEpochReceipts", "weight": 10, "params": [...]}
+]
+
+in which case requests are randomly generated to match the weights.
+
+Responses could be validated by a check expression, e.g.
+
+[
+    {"method": "cfx_getBlockByEpochNumber", "params": ["{{hex .Epoch}}", false],
+     "check": "result.transactions.length >= 0 && result.epochNumber > 0 && latency < 500ms"}
+]
+
+in which result.<path> refers to numeric values in result by keys or array indexes, with hex
+quantities decoded, and result.<path>.length refers to the length of array, object or string.
+
+A task could be scripted with follow-up steps that depend on the previous response, e.g.
+
+[
+    {"method": "cfx_getBlockByEpochNumber", "params": ["{{hex .Epoch}}", false],
+     "steps": [
+        {"method": "cfx_getTransactionReceipt", "params": ["{{index .Result.transactions 0}}"],
+         "when": "result.transactions.length > 0", "check": "result.outcomeStatus == 0"}
+     ]}
+]
+
+in which .Result refers to the decoded result of the previous call in params template, and the
+step is skipped unless the when expression over the previous response holds.
+
+For custom logic beyond steps, each task could be scripted in Starlark instead, e.g.
+
+def task(epoch):
+    blocks = call("cfx_getBlocksByEpoch", hex(epoch))
+    for hash in blocks:
+        block = call("cfx_getBlockByHash", hash, False)
+        check(int(block["epochNumber"], 16) == epoch, "epoch mismatch")
+        check(latency() < 500)
+
+in which the script defines function task(epoch) called with a random epoch for each task, and
+the builtins are:
+  call(method, *params)      calls RPC method and returns the result decoded as JSON
+  check(condition, message)  fails the task and archives the last response unless condition holds
+  latency()                  latency of the last call in milliseconds
+  hex(n)                     encodes integer as hex quantity
+  json                       module to encode and decode JSON`,
+		Run: benchCall,
+	}
+
+	cmd.Flags().StringVar(&callFlags.File, "file", "", "JSON file that defines RPC methods and params template")
+	cmd.Flags().StringVar(&callFlags.Script, "script", "", "Starlark file that defines function task(epoch) to execute for each task")
+	cmd.Flags().IntVar(&callFlags.NumRequests, "count", 100, "Number of requests for each RPC method, or number of tasks for script")
+	cmd.Flags().Uint64Var(&callFlags.EpochRange, "epoch-range", 10000, "Range of epochs to randomly substitute in params")
+	cmd.Flags().IntVar(&callFlags.Total, "total", 0, "Total number of requests for weighted workload, by default count × number of methods")
+	cmd.Flags().Float64Var(&callFlags.QPS, "qps", 0, "Target QPS to issue requests regardless of response latency, 0 to issue by threads")
+	cmd.MarkFlagsOneRequired("file", "script")
+	cmd.MarkFlagsMutuallyExclusive("file", "script")
+
+	return &cmd
+}
+
+func benchCall(*cobra.Command, []string) {
+	var requests []RpcRequest
+	var script *callScript
+	var err error
+	if len(callFlags.Script) > 0 {
+		if script, err = loadCallScript(callFlags.Script); err != nil {
+			fatal(ExitConfig, logrus.WithError(err), "Failed to load script")
+		}
+	} else if requests, err = loadCallRequests(callFlags.File); err != nil {
+		fatal(ExitConfig, logrus.WithError(err), "Failed to load RPC requests")
+	}
+
+	client := mustNewClient()
+	defer client.Close()
+
+	clients := mustNewShardClients(client)
+	for _, v := range clients[1:] {
+		defer v.Close()
+	}
+
+	latestFinalizedEpoch, err := client.GetEpochNumber(types.EpochLatestFinalized)
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get latest epoch number")
+	}
+
+	start := time.Now()
+	stat := CallRpcStat{
+		clients:    clients,
+		requests:   requests,
+		script:     script,
+		schedule:   scheduleCallRequests(requests),
+		epochTo:    latestFinalizedEpoch.ToInt().Uint64(),
+		Latencies:  make(stats.LatencyStats),
+		Errors:     make(map[string]int),
+		ErrorCodes: make(stats.ErrorStats),
+		Timeouts:   &timeoutHistogram,
+
+		CheckFailures: make(map[string]int),
+		Curls:         make(map[string]string),
+	}
+
+	if callFlags.QPS > 0 {
+		load, err := RunOpenLoop(context.Background(), &stat, len(stat.schedule), callFlags.QPS)
+		if err != nil {
+			fatal(ExitFailure, logrus.WithError(err), "Failed to execute RPC requests at target QPS")
+		}
+		stat.Load = &load
+	} else if err = parallel.Serial(context.Background(), &stat, len(stat.schedule), flags.ParallelOption); err != nil {
+		fatal(ExitFailure, logrus.WithError(err), "Failed to parallel execute RPC requests")
+	}
+
+	printResult(stat)
+
+	printInfo("Total elapsed: %v", time.Since(start))
+}
+
+// RpcCall is an RPC method to call along with the params template and optional expressions.
+type RpcCall struct {
+	Method string
+	Params json.RawMessage
+	Check  string // optional expression to validate response
+	When   string // optional expression over the previous response to decide whether to call
+
+	params *template.Template
+	check  *expr.Expr
+	when   *expr.Expr
+}
+
+// RpcRequest is an RPC method to benchmark, which may be followed by steps that depend on the response.
+type RpcRequest struct {
+	RpcCall
+	Weight float64   // relative weight in mixed workload
+	Steps  []RpcCall // follow-up calls in the same task
+}
+
+// callData is the data to render params template.
+type callData struct {
+	Epoch  uint64 // random epoch of task
+	Result any    // decoded result of the previous call in task
+}
+
+// loadCallRequests loads RPC requests from a JSON file and parses the params template.
+func loadCallRequests(file string) ([]RpcRequest, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to read file")
+	}
+
+	var requests []RpcRequest
+	if err = json.Unmarshal(content, &requests); err != nil {
+		return nil, errors.WithMessage(err, "Failed to unmarshal requests")
+	}
+
+	if len(requests) == 0 {
+		return nil, errors.New("No request defined")
+	}
+
+	for i, v := range requests {
+		if v.Weight < 0 {
+			return nil, errors.Errorf("Negative weight of %v", v.Method)
+		}
+
+		if len(v.When) > 0 {
+			return nil, errors.Errorf("Condition not allowed for the first call of %v", v.Method)
+		}
+
+		if err = requests[i].parse(i); err != nil {
+			return nil, err
+		}
+
+		for j := range v.Steps {
+			if err = requests[i].Steps[j].parse(j); err != nil {
+				return nil, errors.WithMessagef(err, "Invalid step of %v", v.Method)
+			}
+		}
+	}
+
+	return requests, nil
+}
+
+// parse parses the params template and expressions of RPC call.
+func (call *RpcCall) parse(index int) (err error) {
+	if call.Method == "" {
+		return errors.Errorf("Method not specified for request %v", index)
+	}
+
+	params := "[]"
+	if len(call.Params) > 0 {
+		params = string(call.Params)
+	}
+
+	funcs := template.FuncMap{
+		"hex": hexutil.EncodeUint64,
+	}
+
+	if call.params, err = template.New(call.Method).Funcs(funcs).Parse(params); err != nil {
+		return errors.WithMessagef(err, "Failed to parse params template of %v", call.Method)
+	}
+
+	if call.check, err = parseCheck(call.Check); err != nil {
+		return errors.WithMessagef(err, "Invalid check of %v", call.Method)
+	}
+
+	if call.when, err = parseCheck(call.When); err != nil {
+		return errors.WithMessagef(err, "Invalid condition of %v", call.Method)
+	}
+
+	return nil
+}
+
+// scheduleCallRequests returns the request index of each task, which is either round robin for all methods,
+// or randomly generated to match the weights if any method is weighted. For script, all tasks are scheduled
+// to index 0 which is not used.
+func scheduleCallRequests(requests []RpcRequest) []int {
+	var totalWeight float64
+	for _, v := range requests {
+		totalWeight += v.Weight
+	}
+
+	total := callFlags.Total
+	if total <= 0 {
+		total = callFlags.NumRequests * max(len(requests), 1)
+	}
+
+	if len(requests) == 0 {
+		return make([]int, total)
+	}
+
+	schedule := make([]int, total)
+
+	for i := range schedule {
+		if totalWeight == 0 {
+			schedule[i] = i % len(requests)
+			continue
+		}
+
+		// pick a request by weight
+		point := rand.Float64() * totalWeight
+		for j, v := range requests {
+			if point < v.Weight || j == len(requests)-1 {
+				schedule[i] = j
+				break
+			}
+
+			point -= v.Weight
+		}
+	}
+
+	return schedule
+}
+
+// render renders the params template with the given data.
+func (call *RpcCall) render(data callData) ([]any, error) {
+	var buf bytes.Buffer
+	if err := call.params.Execute(&buf, data); err != nil {
+		return nil, errors.WithMessage(err, "Failed to render params")
+	}
+
+	var params []any
+	if err := json.Unmarshal(buf.Bytes(), &params); err != nil {
+		return nil, errors.WithMessagef(err, "Failed to unmarshal rendered params %v", buf.String())
+	}
+
+	return params, nil
+}
+
+// do renders params, calls the RPC method and validates the response if check specified.
+func (call *RpcCall) do(client *sdk.Client, latency stats.MethodLatency, data callData) (json.RawMessage, time.Duration, error) {
+	params, err := call.render(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var result json.RawMessage
+	start := time.Now()
+	if err = latency.Measure(call.Method, func() error {
+		return client.CallRPC(&result, call.Method, params...)
+	}); err != nil {
+		return nil, 0, errors.WithMessagef(err, "Failed to call %v", call.Method)
+	}
+	elapsed := time.Since(start)
+
+	if call.check != nil {
+		passed, err := call.check.Eval(responseVars(result, elapsed))
+		if err != nil || !passed {
+			archiveResponse(report.ArchiveEntry{
+				Method: call.Method,
+				Params: params,
+				Reason: "check failed: " + call.check.String(),
+			}, result)
+
+			return nil, 0, &curlError{curl(call.Method, params...), &checkError{call.check.String(), err}}
+		}
+	}
+
+	return result, elapsed, nil
+}
+
+// callError attaches the method of the failed call in task.
+type callError struct {
+	method string
+	err    error
+}
+
+func (e *callError) Error() string { return e.err.Error() }
+func (e *callError) Cause() error  { return e.err }
+func (e *callError) Unwrap() error { return e.err }
+
+type CallRpcStat struct {
+	clients  []*sdk.Client // requests are sharded across clients by task
+	requests []RpcRequest
+	script   *callScript // executed for each task instead of requests if any
+	schedule []int       // request index by task
+	epochTo  uint64
+
+	Latencies stats.LatencyStats
+	Load      *OpenLoopStat `json:",omitempty"`
+
+	NumErrors  int
+	Errors     map[string]int   `json:",omitempty"` // number of errors by method
+	ErrorCodes stats.ErrorStats `json:",omitempty"`
+	Timeouts   *stats.TimeoutHistogram
+
+	NumCheckFailures int
+	CheckFailures    map[string]int `json:",omitempty"` // number of check failures by method
+
+	Curls map[string]string `json:",omitempty"` // curl command to reproduce the first failure by method
+}
+
+func (stat *CallRpcStat) ParallelDo(ctx context.Context, routine, task int) (stats.MethodLatency, error) {
+	latency := stats.NewMethodLatency(flags.Jitter)
+	client := shardClient(stat.clients, task)
+
+	data := callData{}
+	data.Epoch, _ = randomWindow(stat.epochTo, callFlags.EpochRange, 1)
+
+	if stat.script != nil {
+		return latency, stat.script.run(client, latency, data.Epoch)
+	}
+
+	request := &stat.requests[stat.schedule[task]]
+
+	result, elapsed, err := request.do(client, latency, data)
+	if err != nil {
+		return latency, &callError{request.Method, err}
+	}
+
+	for i := range request.Steps {
+		step := &request.Steps[i]
+
+		if step.when != nil {
+			if ok, err := step.when.Eval(responseVars(result, elapsed)); err != nil {
+				return latency, &callError{step.Method, errors.WithMessagef(err, "Failed to evaluate condition %v", step.when)}
+			} else if !ok {
+				continue
+			}
+		}
+
+		if data.Result, err = decodeResult(result); err != nil {
+			return latency, &callError{step.Method, err}
+		}
+
+		if result, elapsed, err = step.do(client, latency, data); err != nil {
+			return latency, &callError{step.Method, err}
+		}
+	}
+
+	return latency, nil
+}
+
+func (stat *CallRpcStat) ParallelCollect(ctx context.Context, result *parallel.Result[stats.MethodLatency]) error {
+	stat.Latencies.Add(result.Value)
+
+	if result.Err == nil {
+		return nil
+	}
+
+	method := "script"
+	if stat.script == nil {
+		method = stat.requests[stat.schedule[result.Task]].Method
+	}
+
+	var callErr *callError
+	if errors.As(result.Err, &callErr) {
+		method = callErr.method
+	}
+
+	var checkErr *checkError
+	if errors.As(result.Err, &checkErr) {
+		logrus.WithError(result.Err).WithField("method", method).Warn("Failed to check RPC response")
+		stat.NumCheckFailures++
+		stat.CheckFailures[method]++
+	} else {
+		logrus.WithError(result.Err).WithField("method", method).Warn("Failed to call RPC")
+		stat.NumErrors++
+		stat.Errors[method]++
+		stat.ErrorCodes.Add(result.Err)
+	}
+
+	if _, ok := stat.Curls[method]; !ok {
+		if curl := curlOf(result.Err); len(curl) > 0 {
+			stat.Curls[method] = curl
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/boqiu/go-test/pkg/chaos"
+	"github.com/sirupsen/logrus"
+)
+
+var chaosProxies = struct {
+	mu      sync.Mutex
+	proxies map[string]*chaos.Proxy // target url -> proxy
+	urls    map[string]string       // target url -> proxy url
+}{
+	proxies: make(map[string]*chaos.Proxy),
+	urls:    make(map[string]string),
+}
+
+// chaosUrl returns the URL of fault injection proxy to route requests to the given endpoint when chaos mode enabled,
+// otherwise the endpoint itself.
+func chaosUrl(url string) string {
+	if !flags.Chaos.Enabled() {
+		return url
+	}
+
+	chaosProxies.mu.Lock()
+	defer chaosProxies.mu.Unlock()
+
+	if proxyUrl, ok := chaosProxies.urls[url]; ok {
+		return proxyUrl
+	}
+
+	proxy := chaos.NewProxy(url, flags.Chaos)
+
+	proxyUrl, err := proxy.Start()
+	if err != nil {
+		fatal(ExitConfig, logrus.WithError(err).WithField("url", url), "Failed to start chaos proxy")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"url":   url,
+		"proxy": proxyUrl,
+	}).Info("Route requests via chaos proxy")
+
+	chaosProxies.proxies[url] = proxy
+	chaosProxies.urls[url] = proxyUrl
+
+	return proxyUrl
+}
+
+// reportChaos outputs the faults injected by chaos proxies if any.
+func reportChaos() {
+	chaosProxies.mu.Lock()
+	defer chaosProxies.mu.Unlock()
+
+	for _, proxy := range chaosProxies.proxies {
+		stat := proxy.Stat()
+		printInfo("Chaos proxy of %v: requests = %v, dropped = %v, delayed = %v, malformed = %v, errors = %v",
+			stat.Target, stat.NumRequests, stat.NumDropped, stat.NumDelayed, stat.NumMalformed, stat.NumErrors)
+	}
+}
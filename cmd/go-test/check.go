@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boqiu/go-test/pkg/expr"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+// checkError indicates that the RPC response does not satisfy the check expression.
+type checkError struct {
+	expr string
+	err  error // error to evaluate expression if any
+}
+
+func (e *checkError) Error() string {
+	if e.err != nil {
+		return "Failed to evaluate check " + e.expr + ": " + e.err.Error()
+	}
+
+	return "Check failed: " + e.expr
+}
+
+// responseVars resolves variables of an RPC response to evaluate check expression, including:
+//   - latency: latency of request in nanoseconds.
+//   - result.<path>: numeric value in result by keys or array indexes, where hex quantities are decoded,
+//     booleans are evaluated to 0 or 1, and null is evaluated to 0.
+//   - result.<path>.length: length of array, object or string in result.
+func responseVars(result json.RawMessage, latency time.Duration) expr.Vars {
+	decoded, decodeErr := decodeResult(result)
+
+	return func(name string) (float64, bool) {
+		if name == "latency" {
+			return float64(latency), true
+		}
+
+		path, ok := strings.CutPrefix(name+".", "result.")
+		if !ok || decodeErr != nil {
+			return 0, false
+		}
+
+		value := decoded
+		for _, key := range strings.Split(strings.TrimSuffix(path, "."), ".") {
+			if len(key) == 0 {
+				continue
+			}
+
+			switch v := value.(type) {
+			case map[string]any:
+				if key == "length" {
+					if _, ok := v[key]; !ok {
+						return float64(len(v)), true
+					}
+				}
+
+				if value, ok = v[key]; !ok {
+					return 0, false
+				}
+			case []any:
+				if key == "length" {
+					return float64(len(v)), true
+				}
+
+				index, err := strconv.Atoi(key)
+				if err != nil || index < 0 || index >= len(v) {
+					return 0, false
+				}
+
+				value = v[index]
+			case string:
+				if key == "length" {
+					return float64(len(v)), true
+				}
+
+				return 0, false
+			default:
+				return 0, false
+			}
+		}
+
+		return numericValue(value)
+	}
+}
+
+// decodeResult decodes the RPC response result, in which numbers are kept as json.Number.
+func decodeResult(result json.RawMessage) (any, error) {
+	var decoded any
+	decoder := json.NewDecoder(bytes.NewReader(result))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, errors.WithMessage(err, "Failed to decode result")
+	}
+
+	return decoded, nil
+}
+
+// numericValue converts a JSON value to number if possible.
+func numericValue(value any) (float64, bool) {
+	switch v := value.(type) {
+	case nil:
+		return 0, true
+	case bool:
+		if v {
+			return 1, true
+		}
+
+		return 0, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		if n, err := hexutil.DecodeBig(v); err == nil {
+			f, _ := n.Float64()
+			return f, true
+		}
+
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// parseCheck parses the check expression of RPC request if any.
+func parseCheck(source string) (*expr.Expr, error) {
+	if len(source) == 0 {
+		return nil, nil
+	}
+
+	e, err := expr.Parse(source)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to parse check %v", source)
+	}
+
+	for _, name := range e.Vars() {
+		if name != "latency" && name != "result" && !strings.HasPrefix(name, "result.") {
+			return nil, errors.Errorf("Unknown variable %v in check %v", name, source)
+		}
+	}
+
+	return e, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var clockFlags struct {
+	Samples  int
+	Interval time.Duration
+}
+
+func newClockCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "clock",
+		Short: "Estimate the clock skew between local machine and fullnode from the latest block timestamps",
+		Long: `Estimate the clock skew between local machine and fullnode from the latest block timestamps.
+
+The offset of each new block is the local time when observed minus the block timestamp, which includes
+the block propagation delay and the truncation of timestamp in seconds. So, the minimum offset is the
+estimated skew, in which positive value means local clock is ahead of the block timestamps, and negative
+value always indicates that local clock is behind.`,
+		Run: estimateClockSkew,
+	}
+
+	cmd.Flags().IntVar(&clockFlags.Samples, "samples", 20, "Number of new blocks to sample")
+	cmd.Flags().DurationVar(&clockFlags.Interval, "interval", time.Second, "Interval to poll the latest mined block")
+
+	return &cmd
+}
+
+// ClockSkewStat is the offsets of local time against block timestamps.
+type ClockSkewStat struct {
+	Samples       int
+	MinOffset     time.Duration
+	MedianOffset  time.Duration
+	MaxOffset     time.Duration
+	EstimatedSkew time.Duration
+	NumErrors     int
+}
+
+func estimateClockSkew(*cobra.Command, []string) {
+	if clockFlags.Samples <= 0 {
+		fatal(ExitConfig, logrus.NewEntry(logrus.StandardLogger()), "Number of samples should be positive")
+	}
+
+	client := mustNewClient()
+	defer client.Close()
+
+	var stat ClockSkewStat
+	var offsets []time.Duration
+	var lastPivot types.Hash
+
+	ticker := time.NewTicker(clockFlags.Interval)
+	defer ticker.Stop()
+
+	for ; len(offsets) < clockFlags.Samples; <-ticker.C {
+		start := time.Now()
+		pivot, err := getPivotBlock(client, types.EpochLatestMined)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to get the latest mined block")
+			stat.NumErrors++
+			continue
+		}
+
+		// midpoint of request to offset the network latency
+		observed := start.Add(time.Since(start) / 2)
+
+		if pivot.Hash == lastPivot || pivot.Timestamp == nil {
+			continue
+		}
+		lastPivot = pivot.Hash
+
+		offset := observed.Sub(time.Unix(pivot.Timestamp.ToInt().Int64(), 0))
+		offsets = append(offsets, offset)
+
+		logrus.WithFields(logrus.Fields{
+			"block":  pivot.Hash,
+			"offset": offset,
+		}).Debug("New block observed")
+	}
+
+	slices.Sort(offsets)
+
+	stat.Samples = len(offsets)
+	stat.MinOffset = offsets[0]
+	stat.MedianOffset = offsets[len(offsets)/2]
+	stat.MaxOffset = offsets[len(offsets)-1]
+	stat.EstimatedSkew = stat.MinOffset
+
+	if stat.EstimatedSkew < -time.Second {
+		logrus.WithField("skew", stat.EstimatedSkew).Warn("Local clock is behind the block timestamps")
+	}
+
+	printResult(stat)
+}
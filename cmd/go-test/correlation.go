@@ -0,0 +1,134 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/boqiu/go-test/pkg/fetch"
+	"github.com/pkg/errors"
+)
+
+// EpochSize is the payload size of an epoch along with the latency to fetch the epoch data.
+type EpochSize struct {
+	Epoch   uint64
+	Txs     int
+	Logs    int
+	Bytes   int // size of blocks, receipts and traces in JSON
+	Latency time.Duration
+}
+
+// epochBytes returns the size of blocks, receipts and traces in JSON, which approximates the response size.
+// In streaming mode, receipts and traces are not retained, so the decoded size is counted instead.
+func epochBytes(data *fetch.EpochData) int {
+	var size int
+	if data.Counts != nil {
+		size += data.Counts.Bytes
+	}
+
+	for _, v := range []any{data.Blocks, data.Receipts, data.Traces} {
+		if encoded, err := json.Marshal(v); err == nil {
+			size += len(encoded)
+		}
+	}
+
+	return size
+}
+
+// SizeCorrelation correlates the epoch payload size with fetch latency, so as to distinguish server
+// slowness from merely large responses.
+type SizeCorrelation struct {
+	samples []EpochSize
+}
+
+func (c *SizeCorrelation) Add(size EpochSize) {
+	c.samples = append(c.samples, size)
+}
+
+// WriteCSV writes samples to a scatter-ready CSV file in epoch order, regardless of the collection order,
+// e.g. epochs retried at the end.
+func (c *SizeCorrelation) WriteCSV(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to create file")
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"epoch", "txs", "logs", "bytes", "latency_ms"})
+
+	samples := slices.Clone(c.samples)
+	slices.SortStableFunc(samples, func(a, b EpochSize) int { return cmp.Compare(a.Epoch, b.Epoch) })
+
+	for _, v := range samples {
+		w.Write([]string{
+			strconv.FormatUint(v.Epoch, 10),
+			strconv.Itoa(v.Txs),
+			strconv.Itoa(v.Logs),
+			strconv.Itoa(v.Bytes),
+			strconv.FormatFloat(float64(v.Latency)/float64(time.Millisecond), 'f', 3, 64),
+		})
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the Pearson correlation coefficients
+// between latency and each size metric.
+func (c *SizeCorrelation) MarshalJSON() ([]byte, error) {
+	latencies := make([]float64, len(c.samples))
+	txs := make([]float64, len(c.samples))
+	logs := make([]float64, len(c.samples))
+	bytes := make([]float64, len(c.samples))
+
+	for i, v := range c.samples {
+		latencies[i] = float64(v.Latency)
+		txs[i] = float64(v.Txs)
+		logs[i] = float64(v.Logs)
+		bytes[i] = float64(v.Bytes)
+	}
+
+	return json.Marshal(struct {
+		NumSamples int
+		Txs        float64
+		Logs       float64
+		Bytes      float64
+	}{len(c.samples), pearson(txs, latencies), pearson(logs, latencies), pearson(bytes, latencies)})
+}
+
+// pearson returns the Pearson correlation coefficient of two series, or 0 if undefined.
+func pearson(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+
+	return cov / math.Sqrt(varX*varY)
+}
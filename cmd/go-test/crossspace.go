@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/openweb3/web3go"
+	ethTypes "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// crossSpaceCall is a call or create from core space into eSpace observed in core space traces,
+// which is expected to be packed as a phantom transaction in the eSpace block of the same epoch.
+type crossSpaceCall struct {
+	to      string // hex address, empty for contract creation
+	value   *big.Int
+	input   []byte
+	success bool
+}
+
+// crossSpaceCalls returns the cross-space calls in traces, along with the value of withdrawals from
+// mapped accounts that are packed as phantom transactions in eSpace too.
+func crossSpaceCalls(traces []*types.LocalizedBlockTrace) (calls []crossSpaceCall, withdrawals []*big.Int) {
+	type frame struct {
+		space types.SpaceType
+		call  int // index of cross-space call, -1 if not
+	}
+
+	for _, blockTraces := range traces {
+		if blockTraces == nil {
+			continue
+		}
+
+		for _, txTraces := range blockTraces.TransactionTraces {
+			var stack []frame
+
+			for _, trace := range txTraces.Traces {
+				parentSpace := types.SPACE_NATIVE
+				if len(stack) > 0 {
+					parentSpace = stack[len(stack)-1].space
+				}
+
+				switch action := trace.Action.(type) {
+				case types.Call:
+					current := frame{action.Space, -1}
+					if action.Space == types.SPACE_EVM && parentSpace != types.SPACE_EVM {
+						current.call = len(calls)
+						calls = append(calls, crossSpaceCall{
+							to:    action.To.GetHexAddress(),
+							value: action.Value.ToInt(),
+							input: action.Input,
+						})
+					}
+					stack = append(stack, current)
+				case types.Create:
+					current := frame{action.Space, -1}
+					if action.Space == types.SPACE_EVM && parentSpace != types.SPACE_EVM {
+						current.call = len(calls)
+						calls = append(calls, crossSpaceCall{
+							value: action.Value.ToInt(),
+							input: action.Init,
+						})
+					}
+					stack = append(stack, current)
+				case types.CallResult:
+					if len(stack) > 0 {
+						if top := stack[len(stack)-1]; top.call >= 0 {
+							calls[top.call].success = action.Outcome == types.OUTCOME_SUCCESS
+						}
+						stack = stack[:len(stack)-1]
+					}
+				case types.CreateResult:
+					if len(stack) > 0 {
+						if top := stack[len(stack)-1]; top.call >= 0 {
+							calls[top.call].success = action.Outcome == types.OUTCOME_SUCCESS
+						}
+						stack = stack[:len(stack)-1]
+					}
+				case types.InternalTransferAction:
+					if action.FromSpace == types.SPACE_EVM && action.ToSpace == types.SPACE_NATIVE {
+						withdrawals = append(withdrawals, action.Value.ToInt())
+					}
+				}
+			}
+		}
+	}
+
+	return calls, withdrawals
+}
+
+// CrossSpaceResult is the result of verifying phantom transactions in eSpace against core space traces.
+type CrossSpaceResult struct {
+	Calls            int // cross-space calls in core space traces
+	Phantoms         int // phantom transactions in eSpace block
+	Matched          int
+	Missing          int // cross-space calls without phantom transaction
+	Unmatched        int // phantom transactions without cross-space call or withdrawal
+	StatusMismatches int // phantom receipt status mismatch with the trace outcome
+}
+
+func (result *CrossSpaceResult) Add(other CrossSpaceResult) {
+	result.Calls += other.Calls
+	result.Phantoms += other.Phantoms
+	result.Matched += other.Matched
+	result.Missing += other.Missing
+	result.Unmatched += other.Unmatched
+	result.StatusMismatches += other.StatusMismatches
+}
+
+// VerifyCrossSpace fetches the eSpace block of an epoch that contains cross-space calls, and verifies the
+// phantom transactions and receipts line up with the cross-space calls in core space traces.
+func VerifyCrossSpace(espace *web3go.Client, epochNumber uint64, traces []*types.LocalizedBlockTrace, latency stats.MethodLatency) (result CrossSpaceResult, err error) {
+	calls, withdrawals := crossSpaceCalls(traces)
+	if len(calls) == 0 && len(withdrawals) == 0 {
+		return result, nil
+	}
+
+	result.Calls = len(calls)
+
+	// eSpace block number is the same as core space epoch number
+	var block *ethTypes.Block
+	if err = latency.Measure("eth_getBlockByNumber", func() (err error) {
+		block, err = espace.Eth.BlockByNumber(ethTypes.BlockNumber(epochNumber), true)
+		return
+	}); err != nil {
+		return CrossSpaceResult{}, errors.WithMessage(err, "Failed to get eSpace block by number")
+	}
+
+	if block == nil {
+		return CrossSpaceResult{}, errors.Errorf("eSpace block %v not found", epochNumber)
+	}
+
+	blockNumber := ethTypes.BlockNumberOrHashWithNumber(ethTypes.BlockNumber(epochNumber))
+
+	var receipts []*ethTypes.Receipt
+	if err = latency.Measure("eth_getBlockReceipts", func() (err error) {
+		receipts, err = espace.Eth.BlockReceipts(&blockNumber)
+		return
+	}); err != nil {
+		return CrossSpaceResult{}, errors.WithMessage(err, "Failed to get eSpace block receipts")
+	}
+
+	txStatus := make(map[string]uint64)
+	for _, receipt := range receipts {
+		if receipt != nil && receipt.Status != nil {
+			txStatus[receipt.TransactionHash.Hex()] = *receipt.Status
+		}
+	}
+
+	// phantom transactions are not signed
+	var phantoms []ethTypes.TransactionDetail
+	for _, tx := range block.Transactions.Transactions() {
+		if tx.R != nil && tx.S != nil && tx.R.Sign() == 0 && tx.S.Sign() == 0 {
+			phantoms = append(phantoms, tx)
+		}
+	}
+
+	result.Phantoms = len(phantoms)
+
+	matched := make([]bool, len(phantoms))
+	for _, call := range calls {
+		index := -1
+		for i, tx := range phantoms {
+			if !matched[i] && phantomMatches(tx, call) {
+				index = i
+				break
+			}
+		}
+
+		if index < 0 {
+			logrus.WithFields(logrus.Fields{
+				"epoch": epochNumber,
+				"to":    call.to,
+				"value": call.value,
+			}).Warn("Phantom transaction not found for cross-space call")
+			result.Missing++
+			continue
+		}
+
+		matched[index] = true
+		result.Matched++
+
+		hash := phantoms[index].Hash.Hex()
+		if status, ok := txStatus[hash]; !ok || (status == 1) != call.success {
+			logrus.WithFields(logrus.Fields{
+				"epoch":   epochNumber,
+				"tx":      hash,
+				"success": call.success,
+			}).Warn("Phantom receipt status mismatch with trace")
+			result.StatusMismatches++
+		}
+	}
+
+	result.Unmatched = max(len(phantoms)-result.Matched-len(withdrawals), 0)
+
+	return result, nil
+}
+
+// phantomMatches returns true if the phantom transaction is packed for the cross-space call.
+func phantomMatches(tx ethTypes.TransactionDetail, call crossSpaceCall) bool {
+	if call.to == "" {
+		if tx.To != nil {
+			return false
+		}
+	} else if tx.To == nil || !strings.EqualFold(tx.To.Hex(), call.to) {
+		return false
+	}
+
+	if tx.Value == nil || call.value == nil || tx.Value.Cmp(call.value) != 0 {
+		return false
+	}
+
+	return bytes.Equal(tx.Input, call.input)
+}
+
+// crossSpaceCallAddress is the hex address of CrossSpaceCall internal contract.
+const crossSpaceCallAddress = "0x0888000000000000000000000000000000000006"
+
+// CrossSpaceTraffic is the cross-space traffic via CrossSpaceCall internal contract in an epoch.
+type CrossSpaceTraffic struct {
+	Epoch            uint64
+	NumTxs           int // transactions interacting with CrossSpaceCall internal contract
+	NumCalls         int // calls or transfers into eSpace
+	NumWithdrawals   int // withdrawals from mapped accounts in eSpace
+	NumLogs          int // logs emitted by CrossSpaceCall internal contract
+	VolumeToEspace   *big.Int
+	VolumeFromEspace *big.Int
+}
+
+// crossSpaceTraffic detects interactions with CrossSpaceCall internal contract in traces and logs of an epoch.
+func crossSpaceTraffic(epochNumber uint64, traces []*types.LocalizedBlockTrace, receipts [][]types.TransactionReceipt) CrossSpaceTraffic {
+	result := CrossSpaceTraffic{
+		Epoch:            epochNumber,
+		VolumeToEspace:   new(big.Int),
+		VolumeFromEspace: new(big.Int),
+	}
+
+	calls, withdrawals := crossSpaceCalls(traces)
+
+	result.NumCalls = len(calls)
+	for _, call := range calls {
+		result.VolumeToEspace.Add(result.VolumeToEspace, call.value)
+	}
+
+	result.NumWithdrawals = len(withdrawals)
+	for _, value := range withdrawals {
+		result.VolumeFromEspace.Add(result.VolumeFromEspace, value)
+	}
+
+	for _, blockTraces := range traces {
+		if blockTraces == nil {
+			continue
+		}
+
+		for _, txTraces := range blockTraces.TransactionTraces {
+			for _, trace := range txTraces.Traces {
+				if call, ok := trace.Action.(types.Call); ok && strings.EqualFold(call.To.GetHexAddress(), crossSpaceCallAddress) {
+					result.NumTxs++
+					break
+				}
+			}
+		}
+	}
+
+	for _, blockReceipts := range receipts {
+		for _, receipt := range blockReceipts {
+			for _, log := range receipt.Logs {
+				if (log.Space == nil || *log.Space == types.SPACE_NATIVE) && strings.EqualFold(log.Address.GetHexAddress(), crossSpaceCallAddress) {
+					result.NumLogs++
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// CrossSpaceTrafficStat aggregates the cross-space traffic, along with the epochs with any traffic.
+type CrossSpaceTrafficStat struct {
+	NumTxs           int
+	NumCalls         int
+	NumWithdrawals   int
+	NumLogs          int
+	VolumeToEspace   *big.Int
+	VolumeFromEspace *big.Int
+
+	Epochs []CrossSpaceTraffic
+}
+
+func NewCrossSpaceTrafficStat() *CrossSpaceTrafficStat {
+	return &CrossSpaceTrafficStat{
+		VolumeToEspace:   new(big.Int),
+		VolumeFromEspace: new(big.Int),
+	}
+}
+
+func (stat *CrossSpaceTrafficStat) Add(traffic CrossSpaceTraffic) {
+	if traffic.NumTxs == 0 && traffic.NumCalls == 0 && traffic.NumWithdrawals == 0 && traffic.NumLogs == 0 {
+		return
+	}
+
+	stat.NumTxs += traffic.NumTxs
+	stat.NumCalls += traffic.NumCalls
+	stat.NumWithdrawals += traffic.NumWithdrawals
+	stat.NumLogs += traffic.NumLogs
+	stat.VolumeToEspace.Add(stat.VolumeToEspace, traffic.VolumeToEspace)
+	stat.VolumeFromEspace.Add(stat.VolumeFromEspace, traffic.VolumeFromEspace)
+
+	stat.Epochs = append(stat.Epochs, traffic)
+}
+
+// MarshalJSON implements the json.Marshaler interface to output epochs in epoch order, regardless of the
+// collection order, e.g. epochs retried at the end.
+func (stat *CrossSpaceTrafficStat) MarshalJSON() ([]byte, error) {
+	type plain CrossSpaceTrafficStat
+	sorted := plain(*stat)
+	sorted.Epochs = slices.Clone(stat.Epochs)
+	slices.SortStableFunc(sorted.Epochs, func(a, b CrossSpaceTraffic) int { return cmp.Compare(a.Epoch, b.Epoch) })
+
+	return json.Marshal(sorted)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestCrossSpaceTrafficStatEpochOrder(t *testing.T) {
+	stat := NewCrossSpaceTrafficStat()
+	for _, epoch := range []uint64{3, 1, 2} {
+		stat.Add(CrossSpaceTraffic{Epoch: epoch, NumTxs: 1, VolumeToEspace: big.NewInt(1), VolumeFromEspace: new(big.Int)})
+	}
+
+	encoded, err := json.Marshal(stat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded CrossSpaceTrafficStat
+	if err = json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.NumTxs != 3 || decoded.VolumeToEspace.Int64() != 3 || len(decoded.Epochs) != 3 {
+		t.Fatalf("Unexpected stat %s", encoded)
+	}
+
+	for i, v := range decoded.Epochs {
+		if v.Epoch != uint64(i+1) {
+			t.Fatalf("Expected epochs in order, but got %s", encoded)
+		}
+	}
+
+	if stat.Epochs[0].Epoch != 3 {
+		t.Fatalf("Epochs should not be sorted in place")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/boqiu/go-test/pkg/report"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+)
+
+// curlError is the error of an RPC request, which is transparent except that the curl command to
+// reproduce the request is attached.
+type curlError struct {
+	curl string
+	err  error
+}
+
+func (e *curlError) Error() string { return e.err.Error() }
+func (e *curlError) Cause() error  { return e.err }
+func (e *curlError) Unwrap() error { return e.err }
+
+// hookCurl attaches the curl command to errors of RPC requests, so that failures could be reproduced
+// against the given endpoint.
+func hookCurl(client *sdk.Client, url string) {
+	client.Provider().HookCallContext(func(call providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if err := call(ctx, result, method, args...); err != nil {
+				return &curlError{report.Curl(url, method, args...), err}
+			}
+
+			return nil
+		}
+	})
+}
+
+// curlOf returns the curl command to reproduce the failed RPC request, or empty if unknown.
+func curlOf(err error) string {
+	var e *curlError
+	if errors.As(err, &e) {
+		return e.curl
+	}
+
+	return ""
+}
+
+// curl returns the curl command to reproduce the RPC request against the fullnode under test.
+func curl(method string, params ...any) string {
+	return report.Curl(flags.Url, method, params...)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+var dashboardFlags struct {
+	Title string
+}
+
+func newDashboardCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "dashboard",
+		Short: "Output Grafana dashboard JSON of the metrics exported by this tool",
+		Long: `Output Grafana dashboard JSON of the metrics exported by this tool, which could be imported in Grafana
+directly with a Prometheus datasource, e.g.
+
+go-test dashboard --output dashboard.json
+
+Panels are generated for all metrics below, which are labeled with endpoint:
+
+` + metricCatalog(),
+		Run: func(*cobra.Command, []string) {
+			printResult(report.GrafanaDashboard(dashboardFlags.Title))
+		},
+	}
+
+	cmd.Flags().StringVar(&dashboardFlags.Title, "title", "go-test", "Title of dashboard")
+
+	return &cmd
+}
+
+// metricCatalog returns the human readable list of exported metrics.
+func metricCatalog() string {
+	var catalog string
+
+	for _, metric := range report.Metrics {
+		catalog += "  " + metric.Name
+		if len(metric.Labels) > 0 {
+			catalog += "{" + strings.Join(metric.Labels, ",") + "}"
+		}
+		catalog += ": " + metric.Help + "\n"
+	}
+
+	return catalog
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/boqiu/go-test/pkg/report"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// hookDecode decodes RPC responses on behalf of client if archive or strict mode enabled, so as to archive the
+// raw response in case of decode error, and detect the fields that SDK drops. Note, it should be hooked at last
+// as the innermost to see the result type of caller.
+func hookDecode(client *sdk.Client, url string) {
+	if archive == nil && unknownFields == nil {
+		return
+	}
+
+	client.Provider().HookCallContext(func(call providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			// nothing to decode, e.g. raw response requested by response cache
+			if _, ok := result.(*json.RawMessage); ok || result == nil {
+				return call(ctx, result, method, args...)
+			}
+
+			var raw json.RawMessage
+			if err := call(ctx, &raw, method, args...); err != nil {
+				return err
+			}
+
+			return decodeResponse(url, method, args, raw, result)
+		}
+	})
+}
+
+// decodeResponse decodes the raw response into result, in which the raw response is archived in case of
+// decode error, and the fields that SDK drops are detected in strict mode.
+func decodeResponse(url, method string, args []any, raw json.RawMessage, result any) error {
+	if err := json.Unmarshal(raw, result); err != nil {
+		archiveResponse(report.ArchiveEntry{
+			Endpoint: url,
+			Method:   method,
+			Params:   args,
+			Reason:   "decode error: " + err.Error(),
+		}, raw)
+
+		return errors.WithMessage(err, "Failed to decode response")
+	}
+
+	if unknownFields == nil {
+		return nil
+	}
+
+	// raw response requested by caller, e.g. call subcommand
+	if _, ok := result.(*json.RawMessage); ok {
+		return nil
+	}
+
+	if paths, err := diffFields(raw, result); err != nil {
+		logrus.WithError(err).WithField("method", method).Debug("Failed to detect unknown fields")
+	} else if len(paths) > 0 {
+		unknownFields.add(method, paths)
+	}
+
+	return nil
+}
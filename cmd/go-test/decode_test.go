@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// testBlock is the typed result of cfx_getBlockByHash, which drops unknown fields.
+type testBlock struct {
+	Hash   string `json:"hash"`
+	Height string `json:"height"`
+}
+
+// testBlockServer responds cfx_getBlockByHash with the block of given hash, and counts requests of blocks.
+func testBlockServer(t *testing.T, blocks map[string]string) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Id     json.RawMessage
+			Method string
+			Params []any
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		result := `{"chainId":"0x1","networkId":"0x1"}`
+		if req.Method == "cfx_getBlockByHash" {
+			requests.Add(1)
+			hash, _ := req.Params[0].(string)
+			result = blocks[hash]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.Id) + `,"result":` + result + `}`))
+	}))
+
+	return server, &requests
+}
+
+// testDecodeHooks enables archive and strict mode along with the hooks of client, and restores them on cleanup.
+func testDecodeHooks(t *testing.T, cacheDir string) {
+	savedFlags, savedArchive, savedUnknownFields, savedResponseCache := flags, archive, unknownFields, responseCache
+	t.Cleanup(func() {
+		flags, archive, unknownFields, responseCache = savedFlags, savedArchive, savedUnknownFields, savedResponseCache
+	})
+
+	flags.ArchiveDir = t.TempDir()
+	flags.Strict = true
+	flags.CacheDir = cacheDir
+	flags.CacheTTL = 0
+
+	if err := setupArchive(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setupResponseCache(); err != nil {
+		t.Fatal(err)
+	}
+
+	setupStrict()
+}
+
+func TestHookDecode(t *testing.T) {
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	server, _ := testBlockServer(t, map[string]string{
+		"0x01": `{"hash":"0x01","height":"0x1","newField":"0x2"}`,
+		"0x02": `{"hash":"0x02","height":2}`,
+	})
+	defer server.Close()
+
+	for _, c := range []struct {
+		hash     string
+		wantErr  bool
+		archived int
+		unknown  map[string]int
+	}{
+		{"0x01", false, 0, map[string]int{"newField": 1}},
+		{"0x02", true, 2, nil},
+	} {
+		testDecodeHooks(t, "")
+
+		client, err := newClientOf(server.URL, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var block testBlock
+		if err = client.CallRPC(&block, "cfx_getBlockByHash", c.hash); (err != nil) != c.wantErr {
+			t.Errorf("Expected error %v to decode block %v, got %v", c.wantErr, c.hash, err)
+		}
+
+		if files, _ := os.ReadDir(flags.ArchiveDir); len(files) != c.archived {
+			t.Errorf("Expected %v archived files of block %v, got %v", c.archived, c.hash, len(files))
+		}
+
+		if len(unknownFields.fields["cfx_getBlockByHash"]) != len(c.unknown) {
+			t.Errorf("Expected unknown fields %v of block %v, got %v", c.unknown, c.hash, unknownFields.fields)
+		}
+
+		for path, count := range c.unknown {
+			if actual := unknownFields.fields["cfx_getBlockByHash"][path]; actual != count {
+				t.Errorf("Expected unknown field %v of block %v counted %v, got %v", path, c.hash, count, actual)
+			}
+		}
+	}
+}
+
+func TestHookResponseCacheDecode(t *testing.T) {
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	server, requests := testBlockServer(t, map[string]string{
+		"0x01": `{"hash":"0x01","height":"0x1","newField":"0x2"}`,
+		"0x02": `{"hash":"0x02","height":2}`,
+	})
+	defer server.Close()
+
+	for _, c := range []struct {
+		hash     string
+		wantErr  bool
+		requests int32 // requests to fullnode of 2 calls
+		archived int
+		unknown  int
+	}{
+		{"0x01", false, 1, 0, 2}, // served from cache at the 2nd call
+		{"0x02", true, 2, 4, 0},  // not cached due to decode error
+	} {
+		testDecodeHooks(t, t.TempDir())
+		requests.Store(0)
+
+		client, err := newClientOf(server.URL, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 2; i++ {
+			var block testBlock
+			if err = client.CallRPC(&block, "cfx_getBlockByHash", c.hash); (err != nil) != c.wantErr {
+				t.Errorf("Expected error %v to decode block %v, got %v", c.wantErr, c.hash, err)
+			}
+		}
+
+		if actual := requests.Load(); actual != c.requests {
+			t.Errorf("Expected %v requests of block %v, got %v", c.requests, c.hash, actual)
+		}
+
+		if files, _ := os.ReadDir(flags.ArchiveDir); len(files) != c.archived {
+			t.Errorf("Expected %v archived files of block %v, got %v", c.archived, c.hash, len(files))
+		}
+
+		if actual := unknownFields.fields["cfx_getBlockByHash"]["newField"]; actual != c.unknown {
+			t.Errorf("Expected unknown field of block %v counted %v, got %v", c.hash, c.unknown, actual)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+	"github.com/boqiu/go-test/pkg/diff"
+	"github.com/boqiu/go-test/pkg/fetch"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var diffFlags struct {
+	Against        string
+	EpochFrom      uint64
+	NumEpochs      uint64
+	MaxSamples     int
+	MaxDifferences int
+	Exact          bool
+}
+
+func newDiffCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "diff",
+		Short: "Compare RPC responses of epochs between two fullnodes, ignoring formatting noise",
+		Long: `Compare RPC responses of epochs between two fullnodes, in which responses are normalized
+before comparison so that only meaningful differences are reported: object keys are compared
+regardless of order, hex strings are lowercased, and null, empty array and empty object are equivalent.
+
+To certify exact output compatibility, e.g. of a node fork, use --exact to compare responses byte for byte
+after stable re-serialization, i.e. compact JSON with sorted object keys.`,
+		Run: diffEpochs,
+	}
+
+	cmd.Flags().StringVar(&diffFlags.Against, "against", "", "Fullnode RPC endpoint to compare with --url")
+	cmd.Flags().Uint64Var(&diffFlags.EpochFrom, "epoch-from", 0, "Epoch number to compare from, 0 to compare the latest finalized epochs")
+	cmd.Flags().Uint64Var(&diffFlags.NumEpochs, "epoch-count", 30, "Number of epochs to compare")
+	cmd.Flags().IntVar(&diffFlags.MaxSamples, "max-samples", 20, "Maximum number of mismatched responses to report")
+	cmd.Flags().IntVar(&diffFlags.MaxDifferences, "max-differences", 10, "Maximum number of differences to report for each mismatched response")
+	cmd.Flags().BoolVar(&diffFlags.Exact, "exact", false, "Whether to compare responses byte for byte after stable re-serialization instead of normalization")
+	cmd.MarkFlagRequired("against")
+
+	return &cmd
+}
+
+// diffRequest is an RPC request of epoch to compare responses.
+type diffRequest struct {
+	method string
+	params func(epoch string) []any
+}
+
+var diffRequests = []diffRequest{
+	{"cfx_getBlocksByEpoch", func(epoch string) []any { return []any{epoch} }},
+	{"cfx_getBlockByEpochNumber", func(epoch string) []any { return []any{epoch, true} }},
+	{"cfx_getEpochReceipts", func(epoch string) []any { return []any{epoch} }},
+}
+
+func diffEpochs(*cobra.Command, []string) {
+	epochFrom := diffFlags.EpochFrom
+	if epochFrom == 0 {
+		client := mustNewClient()
+		latestFinalized, err := client.GetEpochNumber(types.EpochLatestFinalized)
+		client.Close()
+		if err != nil {
+			fatal(ExitPreflight, logrus.WithError(err), "Failed to get latest finalized epoch number")
+		}
+
+		epochTo := latestFinalized.ToInt().Uint64()
+		epochFrom = epochTo + 1 - min(diffFlags.NumEpochs, epochTo+1)
+	}
+
+	start := time.Now()
+	stat := DiffStat{
+		a:        fetch.NewRawClient(chaosUrl(flags.Url), flags.RpcOption.RequestTimeout, flags.RawOption),
+		b:        fetch.NewRawClient(chaosUrl(diffFlags.Against), flags.RpcOption.RequestTimeout, flags.RawOption),
+		From:     epochFrom,
+		Mismatch: make(map[string]int),
+	}
+
+	if err := parallel.Serial(context.Background(), &stat, int(diffFlags.NumEpochs), flags.ParallelOption); err != nil {
+		fatal(ExitFailure, logrus.WithError(err), "Failed to compare epochs")
+	}
+
+	printResult(stat)
+
+	printInfo("Total elapsed: %v", time.Since(start))
+}
+
+// ResponseDiff is the differences of a mismatched response.
+type ResponseDiff struct {
+	Epoch       uint64
+	Method      string
+	Differences []diff.Difference
+}
+
+// EpochDiff is the comparison result of an epoch.
+type EpochDiff struct {
+	NumRequests int
+	Mismatched  []ResponseDiff
+}
+
+type DiffStat struct {
+	a, b *fetch.RawClient
+
+	From        uint64
+	NumEpochs   int
+	NumRequests int
+	NumErrors   int
+
+	NumMismatched int
+	Mismatch      map[string]int `json:",omitempty"` // number of mismatched responses by method
+	Samples       []ResponseDiff `json:",omitempty"`
+}
+
+func (stat *DiffStat) ParallelDo(ctx context.Context, routine, task int) (EpochDiff, error) {
+	epochNumber := stat.From + uint64(task)
+	epoch := hexutil.EncodeUint64(epochNumber)
+
+	var result EpochDiff
+
+	for _, request := range diffRequests {
+		params := request.params(epoch)
+
+		a, err := stat.a.Call(request.method, params...)
+		if err != nil {
+			return result, errors.WithMessagef(err, "Failed to call %v on %v", request.method, flags.Url)
+		}
+
+		b, err := stat.b.Call(request.method, params...)
+		if err != nil {
+			return result, errors.WithMessagef(err, "Failed to call %v on %v", request.method, diffFlags.Against)
+		}
+
+		result.NumRequests++
+
+		compare := diff.Compare
+		if diffFlags.Exact {
+			compare = diff.CompareBytes
+		}
+
+		diffs, err := compare(a, b)
+		if err != nil {
+			return result, errors.WithMessagef(err, "Failed to compare responses of %v", request.method)
+		}
+
+		if len(diffs) > 0 {
+			result.Mismatched = append(result.Mismatched, ResponseDiff{epochNumber, request.method, diffs})
+		}
+	}
+
+	return result, nil
+}
+
+func (stat *DiffStat) ParallelCollect(ctx context.Context, result *parallel.Result[EpochDiff]) error {
+	epoch := stat.From + uint64(result.Task)
+
+	stat.NumEpochs++
+	stat.NumRequests += result.Value.NumRequests
+
+	if result.Err != nil {
+		logrus.WithError(result.Err).WithField("epoch", epoch).Warn("Failed to compare epoch")
+		stat.NumErrors++
+		return nil
+	}
+
+	for _, v := range result.Value.Mismatched {
+		logrus.WithFields(logrus.Fields{
+			"epoch":       epoch,
+			"method":      v.Method,
+			"differences": len(v.Differences),
+		}).Warn("Responses mismatched")
+
+		stat.NumMismatched++
+		stat.Mismatch[v.Method]++
+
+		if len(stat.Samples) < diffFlags.MaxSamples {
+			if len(v.Differences) > diffFlags.MaxDifferences {
+				v.Differences = v.Differences[:diffFlags.MaxDifferences]
+			}
+
+			stat.Samples = append(stat.Samples, v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/boqiu/go-test/pkg/fetch"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+)
+
+// DryRunPlan is the planned load of test, estimated from a small sample of epochs. Note, requests of
+// optional features are not included.
+type DryRunPlan struct {
+	EpochFrom  uint64
+	EpochTo    uint64 // exclusive
+	NumSamples int
+
+	AvgBlocks float64           // average number of blocks per epoch
+	Requests  map[string]uint64 // planned number of requests by method
+
+	EstimatedBytes    int64
+	EstimatedDuration time.Duration
+}
+
+// MarshalJSON implements the json.Marshaler interface to output human readable size and duration.
+func (plan *DryRunPlan) MarshalJSON() ([]byte, error) {
+	type alias DryRunPlan
+
+	return json.Marshal(struct {
+		*alias
+		EstimatedMB       float64
+		EstimatedDuration string
+	}{(*alias)(plan), float64(plan.EstimatedBytes) / 1024 / 1024, plan.EstimatedDuration.String()})
+}
+
+// PlanDryRun samples epochs evenly in range [epochFrom, epochFrom+numEpochs) to estimate the requests,
+// payload volume and duration of test.
+func PlanDryRun(client *sdk.Client, epochFrom, numEpochs uint64, samples, threads int) (*DryRunPlan, error) {
+	if numEpochs == 0 {
+		return nil, errors.New("No epoch to test")
+	}
+
+	plan := DryRunPlan{
+		EpochFrom: epochFrom,
+		EpochTo:   epochFrom + numEpochs,
+		Requests:  make(map[string]uint64),
+	}
+
+	samples = int(min(uint64(max(samples, 1)), numEpochs))
+
+	var numBlocks, numBytes int
+	var elapsed time.Duration
+
+	for i := 0; i < samples; i++ {
+		epoch := epochFrom + uint64(i)*numEpochs/uint64(samples)
+
+		start := time.Now()
+		data, err := fetch.QueryEpochData(client, epoch, stats.NewMethodLatency(flags.Jitter), flags.FetchOption)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Failed to query sample epoch %v", epoch)
+		}
+		elapsed += time.Since(start)
+
+		numBlocks += len(data.Blocks)
+		numBytes += epochBytes(&data)
+	}
+
+	plan.NumSamples = samples
+	plan.AvgBlocks = float64(numBlocks) / float64(samples)
+
+	// requests issued by fetch.QueryEpochData
+	plannedBlocks := uint64(plan.AvgBlocks * float64(numEpochs))
+	plan.Requests["cfx_getBlocksByEpoch"] = numEpochs
+	plan.Requests["cfx_getBlockByHash"] = plannedBlocks
+	plan.Requests["trace_block"] = plannedBlocks
+	plan.Requests["cfx_getEpochReceipts"] = numEpochs
+
+	plan.EstimatedBytes = int64(float64(numBytes) / float64(samples) * float64(numEpochs))
+	plan.EstimatedDuration = elapsed / time.Duration(samples) * time.Duration(numEpochs) / time.Duration(max(threads, 1))
+
+	return &plan, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/boqiu/go-test/pkg/stats"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/sirupsen/logrus"
+)
+
+// Endpoint is a fullnode RPC endpoint with circuit breaker, which is ejected after consecutive failures,
+// and probed by a single request once cooled down.
+type Endpoint struct {
+	Url    string
+	client *sdk.Client
+	now    func() time.Time // clock of circuit breaker, e.g. time.Now
+
+	mu          sync.Mutex
+	consecutive int       // consecutive failures
+	ejectedAt   time.Time // zero if not ejected
+	probing     bool      // whether a probe request is in flight
+
+	NumRequests int
+	NumFailures int
+	NumEjects   int
+}
+
+// record records the result of an RPC request, in which errors responded from fullnode are not regarded as
+// endpoint failures.
+func (e *Endpoint) record(err error, threshold int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.NumRequests++
+
+	if err == nil || stats.IsRpcError(err) {
+		if !e.ejectedAt.IsZero() {
+			logrus.WithField("url", e.Url).Info("Endpoint recovered")
+		}
+
+		e.consecutive, e.ejectedAt, e.probing = 0, time.Time{}, false
+		return
+	}
+
+	e.NumFailures++
+	e.consecutive++
+
+	// probe failed, or too many consecutive failures
+	if e.probing || (e.ejectedAt.IsZero() && e.consecutive >= threshold) {
+		if e.ejectedAt.IsZero() {
+			logrus.WithError(err).WithField("url", e.Url).Warn("Endpoint ejected")
+			e.NumEjects++
+		}
+
+		e.ejectedAt, e.probing = e.now(), false
+	}
+}
+
+// available returns true if not ejected, or cooled down to probe.
+func (e *Endpoint) available(cooldown time.Duration) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ejectedAt.IsZero() {
+		return true
+	}
+
+	if e.probing || e.now().Sub(e.ejectedAt) < cooldown {
+		return false
+	}
+
+	e.probing = true
+
+	return true
+}
+
+// EndpointPool distributes requests across endpoints of the same network, skipping the ejected ones.
+type EndpointPool struct {
+	endpoints []*Endpoint
+	cooldown  time.Duration
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewEndpointPool hooks the circuit breaker on clients of endpoints.
+func NewEndpointPool(clients []*sdk.Client, urls []string, threshold int, cooldown time.Duration) *EndpointPool {
+	pool := EndpointPool{cooldown: cooldown}
+
+	for i, client := range clients {
+		endpoint := &Endpoint{Url: urls[i], client: client, now: time.Now}
+
+		client.Provider().HookCallContext(func(call providers.CallContextFunc) providers.CallContextFunc {
+			return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				err := call(ctx, result, method, args...)
+				endpoint.record(err, threshold)
+				return err
+			}
+		})
+
+		pool.endpoints = append(pool.endpoints, endpoint)
+	}
+
+	return &pool
+}
+
+// Client returns the client of next available endpoint in round robin. If all endpoints are ejected,
+// the next one is returned anyway so as not to stall the test.
+func (pool *EndpointPool) Client() *sdk.Client {
+	pool.mu.Lock()
+	start := pool.next
+	pool.next = (pool.next + 1) % len(pool.endpoints)
+	pool.mu.Unlock()
+
+	for i := range pool.endpoints {
+		if endpoint := pool.endpoints[(start+i)%len(pool.endpoints)]; endpoint.available(pool.cooldown) {
+			return endpoint.client
+		}
+	}
+
+	return pool.endpoints[start].client
+}
+
+// MarshalJSON implements the json.Marshaler interface to output statistics of endpoints.
+func (pool *EndpointPool) MarshalJSON() ([]byte, error) {
+	var stats []*Endpoint
+	for _, endpoint := range pool.endpoints {
+		endpoint.mu.Lock()
+		stats = append(stats, &Endpoint{
+			Url:         endpoint.Url,
+			NumRequests: endpoint.NumRequests,
+			NumFailures: endpoint.NumFailures,
+			NumEjects:   endpoint.NumEjects,
+		})
+		endpoint.mu.Unlock()
+	}
+
+	return json.Marshal(stats)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/pkg/errors"
+)
+
+// testClock is a manual clock of circuit breaker.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+// testRpcError is an error responded from fullnode.
+type testRpcError struct{}
+
+func (testRpcError) Error() string  { return "execution reverted" }
+func (testRpcError) ErrorCode() int { return -32015 }
+
+func TestEndpointCircuitBreaker(t *testing.T) {
+	const threshold, cooldown = 3, 10 * time.Second
+
+	errNetwork := errors.New("connection refused")
+
+	type step struct {
+		advance   time.Duration // time elapsed before the step
+		available bool          // expected availability before request
+		err       error         // result of request if available
+		ejected   bool          // expected state after request
+	}
+
+	for _, tc := range []struct {
+		name   string
+		steps  []step
+		ejects int
+	}{
+		{"closed on success", []step{
+			{0, true, nil, false},
+			{0, true, errNetwork, false},
+			{0, true, nil, false},
+			{0, true, errNetwork, false},
+			{0, true, errNetwork, false},
+		}, 0},
+		{"rpc errors never open", []step{
+			{0, true, testRpcError{}, false},
+			{0, true, testRpcError{}, false},
+			{0, true, testRpcError{}, false},
+		}, 0},
+		{"open on consecutive failures", []step{
+			{0, true, errNetwork, false},
+			{0, true, errNetwork, false},
+			{0, true, errNetwork, true},
+			{cooldown - 1, false, nil, true},
+		}, 1},
+		{"half open then closed", []step{
+			{0, true, errNetwork, false},
+			{0, true, errNetwork, false},
+			{0, true, errNetwork, true},
+			{cooldown, true, nil, false},
+			{0, true, errNetwork, false},
+		}, 1},
+		{"half open then open again", []step{
+			{0, true, errNetwork, false},
+			{0, true, errNetwork, false},
+			{0, true, errNetwork, true},
+			{cooldown, true, errNetwork, true},
+			{cooldown - 1, false, nil, true},
+			{1, true, testRpcError{}, false},
+		}, 1},
+	} {
+		clock := testClock{time.Unix(1700000000, 0)}
+		endpoint := Endpoint{Url: tc.name, now: clock.Now}
+
+		for i, s := range tc.steps {
+			clock.now = clock.now.Add(s.advance)
+
+			if available := endpoint.available(cooldown); available != s.available {
+				t.Fatalf("%v: expected availability %v of step %v", tc.name, s.available, i)
+			} else if available {
+				endpoint.record(s.err, threshold)
+			}
+
+			if ejected := !endpoint.ejectedAt.IsZero(); ejected != s.ejected {
+				t.Fatalf("%v: expected ejected %v after step %v", tc.name, s.ejected, i)
+			}
+		}
+
+		if endpoint.NumEjects != tc.ejects {
+			t.Errorf("%v: expected %v ejects, but got %v", tc.name, tc.ejects, endpoint.NumEjects)
+		}
+	}
+}
+
+func TestEndpointHalfOpenSingleProbe(t *testing.T) {
+	clock := testClock{time.Unix(1700000000, 0)}
+	endpoint := Endpoint{now: clock.Now}
+	endpoint.record(errors.New("timeout"), 1)
+
+	clock.now = clock.now.Add(time.Second)
+
+	if !endpoint.available(time.Second) {
+		t.Fatal("Expected a probe once cooled down")
+	}
+
+	if endpoint.available(time.Second) {
+		t.Fatal("Expected no more requests while probing")
+	}
+}
+
+func TestEndpointPoolClient(t *testing.T) {
+	clock := testClock{time.Unix(1700000000, 0)}
+	clients := []*sdk.Client{new(sdk.Client), new(sdk.Client), new(sdk.Client)}
+
+	pool := EndpointPool{cooldown: time.Minute}
+	for _, client := range clients {
+		pool.endpoints = append(pool.endpoints, &Endpoint{client: client, now: clock.Now})
+	}
+
+	// round robin
+	for i := 0; i < 6; i++ {
+		if pool.Client() != clients[i%3] {
+			t.Fatalf("Expected client %v in round robin", i%3)
+		}
+	}
+
+	// skip the ejected one
+	pool.endpoints[1].record(errors.New("timeout"), 1)
+	for i, expected := range []int{0, 2, 2, 0} {
+		if pool.Client() != clients[expected] {
+			t.Fatalf("Expected client %v of request %v with endpoint ejected", expected, i)
+		}
+	}
+
+	// the next one anyway if all ejected
+	for _, endpoint := range pool.endpoints {
+		endpoint.record(errors.New("timeout"), 1)
+	}
+	if next := pool.next; pool.Client() != clients[next] {
+		t.Fatal("Expected the next client if all ejected")
+	}
+}
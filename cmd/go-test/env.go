@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix is the prefix of environment variables to configure flags, e.g. GOTEST_URL for --url.
+const envPrefix = "GOTEST_"
+
+// envName returns the environment variable name bound to the flag.
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// bindEnv sets flags that not specified in command line from environment variables.
+func bindEnv(cmd *cobra.Command) (err error) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil || flag.Changed {
+			return
+		}
+
+		value, ok := os.LookupEnv(envName(flag.Name))
+		if !ok {
+			return
+		}
+
+		if e := cmd.Flags().Set(flag.Name, value); e != nil {
+			err = errors.WithMessagef(e, "Invalid environment variable %v", envName(flag.Name))
+		}
+	})
+
+	return
+}
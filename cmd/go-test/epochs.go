@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FailedEpoch is an epoch that failed persistently, even after retry.
+type FailedEpoch struct {
+	Epoch  uint64
+	Method string `json:",omitempty"`
+	Error  string
+	Curl   string `json:",omitempty"` // curl command to reproduce the failed request
+}
+
+// readEpochsFile reads epoch numbers line by line, in which texts after '#' are ignored as comments.
+// Returns the sorted and deduplicated epoch numbers.
+func readEpochsFile(file string) ([]uint64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to open file")
+	}
+	defer f.Close()
+
+	var epochs []uint64
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		if text = strings.TrimSpace(text); len(text) == 0 {
+			continue
+		}
+
+		epoch, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Invalid epoch number at line %v", line)
+		}
+
+		epochs = append(epochs, epoch)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.WithMessage(err, "Failed to read file")
+	}
+
+	slices.Sort(epochs)
+
+	return slices.Compact(epochs), nil
+}
+
+// writeFailedEpochs writes failed epochs line by line with error details as comments, so that the file
+// could be fed back via --epochs-file.
+func writeFailedEpochs(file string, failed []FailedEpoch) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to create file")
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, v := range failed {
+		msg := strings.ReplaceAll(v.Error, "\n", " ")
+		if len(v.Method) > 0 {
+			msg = fmt.Sprintf("%v: %v", v.Method, msg)
+		}
+
+		fmt.Fprintf(w, "%v # %v\n", v.Epoch, msg)
+	}
+
+	if err = w.Flush(); err != nil {
+		return errors.WithMessage(err, "Failed to write file")
+	}
+
+	return nil
+}
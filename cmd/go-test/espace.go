@@ -0,0 +1,180 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/openweb3/web3go"
+	ethTypes "github.com/openweb3/web3go/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// EspaceParityResult is the result of verifying eth_ RPCs of an eSpace block against the core space epoch.
+type EspaceParityResult struct {
+	Blocks            int
+	BlockMismatches   int // eSpace block mismatch with the pivot block of epoch, e.g. hash or timestamp
+	ReceiptMismatches int // eSpace receipts mismatch with transactions in block
+	LogMismatches     int // eth_getLogs mismatch with logs in eSpace receipts
+	CoreLogs          int // eSpace logs in core space receipts, e.g. emitted by cross-space calls
+	MissingCoreLogs   int // eSpace logs in core space receipts but not returned by eth_getLogs
+	NumEspaceTxs      int
+	NumEspaceLogs     int
+}
+
+func (result *EspaceParityResult) Add(other EspaceParityResult) {
+	result.Blocks += other.Blocks
+	result.BlockMismatches += other.BlockMismatches
+	result.ReceiptMismatches += other.ReceiptMismatches
+	result.LogMismatches += other.LogMismatches
+	result.CoreLogs += other.CoreLogs
+	result.MissingCoreLogs += other.MissingCoreLogs
+	result.NumEspaceTxs += other.NumEspaceTxs
+	result.NumEspaceLogs += other.NumEspaceLogs
+}
+
+// VerifyEspaceParity compares eth_getBlockByNumber, eth_getBlockReceipts and eth_getLogs of the eSpace block
+// against the equivalent data derived from core space blocks and receipts of the same epoch.
+func VerifyEspaceParity(espace *web3go.Client, epochNumber uint64, blocks []*types.Block, receipts [][]types.TransactionReceipt, latency stats.MethodLatency) (result EspaceParityResult, err error) {
+	if len(blocks) == 0 {
+		return result, nil
+	}
+
+	number := ethTypes.BlockNumber(epochNumber)
+	logger := logrus.WithField("epoch", epochNumber)
+
+	// block, whose hash and timestamp are the same as the pivot block of epoch
+	var block *ethTypes.Block
+	if err = latency.Measure("eth_getBlockByNumber", func() (err error) {
+		block, err = espace.Eth.BlockByNumber(number, false)
+		return
+	}); err != nil {
+		return EspaceParityResult{}, errors.WithMessage(err, "Failed to get eSpace block by number")
+	}
+
+	if block == nil {
+		return EspaceParityResult{}, errors.Errorf("eSpace block %v not found", epochNumber)
+	}
+
+	result.Blocks++
+	result.NumEspaceTxs = len(block.Transactions.Hashes())
+
+	pivot := blocks[len(blocks)-1]
+	if block.Hash.Hex() != string(pivot.Hash) || pivot.Timestamp == nil || block.Timestamp != pivot.Timestamp.ToInt().Uint64() {
+		logger.WithFields(logrus.Fields{
+			"hash":      block.Hash,
+			"pivotHash": pivot.Hash,
+		}).Warn("eSpace block mismatch with pivot block")
+		result.BlockMismatches++
+	}
+
+	// receipts
+	blockNumber := ethTypes.BlockNumberOrHashWithNumber(number)
+
+	var ethReceipts []*ethTypes.Receipt
+	if err = latency.Measure("eth_getBlockReceipts", func() (err error) {
+		ethReceipts, err = espace.Eth.BlockReceipts(&blockNumber)
+		return
+	}); err != nil {
+		return EspaceParityResult{}, errors.WithMessage(err, "Failed to get eSpace block receipts")
+	}
+
+	if len(ethReceipts) != result.NumEspaceTxs {
+		logger.WithFields(logrus.Fields{
+			"txs":      result.NumEspaceTxs,
+			"receipts": len(ethReceipts),
+		}).Warn("eSpace receipts mismatch with transactions")
+		result.ReceiptMismatches++
+	}
+
+	receiptLogs := make(map[string]int)
+	for _, receipt := range ethReceipts {
+		if receipt == nil {
+			continue
+		}
+
+		if receipt.BlockHash != block.Hash {
+			logger.WithField("tx", receipt.TransactionHash).Warn("eSpace receipt mismatch with block hash")
+			result.ReceiptMismatches++
+		}
+
+		for _, log := range receipt.Logs {
+			receiptLogs[ethLogKey(log)]++
+		}
+	}
+
+	// logs
+	var logs []ethTypes.Log
+	if err = latency.Measure("eth_getLogs", func() (err error) {
+		logs, err = espace.Eth.Logs(ethTypes.FilterQuery{FromBlock: &number, ToBlock: &number})
+		return
+	}); err != nil {
+		return EspaceParityResult{}, errors.WithMessage(err, "Failed to get eSpace logs")
+	}
+
+	result.NumEspaceLogs = len(logs)
+
+	filterLogs := make(map[string]int)
+	for i := range logs {
+		key := ethLogKey(&logs[i])
+		filterLogs[key]++
+
+		if receiptLogs[key] == 0 {
+			result.LogMismatches++
+		} else {
+			receiptLogs[key]--
+		}
+	}
+
+	for _, count := range receiptLogs {
+		result.LogMismatches += count
+	}
+
+	if result.LogMismatches > 0 {
+		logger.WithField("mismatches", result.LogMismatches).Warn("eSpace logs mismatch with receipts")
+	}
+
+	// eSpace logs in core space receipts
+	for _, blockReceipts := range receipts {
+		for _, receipt := range blockReceipts {
+			for _, log := range receipt.Logs {
+				if log.Space == nil || *log.Space != types.SPACE_EVM {
+					continue
+				}
+
+				result.CoreLogs++
+
+				key := strings.ToLower(log.Address.GetHexAddress())
+				for _, topic := range log.Topics {
+					key += strings.ToLower(string(topic))
+				}
+				key += hexutil.Encode(log.Data)
+
+				if filterLogs[key] == 0 {
+					result.MissingCoreLogs++
+				} else {
+					filterLogs[key]--
+				}
+			}
+		}
+	}
+
+	if result.MissingCoreLogs > 0 {
+		logger.WithField("missing", result.MissingCoreLogs).Warn("eSpace logs in core space receipts not found")
+	}
+
+	return result, nil
+}
+
+// ethLogKey returns the content key of eSpace log to match logs across core space and eSpace.
+func ethLogKey(log *ethTypes.Log) string {
+	key := strings.ToLower(log.Address.Hex())
+
+	for _, topic := range log.Topics {
+		key += topic.Hex()
+	}
+
+	return key + hexutil.Encode(log.Data)
+}
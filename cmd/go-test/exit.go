@@ -0,0 +1,97 @@
+package main
+
+import (
+	"github.com/boqiu/go-test/pkg/scan"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Exit codes by failure class, so that orchestration scripts could branch on the failure class.
+const (
+	ExitFailure      = 1 // unclassified failure, e.g. failed to write output
+	ExitConfig       = 2 // invalid flags or incompatible options
+	ExitPreflight    = 3 // fullnode failed the preflight check, e.g. pruned data or wrong network
+	ExitErrorBudget  = 4 // epochs failed persistently beyond the error budget
+	ExitVerification = 5 // verification mismatches, e.g. traces or logs
+	ExitSLO          = 6 // assertion failures
+)
+
+// exitCodes describes the exit codes in command help.
+const exitCodes = `Exit codes:
+  0  succeeded
+  1  unclassified failure, e.g. failed to write output
+  2  invalid flags or incompatible options
+  3  fullnode failed the preflight check, e.g. pruned data or wrong network
+  4  epochs failed persistently beyond --error-budget, or any failure with --fail-fast
+  5  verification mismatches
+  6  assertion failures of --assert`
+
+// exitCode is the exit code of command once completed, since the failure class of test run is determined
+// after the result output.
+var exitCode int
+
+// fatal logs the entry at fatal level and exits with the given code.
+func fatal(code int, entry *logrus.Entry, msg string) {
+	entry.Log(logrus.FatalLevel, msg)
+	entry.Logger.Exit(code)
+}
+
+// runFailure is the first failure after the test run started, which is reported once results output.
+type runFailure struct {
+	code int
+}
+
+// set logs the failure at error level, and keeps the exit code of the first failure.
+func (f *runFailure) set(code int, entry *logrus.Entry, msg string) {
+	entry.WithField("exitCode", code).Error(msg)
+
+	if f.code == 0 {
+		f.code = code
+	}
+}
+
+// exitCodeOf returns the exit code of the completed test run along with the reason of failure.
+func exitCodeOf(stat *RpcStat) (int, string) {
+	if float64(stat.NumErrors) > flags.ErrorBudget*float64(flags.NumEpochs) {
+		return ExitErrorBudget, "Epochs failed beyond the error budget"
+	}
+
+	for _, v := range verificationsOf(stat) {
+		if v.failures > 0 {
+			return ExitVerification, "Verification mismatches of " + v.name
+		}
+	}
+
+	if stat.Assert != nil && stat.Assert.NumFailed > 0 {
+		return ExitSLO, "Assertions failed"
+	}
+
+	return 0, ""
+}
+
+// exitCodeOfError returns the exit code of test run aborted by error.
+func exitCodeOfError(err error) int {
+	// any failure in fail-fast mode is beyond the error budget
+	var failFastErr *scan.FailFastError
+	if errors.As(err, &failFastErr) {
+		return ExitErrorBudget
+	}
+
+	return ExitFailure
+}
+
+// logFailFast logs the details of epoch failed in fail-fast mode, so that the failure could be reproduced.
+func logFailFast(err error) {
+	var failFastErr *scan.FailFastError
+	if !errors.As(err, &failFastErr) {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"epoch":  failFastErr.Epoch,
+		"method": stats.RpcMethod(failFastErr.Err),
+		"raw":    errors.Cause(failFastErr.Err),
+		"curl":   curlOf(failFastErr.Err),
+	}).Error(failFastErr.Err.Error())
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+	"github.com/boqiu/go-test/pkg/export"
+	"github.com/boqiu/go-test/pkg/fetch"
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var exportFlags struct {
+	Dir       string
+	EpochFrom uint64
+	NumEpochs uint64
+	Format    string
+	Option    export.Option
+	Postgres  string
+}
+
+func newExportCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "export",
+		Short: "Export raw blocks, receipts and traces of epochs to compressed files",
+		Long: `Export raw blocks, receipts and traces of epochs to files in directory, in which each line is the
+JSON of an epoch as below:
+
+{"epoch": 123, "blocks": [...], "traces": [...], "receipts": [[...]]}
+
+Files are compressed by gzip or zstd and rotated by size, since raw data of long ranges could be huge.
+
+In parquet format, transactions, logs and traces are flattened into tables of separate files instead, so as
+to query directly with DuckDB or Spark, e.g.
+
+SELECT "to", count(*) FROM 'transactions-*.parquet' GROUP BY "to"
+
+The flattened tables could also be inserted into PostgreSQL, in which tables are created
+automatically if not exist.`,
+		Run: exportEpochs,
+	}
+
+	cmd.Flags().StringVar(&exportFlags.Dir, "dir", "", "Directory to export files")
+	cmd.Flags().Uint64Var(&exportFlags.EpochFrom, "epoch-from", 0, "Epoch number to export from, 0 to export the latest finalized epochs")
+	cmd.Flags().Uint64Var(&exportFlags.NumEpochs, "epoch-count", 30, "Number of epochs to export")
+	cmd.Flags().StringVar(&exportFlags.Format, "format", "json", "Format of exported files, json or parquet")
+	cmd.Flags().StringVar(&exportFlags.Option.Compression, "file-compression", "gzip", "Compression of exported files, gzip, zstd or none")
+	cmd.Flags().IntVar(&exportFlags.Option.Level, "file-compression-level", 6, "Compression level, from 1 for best speed to 9 for gzip or 22 for zstd for best compression")
+	cmd.Flags().Int64Var(&exportFlags.Option.MaxFileSize, "max-file-size", 1<<30, "Approximate size in bytes of exported file to rotate, 0 for no rotation")
+	cmd.Flags().StringVar(&exportFlags.Postgres, "postgres", "", "PostgreSQL DSN to insert transactions, logs and traces, e.g. postgres://user@host:5432/db with password via PGPASSWORD or PGPASSFILE")
+	cmd.MarkFlagsOneRequired("dir", "postgres")
+
+	return &cmd
+}
+
+func exportEpochs(*cobra.Command, []string) {
+	stat := ExportStat{
+		client: fetch.NewRawClient(chaosUrl(flags.Url), flags.RpcOption.RequestTimeout, flags.RawOption),
+		Format: exportFlags.Format,
+	}
+
+	var err error
+	switch {
+	case exportFlags.Dir == "":
+	case exportFlags.Format == "json":
+		stat.writer, err = export.NewWriter(exportFlags.Dir, "epochs", exportFlags.Option)
+	case exportFlags.Format == "parquet":
+		for _, table := range exportTables {
+			var writer *export.ParquetWriter
+			if writer, err = export.NewParquetWriter(exportFlags.Dir, table.name, table.columns, exportFlags.Option); err != nil {
+				break
+			}
+
+			stat.tables = append(stat.tables, writer)
+		}
+	default:
+		err = errors.Errorf("Invalid format %v, expected json or parquet", exportFlags.Format)
+	}
+
+	if err != nil {
+		fatal(ExitConfig, logrus.WithError(err), "Failed to create export writer")
+	}
+
+	if exportFlags.Postgres != "" {
+		if stat.sink, err = report.NewPostgresSink(exportFlags.Postgres, exportSqlTables()); err != nil {
+			fatal(ExitConfig, logrus.WithError(err), "Failed to connect PostgreSQL database")
+		}
+	}
+
+	epochFrom := exportFlags.EpochFrom
+	if epochFrom == 0 {
+		client := mustNewClient()
+		latestFinalized, err := client.GetEpochNumber(types.EpochLatestFinalized)
+		client.Close()
+		if err != nil {
+			fatal(ExitPreflight, logrus.WithError(err), "Failed to get latest finalized epoch number")
+		}
+
+		epochTo := latestFinalized.ToInt().Uint64()
+		epochFrom = epochTo + 1 - min(exportFlags.NumEpochs, epochTo+1)
+	}
+
+	start := time.Now()
+	stat.From = epochFrom
+
+	err = parallel.Serial(context.Background(), &stat, int(exportFlags.NumEpochs), flags.ParallelOption)
+
+	if closeErr := stat.close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		fatal(ExitFailure, logrus.WithError(err), "Failed to export epochs")
+	}
+
+	printResult(stat)
+
+	printInfo("Total elapsed: %v", time.Since(start))
+
+	if stat.NumErrors > 0 {
+		exitCode = ExitErrorBudget
+		logrus.WithFields(logrus.Fields{
+			"epochs": stat.NumErrors,
+			"gaps":   len(stat.Gaps),
+		}).Error("Epochs failed and missing in exported data")
+	}
+}
+
+// ExportedEpoch is the raw data of an epoch to export.
+type ExportedEpoch struct {
+	Epoch    uint64            `json:"epoch"`
+	Blocks   []json.RawMessage `json:"blocks"`
+	Traces   []json.RawMessage `json:"traces"`
+	Receipts json.RawMessage   `json:"receipts"`
+}
+
+// EpochGap is a range of consecutive epochs missing in exported data.
+type EpochGap struct {
+	From uint64
+	To   uint64 // inclusive
+}
+
+// exportRecords is the records of an epoch to write, either a JSON line or rows of tables.
+type exportRecords struct {
+	line []byte
+	rows [3][][]any // rows by export table
+}
+
+type ExportStat struct {
+	client *fetch.RawClient
+	writer *export.Writer          // in json format
+	tables []*export.ParquetWriter // in parquet format, by export table
+	sink   *report.SqlSink         // nil if not inserting into database
+
+	Format    string
+	From      uint64
+	NumEpochs int
+	NumErrors int
+	Gaps      []EpochGap `json:",omitempty"` // epochs failed and missing in exported data
+
+	Files           []string
+	Rows            map[string]int64 `json:",omitempty"` // number of rows by table in parquet format or database
+	Bytes           int64            `json:",omitempty"` // bytes before compression in json format
+	CompressedBytes int64
+}
+
+// close closes writers and database sink, and updates the exported files and bytes.
+func (stat *ExportStat) close() error {
+	var err error
+
+	if stat.writer != nil {
+		err = stat.writer.Close()
+
+		stat.Files = stat.writer.Files
+		stat.Bytes = stat.writer.Bytes
+		stat.CompressedBytes = stat.writer.CompressedBytes
+	}
+
+	for _, writer := range stat.tables {
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+
+		stat.Files = append(stat.Files, writer.Files...)
+		stat.CompressedBytes += writer.Bytes
+	}
+
+	if stat.sink != nil {
+		if closeErr := stat.sink.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+func (stat *ExportStat) ParallelDo(ctx context.Context, routine, task int) (exportRecords, error) {
+	epochNumber := stat.From + uint64(task)
+	epoch := hexutil.EncodeUint64(epochNumber)
+
+	result := ExportedEpoch{Epoch: epochNumber}
+
+	raw, err := stat.client.Call("cfx_getBlocksByEpoch", epoch)
+	if err != nil {
+		return exportRecords{}, errors.WithMessage(err, "Failed to get blocks by epoch")
+	}
+
+	var blocks []types.Hash
+	if err = json.Unmarshal(raw, &blocks); err != nil {
+		return exportRecords{}, errors.WithMessage(err, "Failed to decode blocks by epoch")
+	}
+
+	for _, blockHash := range blocks {
+		block, err := stat.client.Call("cfx_getBlockByHash", blockHash, true)
+		if err != nil {
+			return exportRecords{}, errors.WithMessagef(err, "Failed to get block by hash %v", blockHash)
+		}
+		result.Blocks = append(result.Blocks, block)
+
+		traces, err := stat.client.Call("trace_block", blockHash)
+		if err != nil {
+			return exportRecords{}, errors.WithMessagef(err, "Failed to get block traces by block hash %v", blockHash)
+		}
+		result.Traces = append(result.Traces, traces)
+	}
+
+	if result.Receipts, err = stat.client.Call("cfx_getEpochReceipts", epoch); err != nil {
+		return exportRecords{}, errors.WithMessage(err, "Failed to get epoch receipts")
+	}
+
+	var records exportRecords
+
+	if stat.writer != nil {
+		if records.line, err = json.Marshal(result); err != nil {
+			return exportRecords{}, errors.WithMessage(err, "Failed to marshal epoch")
+		}
+	}
+
+	if len(stat.tables) > 0 || stat.sink != nil {
+		if records.rows, err = flattenEpoch(result); err != nil {
+			return exportRecords{}, errors.WithMessage(err, "Failed to flatten epoch")
+		}
+	}
+
+	return records, nil
+}
+
+func (stat *ExportStat) ParallelCollect(ctx context.Context, result *parallel.Result[exportRecords]) error {
+	epoch := stat.From + uint64(result.Task)
+
+	stat.NumEpochs++
+
+	if result.Err != nil {
+		logrus.WithError(result.Err).WithField("epoch", epoch).Warn("Failed to export epoch")
+		stat.NumErrors++
+
+		// results are collected in epoch order
+		if n := len(stat.Gaps); n > 0 && stat.Gaps[n-1].To+1 == epoch {
+			stat.Gaps[n-1].To = epoch
+		} else {
+			stat.Gaps = append(stat.Gaps, EpochGap{epoch, epoch})
+		}
+
+		return nil
+	}
+
+	if stat.writer != nil {
+		if err := stat.writer.WriteRecord(result.Value.line); err != nil {
+			return errors.WithMessagef(err, "Failed to write epoch %v", epoch)
+		}
+	}
+
+	for i, table := range exportTables {
+		for _, row := range result.Value.rows[i] {
+			if len(stat.tables) > 0 {
+				if err := stat.tables[i].WriteRow(row...); err != nil {
+					return errors.WithMessagef(err, "Failed to write %v of epoch %v", table.name, epoch)
+				}
+			}
+
+			if stat.sink != nil {
+				if err := stat.sink.Insert(table.name, row...); err != nil {
+					return errors.WithMessagef(err, "Failed to insert %v of epoch %v", table.name, epoch)
+				}
+			}
+		}
+
+		if len(result.Value.rows[i]) > 0 {
+			if stat.Rows == nil {
+				stat.Rows = make(map[string]int64)
+			}
+
+			stat.Rows[table.name] += int64(len(result.Value.rows[i]))
+		}
+	}
+
+	if stat.sink != nil {
+		if err := stat.sink.Checkpoint(); err != nil {
+			return errors.WithMessagef(err, "Failed to commit rows of epoch %v", epoch)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	rpc "github.com/openweb3/go-rpc-provider"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// FilterTester tests the lifecycle of log and block filters along with the epoch scan, and verifies
+// the filter results against data fetched directly.
+type FilterTester struct {
+	client      *sdk.Client
+	logFilter   rpc.ID
+	blockFilter rpc.ID
+
+	epochLogs   map[uint64]int // number of logs by epoch from receipts
+	blockHashes []types.Hash   // new blocks polled from block filter
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	NumPolls            int
+	NumPollErrors       int
+	NumLogChanges       int
+	NumBlockChanges     int
+	NumFilterLogs       int
+	NumMismatchedEpochs int
+	NumMissingBlocks    int
+}
+
+// NewFilterTester installs a log filter for the given epoch range and a block filter.
+func NewFilterTester(client *sdk.Client, epochFrom, epochTo uint64) (*FilterTester, error) {
+	logFilter, err := client.Filter().NewFilter(types.LogFilter{
+		FromEpoch: types.NewEpochNumberUint64(epochFrom),
+		ToEpoch:   types.NewEpochNumberUint64(epochTo),
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to install log filter")
+	}
+
+	blockFilter, err := client.Filter().NewBlockFilter()
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to install block filter")
+	}
+
+	return &FilterTester{
+		client:      client,
+		logFilter:   *logFilter,
+		blockFilter: *blockFilter,
+		epochLogs:   make(map[uint64]int),
+	}, nil
+}
+
+// Start polls filter changes periodically in a separate goroutine until stopped.
+func (tester *FilterTester) Start(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tester.cancel = cancel
+
+	tester.wg.Add(1)
+	go func() {
+		defer tester.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				tester.poll()
+			}
+		}
+	}()
+}
+
+func (tester *FilterTester) poll() {
+	tester.NumPolls++
+
+	logChanges, err := tester.client.Filter().GetFilterChanges(tester.logFilter)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to get log filter changes")
+		tester.NumPollErrors++
+	} else if logChanges != nil {
+		tester.NumLogChanges += len(logChanges.Logs)
+	}
+
+	blockChanges, err := tester.client.Filter().GetFilterChanges(tester.blockFilter)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to get block filter changes")
+		tester.NumPollErrors++
+	} else if blockChanges != nil {
+		tester.NumBlockChanges += len(blockChanges.Hashes)
+		tester.blockHashes = append(tester.blockHashes, blockChanges.Hashes...)
+	}
+}
+
+// AddEpoch records the number of logs of an epoch queried from receipts to verify filter logs.
+func (tester *FilterTester) AddEpoch(epoch uint64, numLogs int) {
+	tester.epochLogs[epoch] = numLogs
+}
+
+// Stop stops polling, verifies the filter results and uninstalls filters.
+func (tester *FilterTester) Stop() error {
+	if tester.cancel != nil {
+		tester.cancel()
+		tester.wg.Wait()
+	}
+
+	// verify filter logs against receipts of successfully queried epochs
+	logs, err := tester.client.Filter().GetFilterLogs(tester.logFilter)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to get filter logs")
+	}
+
+	tester.NumFilterLogs = len(logs)
+
+	filterLogs := make(map[uint64]int)
+	for _, log := range logs {
+		if log.EpochNumber != nil {
+			filterLogs[log.EpochNumber.ToInt().Uint64()]++
+		}
+	}
+
+	for epoch, numLogs := range tester.epochLogs {
+		if filterLogs[epoch] != numLogs {
+			logrus.WithFields(logrus.Fields{
+				"epoch":    epoch,
+				"receipts": numLogs,
+				"filter":   filterLogs[epoch],
+			}).Warn("Filter logs mismatch with receipts")
+			tester.NumMismatchedEpochs++
+		}
+	}
+
+	// verify new blocks polled from block filter
+	for _, hash := range tester.blockHashes {
+		block, err := tester.client.GetBlockSummaryByHash(hash)
+		if err != nil {
+			return errors.WithMessagef(err, "Failed to get block summary by hash %v", hash)
+		}
+
+		if block == nil {
+			logrus.WithField("hash", hash).Warn("Block polled from filter not found")
+			tester.NumMissingBlocks++
+		}
+	}
+
+	// uninstall filters
+	for _, id := range []rpc.ID{tester.logFilter, tester.blockFilter} {
+		uninstalled, err := tester.client.Filter().UninstallFilter(id)
+		if err != nil {
+			return errors.WithMessagef(err, "Failed to uninstall filter %v", id)
+		}
+
+		if !uninstalled {
+			return errors.Errorf("Filter %v not uninstalled", id)
+		}
+	}
+
+	return nil
+}
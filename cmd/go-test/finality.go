@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var finalityFlags struct {
+	Interval            time.Duration
+	Duration            time.Duration
+	StallThreshold      time.Duration
+	MinedStallThreshold time.Duration
+	Webhook             string
+	ExitGrace           time.Duration
+}
+
+func newFinalityCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "finality",
+		Short: "Track gaps between latest mined, state, confirmed and finalized epochs over time",
+		Long: `Track gaps between latest mined, state, confirmed and finalized epochs over time, and alert
+if the latest mined or finalized epoch stops advancing, so as to detect stalled fullnode.`,
+		Run: trackFinality,
+	}
+
+	cmd.Flags().DurationVar(&finalityFlags.Interval, "interval", 5*time.Second, "Interval to sample the latest epoch numbers")
+	cmd.Flags().DurationVar(&finalityFlags.Duration, "duration", 30*time.Minute, "Duration to track finality")
+	cmd.Flags().DurationVar(&finalityFlags.StallThreshold, "stall-threshold", 10*time.Minute, "Alert when the latest finalized epoch does not advance beyond the threshold")
+	cmd.Flags().DurationVar(&finalityFlags.MinedStallThreshold, "mined-stall-threshold", time.Minute, "Alert when the latest mined epoch does not advance beyond the threshold")
+	cmd.Flags().StringVar(&finalityFlags.Webhook, "webhook", "", "Webhook URL to post alerts in JSON format when stalled and resumed")
+	cmd.Flags().DurationVar(&finalityFlags.ExitGrace, "exit-grace", 0, "Exit with non-zero code if still stalled after the grace period since alerted, 0 to keep tracking")
+
+	return &cmd
+}
+
+// StallAlert is the alert posted to webhook when the latest epoch of some tag stalled or resumed.
+type StallAlert struct {
+	Endpoint string
+	Tag      string
+	Epoch    uint64
+	Stalled  time.Duration
+	Resumed  bool
+	Time     time.Time
+}
+
+// headWatch watches the latest epoch of some tag, and alerts if it does not advance beyond the threshold.
+type headWatch struct {
+	tag       string
+	threshold time.Duration
+
+	last     uint64
+	advanced time.Time
+	stalled  bool
+	maxStall time.Duration
+}
+
+// update updates the latest epoch, and returns the duration stalled so far.
+func (watch *headWatch) update(epoch uint64, now time.Time, stat *FinalityStat) time.Duration {
+	if epoch > watch.last || watch.advanced.IsZero() {
+		if watch.stalled {
+			stall := now.Sub(watch.advanced)
+			logrus.WithFields(logrus.Fields{
+				"tag":     watch.tag,
+				"stalled": stall,
+			}).Info("Latest epoch resumed")
+			watch.alert(StallAlert{Tag: watch.tag, Epoch: epoch, Stalled: stall, Resumed: true, Time: now})
+		}
+
+		watch.last, watch.advanced, watch.stalled = epoch, now, false
+
+		return 0
+	}
+
+	stall := now.Sub(watch.advanced)
+	watch.maxStall = max(watch.maxStall, stall)
+
+	if !watch.stalled && stall > watch.threshold {
+		logrus.WithFields(logrus.Fields{
+			"tag":     watch.tag,
+			"epoch":   watch.last,
+			"stalled": stall,
+		}).Error("Latest epoch stalled")
+		watch.alert(StallAlert{Tag: watch.tag, Epoch: watch.last, Stalled: stall, Time: now})
+
+		stat.NumStalls++
+		watch.stalled = true
+	}
+
+	return stall
+}
+
+func (watch *headWatch) alert(alert StallAlert) {
+	if len(finalityFlags.Webhook) == 0 {
+		return
+	}
+
+	alert.Endpoint = flags.Url
+
+	if err := report.PostWebhook(finalityFlags.Webhook, alert); err != nil {
+		logrus.WithError(err).Warn("Failed to post alert to webhook")
+	}
+}
+
+func trackFinality(*cobra.Command, []string) {
+	client := mustNewClient()
+	defer client.Close()
+
+	var stat FinalityStat
+
+	watches := []*headWatch{
+		{tag: types.EpochLatestMined.String(), threshold: finalityFlags.MinedStallThreshold},
+		{tag: types.EpochLatestFinalized.String(), threshold: finalityFlags.StallThreshold},
+	}
+
+	ticker := time.NewTicker(finalityFlags.Interval)
+	defer ticker.Stop()
+
+	for start := time.Now(); time.Since(start) < finalityFlags.Duration; <-ticker.C {
+		sample, err := QueryFinality(client)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to query latest epoch numbers")
+			stat.NumErrors++
+			continue
+		}
+
+		stat.Add(sample)
+
+		logrus.WithFields(logrus.Fields{
+			"mined":     sample.Mined,
+			"state":     sample.State,
+			"confirmed": sample.Confirmed,
+			"finalized": sample.Finalized,
+		}).Debug("Latest epoch numbers sampled")
+
+		for i, epoch := range []uint64{sample.Mined, sample.Finalized} {
+			stall := watches[i].update(epoch, sample.Time, &stat)
+
+			if finalityFlags.ExitGrace > 0 && stall > watches[i].threshold+finalityFlags.ExitGrace {
+				printResult(&stat)
+				fatal(ExitVerification, logrus.WithField("tag", watches[i].tag), "Latest epoch still stalled after grace period")
+			}
+		}
+
+		stat.MaxStall = watches[1].maxStall
+	}
+
+	printResult(&stat)
+}
+
+// FinalitySample is the latest epoch numbers of different tags sampled at some time.
+type FinalitySample struct {
+	Time      time.Time
+	Mined     uint64
+	State     uint64
+	Confirmed uint64
+	Finalized uint64
+}
+
+// QueryFinality queries the latest mined, state, confirmed and finalized epoch numbers.
+func QueryFinality(client *sdk.Client) (result FinalitySample, err error) {
+	result.Time = time.Now()
+
+	for _, v := range []struct {
+		epoch  *types.Epoch
+		number *uint64
+	}{
+		{types.EpochLatestMined, &result.Mined},
+		{types.EpochLatestState, &result.State},
+		{types.EpochLatestConfirmed, &result.Confirmed},
+		{types.EpochLatestFinalized, &result.Finalized},
+	} {
+		number, err := client.GetEpochNumber(v.epoch)
+		if err != nil {
+			return FinalitySample{}, errors.WithMessagef(err, "Failed to get epoch number of %v", v.epoch)
+		}
+
+		*v.number = number.ToInt().Uint64()
+	}
+
+	return result, nil
+}
+
+// FinalityGap is the statistics of gap between the latest mined epoch and epoch of another tag.
+type FinalityGap struct {
+	Min   uint64
+	Max   uint64
+	Avg   float64
+	total uint64
+	count int
+}
+
+func (gap *FinalityGap) Add(value uint64) {
+	if gap.count == 0 || value < gap.Min {
+		gap.Min = value
+	}
+
+	gap.Max = max(gap.Max, value)
+	gap.total += value
+	gap.count++
+	gap.Avg = float64(gap.total) / float64(gap.count)
+}
+
+// FinalityStat is the gaps between the latest epoch numbers over time.
+type FinalityStat struct {
+	Samples []FinalitySample
+
+	StateGap     FinalityGap // mined - state
+	ConfirmedGap FinalityGap // mined - confirmed
+	FinalizedGap FinalityGap // mined - finalized
+
+	NumStalls int
+	MaxStall  time.Duration `json:"-"`
+	NumErrors int
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the max stall in human readable format.
+func (stat *FinalityStat) MarshalJSON() ([]byte, error) {
+	type alias FinalityStat
+
+	return json.Marshal(struct {
+		*alias
+		MaxStall string
+	}{(*alias)(stat), stat.MaxStall.String()})
+}
+
+func (stat *FinalityStat) Add(sample FinalitySample) {
+	stat.Samples = append(stat.Samples, sample)
+
+	stat.StateGap.Add(sample.Mined - min(sample.State, sample.Mined))
+	stat.ConfirmedGap.Add(sample.Mined - min(sample.Confirmed, sample.Mined))
+	stat.FinalizedGap.Add(sample.Mined - min(sample.Finalized, sample.Mined))
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/export"
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+// exportTable is a flat table of exported data in Parquet format.
+type exportTable struct {
+	name    string
+	columns []export.Column
+}
+
+var exportTables = []exportTable{
+	{"transactions", []export.Column{
+		{Name: "epoch", Type: export.Int64},
+		{Name: "block_hash", Type: export.String},
+		{Name: "transaction_index", Type: export.Int64},
+		{Name: "hash", Type: export.String},
+		{Name: "from", Type: export.String},
+		{Name: "to", Type: export.String},
+		{Name: "contract_created", Type: export.String},
+		{Name: "nonce", Type: export.Int64},
+		{Name: "value", Type: export.String},
+		{Name: "gas", Type: export.Int64},
+		{Name: "gas_price", Type: export.String},
+		{Name: "storage_limit", Type: export.Int64},
+		{Name: "epoch_height", Type: export.Int64},
+		{Name: "chain_id", Type: export.Int64},
+		{Name: "status", Type: export.Int64},
+		{Name: "data", Type: export.String},
+	}},
+	{"logs", []export.Column{
+		{Name: "epoch", Type: export.Int64},
+		{Name: "block_hash", Type: export.String},
+		{Name: "transaction_hash", Type: export.String},
+		{Name: "transaction_index", Type: export.Int64},
+		{Name: "log_index", Type: export.Int64},
+		{Name: "address", Type: export.String},
+		{Name: "topic0", Type: export.String},
+		{Name: "topic1", Type: export.String},
+		{Name: "topic2", Type: export.String},
+		{Name: "topic3", Type: export.String},
+		{Name: "data", Type: export.String},
+	}},
+	{"traces", []export.Column{
+		{Name: "epoch", Type: export.Int64},
+		{Name: "block_hash", Type: export.String},
+		{Name: "transaction_hash", Type: export.String},
+		{Name: "transaction_position", Type: export.Int64},
+		{Name: "trace_index", Type: export.Int64},
+		{Name: "type", Type: export.String},
+		{Name: "valid", Type: export.Bool},
+		{Name: "from", Type: export.String},
+		{Name: "to", Type: export.String},
+		{Name: "value", Type: export.String},
+		{Name: "gas", Type: export.String},
+		{Name: "call_type", Type: export.String},
+		{Name: "outcome", Type: export.String},
+		{Name: "input", Type: export.String},
+		{Name: "output", Type: export.String},
+	}},
+}
+
+// exportSqlTables returns the SQL schema of export tables.
+func exportSqlTables() []report.SqlTable {
+	sqlTypes := map[export.ColumnType]string{
+		export.Bool:   "BOOLEAN",
+		export.Int64:  "BIGINT",
+		export.String: "TEXT",
+	}
+
+	var tables []report.SqlTable
+	for _, table := range exportTables {
+		var columns []report.SqlColumn
+		for _, column := range table.columns {
+			columns = append(columns, report.SqlColumn{Name: column.Name, Type: sqlTypes[column.Type]})
+		}
+
+		tables = append(tables, report.SqlTable{Name: table.name, Columns: columns})
+	}
+
+	return tables
+}
+
+// flattenedTrace is the block trace decoded with generic action of any trace type.
+type flattenedTrace struct {
+	BlockHash         string `json:"blockHash"`
+	TransactionTraces []struct {
+		TransactionHash     string         `json:"transactionHash"`
+		TransactionPosition hexutil.Uint64 `json:"transactionPosition"`
+		Traces              []struct {
+			Type   string         `json:"type"`
+			Valid  bool           `json:"valid"`
+			Action map[string]any `json:"action"`
+		} `json:"traces"`
+	} `json:"transactionTraces"`
+}
+
+// flattenEpoch flattens the raw data of epoch into rows of transactions, logs and traces tables.
+func flattenEpoch(data ExportedEpoch) ([3][][]any, error) {
+	var tables [3][][]any
+	epoch := int64(data.Epoch)
+
+	for _, raw := range data.Blocks {
+		var block types.Block
+		if err := json.Unmarshal(raw, &block); err != nil {
+			return tables, errors.WithMessage(err, "Failed to decode block")
+		}
+
+		for _, tx := range block.Transactions {
+			tables[0] = append(tables[0], []any{
+				epoch, block.Hash.String(), optUint64(tx.TransactionIndex), tx.Hash.String(), tx.From.String(),
+				optAddress(tx.To), optAddress(tx.ContractCreated), optInt64(tx.Nonce), optBig(tx.Value),
+				optInt64(tx.Gas), optBig(tx.GasPrice), optInt64(tx.StorageLimit), optInt64(tx.EpochHeight),
+				optInt64(tx.ChainID), optUint64(tx.Status), tx.Data,
+			})
+		}
+	}
+
+	var receipts [][]types.TransactionReceipt
+	if err := json.Unmarshal(data.Receipts, &receipts); err != nil {
+		return tables, errors.WithMessage(err, "Failed to decode receipts")
+	}
+
+	for _, blockReceipts := range receipts {
+		for _, receipt := range blockReceipts {
+			for i, log := range receipt.Logs {
+				row := []any{
+					epoch, receipt.BlockHash.String(), receipt.TransactionHash.String(), int64(receipt.Index),
+					int64(i), log.Address.String(), nil, nil, nil, nil, hexutil.Encode(log.Data),
+				}
+
+				for j, topic := range log.Topics[:min(len(log.Topics), 4)] {
+					row[6+j] = topic.String()
+				}
+
+				tables[1] = append(tables[1], row)
+			}
+		}
+	}
+
+	for _, raw := range data.Traces {
+		var blockTrace flattenedTrace
+		if err := json.Unmarshal(raw, &blockTrace); err != nil {
+			return tables, errors.WithMessage(err, "Failed to decode block traces")
+		}
+
+		for _, txTrace := range blockTrace.TransactionTraces {
+			for j, trace := range txTrace.Traces {
+				action := trace.Action
+				tables[2] = append(tables[2], []any{
+					epoch, blockTrace.BlockHash, txTrace.TransactionHash, int64(txTrace.TransactionPosition), int64(j),
+					trace.Type, trace.Valid, actionString(action, "from"), actionString(action, "to"),
+					actionBig(action, "value"), actionBig(action, "gas"),
+					actionString(action, "callType", "createType"), actionString(action, "outcome"),
+					actionString(action, "input", "init"), actionString(action, "returnData"),
+				})
+			}
+		}
+	}
+
+	return tables, nil
+}
+
+func optAddress(v *types.Address) any {
+	if v == nil {
+		return nil
+	}
+
+	return v.String()
+}
+
+func optUint64(v *hexutil.Uint64) any {
+	if v == nil {
+		return nil
+	}
+
+	return int64(*v)
+}
+
+// optInt64 returns nil if value is nil or overflows int64.
+func optInt64(v *hexutil.Big) any {
+	if v == nil || !v.ToInt().IsInt64() {
+		return nil
+	}
+
+	return v.ToInt().Int64()
+}
+
+// optBig returns the decimal string of value, since it may overflow int64.
+func optBig(v *hexutil.Big) any {
+	if v == nil {
+		return nil
+	}
+
+	return v.ToInt().String()
+}
+
+// actionString returns the string value of the first available key in trace action.
+func actionString(action map[string]any, keys ...string) any {
+	for _, key := range keys {
+		if v, ok := action[key].(string); ok {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// actionBig returns the decimal string of hex quantity in trace action.
+func actionBig(action map[string]any, key string) any {
+	v, ok := action[key].(string)
+	if !ok {
+		return nil
+	}
+
+	value, err := hexutil.DecodeBig(v)
+	if err != nil {
+		return v
+	}
+
+	return value.String()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var followFlags struct {
+	Interval time.Duration
+	Duration time.Duration
+	MaxWait  time.Duration
+}
+
+func newFollowCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "follow",
+		Short: "Follow the latest epochs to measure data availability lag",
+		Run:   follow,
+	}
+
+	cmd.Flags().DurationVar(&followFlags.Interval, "interval", time.Second, "Interval to poll the latest epoch and pending data")
+	cmd.Flags().DurationVar(&followFlags.Duration, "duration", 5*time.Minute, "Duration to follow the latest epochs")
+	cmd.Flags().DurationVar(&followFlags.MaxWait, "max-wait", 5*time.Minute, "Maximum duration to wait for data of an epoch to be available")
+
+	return &cmd
+}
+
+// Data types to measure availability lag.
+const (
+	dataTypeBlock    = "block"
+	dataTypeReceipts = "receipts"
+	dataTypeTraces   = "traces"
+)
+
+// followingEpoch is a new epoch of which some data is not available yet.
+type followingEpoch struct {
+	number    uint64
+	pivot     types.Hash
+	timestamp time.Time           // timestamp of pivot block
+	seen      time.Time           // when the epoch is observed at first
+	available map[string]struct{} // available data types
+}
+
+func follow(*cobra.Command, []string) {
+	client := mustNewClient()
+	defer client.Close()
+
+	latestEpoch, err := client.GetEpochNumber(types.EpochLatestMined)
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get latest mined epoch")
+	}
+
+	stat := FollowStat{
+		Lags: make(stats.LatencyStats),
+	}
+	nextEpoch := latestEpoch.ToInt().Uint64() + 1
+	var pending []*followingEpoch
+
+	ticker := time.NewTicker(followFlags.Interval)
+	defer ticker.Stop()
+
+	for start := time.Now(); time.Since(start) < followFlags.Duration; <-ticker.C {
+		latestEpoch, err := client.GetEpochNumber(types.EpochLatestMined)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to get latest mined epoch")
+			stat.NumErrors++
+			continue
+		}
+
+		for ; nextEpoch <= latestEpoch.ToInt().Uint64(); nextEpoch++ {
+			pending = append(pending, &followingEpoch{
+				number:    nextEpoch,
+				seen:      time.Now(),
+				available: make(map[string]struct{}),
+			})
+		}
+
+		var stillPending []*followingEpoch
+		for _, epoch := range pending {
+			lags, err := pollEpochData(client, epoch)
+			if err != nil {
+				logrus.WithError(err).WithField("epoch", epoch.number).Warn("Failed to poll epoch data")
+				stat.NumErrors++
+			}
+
+			stat.Lags.Add(lags)
+
+			if len(epoch.available) == 3 {
+				stat.NumEpochs++
+			} else if time.Since(epoch.seen) > followFlags.MaxWait {
+				logrus.WithField("epoch", epoch.number).Warn("Epoch data not available in time")
+				stat.NumTimeouts++
+			} else {
+				stillPending = append(stillPending, epoch)
+			}
+		}
+		pending = stillPending
+
+		logrus.WithFields(logrus.Fields{
+			"latest":  latestEpoch.ToInt(),
+			"pending": len(pending),
+		}).Debug("Following epochs")
+	}
+
+	printResult(stat)
+}
+
+// pollEpochData tries to retrieve the data types that not available yet, and returns the lag between the
+// pivot block timestamp and now for data types that become available.
+func pollEpochData(client *sdk.Client, epoch *followingEpoch) (stats.MethodLatency, error) {
+	lags := stats.NewMethodLatency(0)
+
+	if _, ok := epoch.available[dataTypeBlock]; !ok {
+		blocks, err := client.GetBlocksByEpoch(types.NewEpochNumberUint64(epoch.number))
+		if err != nil {
+			return lags, errors.WithMessage(err, "Failed to get blocks by epoch")
+		}
+
+		if len(blocks) == 0 {
+			return lags, nil
+		}
+
+		pivot, err := client.GetBlockSummaryByHash(blocks[len(blocks)-1])
+		if err != nil {
+			return lags, errors.WithMessage(err, "Failed to get pivot block")
+		}
+
+		if pivot == nil || pivot.Timestamp == nil {
+			return lags, nil
+		}
+
+		epoch.pivot = pivot.Hash
+		epoch.timestamp = time.Unix(pivot.Timestamp.ToInt().Int64(), 0)
+		epoch.available[dataTypeBlock] = struct{}{}
+		lags.Record(dataTypeBlock, time.Since(epoch.timestamp))
+	}
+
+	// receipts are not available until executed, so errors are regarded as not available
+	if _, ok := epoch.available[dataTypeReceipts]; !ok {
+		receipts, err := client.GetEpochReceiptsByPivotBlockHash(epoch.pivot)
+		if err == nil && receipts != nil {
+			epoch.available[dataTypeReceipts] = struct{}{}
+			lags.Record(dataTypeReceipts, time.Since(epoch.timestamp))
+		}
+	}
+
+	if _, ok := epoch.available[dataTypeTraces]; !ok {
+		traces, err := client.GetBlockTraces(epoch.pivot)
+		if err == nil && traces != nil {
+			epoch.available[dataTypeTraces] = struct{}{}
+			lags.Record(dataTypeTraces, time.Since(epoch.timestamp))
+		}
+	}
+
+	return lags, nil
+}
+
+// FollowStat is the statistics of data availability lag by data type.
+type FollowStat struct {
+	NumEpochs   int // epochs of which all data types are available
+	NumTimeouts int
+	NumErrors   int
+
+	Lags stats.LatencyStats
+}
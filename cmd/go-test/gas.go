@@ -0,0 +1,131 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"math/big"
+	"slices"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+)
+
+// FeePercentiles is the statistics of fees in drip.
+type FeePercentiles struct {
+	Min    *big.Int
+	Median *big.Int
+	P95    *big.Int
+	Max    *big.Int
+}
+
+// feePercentilesOf returns the statistics of fees, or nil if empty.
+func feePercentilesOf(fees []*big.Int) *FeePercentiles {
+	if len(fees) == 0 {
+		return nil
+	}
+
+	percentiles := stats.NewPercentiles(fees, (*big.Int).Cmp)
+
+	return &FeePercentiles{
+		Min:    percentiles.Min(),
+		Median: percentiles.At(50),
+		P95:    percentiles.At(95),
+		Max:    percentiles.Max(),
+	}
+}
+
+// gasFees is the gas price, and max fee and max priority fee of dynamic fee transactions if any.
+type gasFees struct {
+	gasPrices       []*big.Int
+	maxFees         []*big.Int
+	maxPriorityFees []*big.Int
+}
+
+func (fees *gasFees) add(tx *types.Transaction) {
+	if tx.GasPrice != nil {
+		fees.gasPrices = append(fees.gasPrices, tx.GasPrice.ToInt())
+	}
+	if tx.MaxFeePerGas != nil {
+		fees.maxFees = append(fees.maxFees, tx.MaxFeePerGas.ToInt())
+	}
+	if tx.MaxPriorityFeePerGas != nil {
+		fees.maxPriorityFees = append(fees.maxPriorityFees, tx.MaxPriorityFeePerGas.ToInt())
+	}
+}
+
+func (fees *gasFees) merge(other gasFees) {
+	fees.gasPrices = append(fees.gasPrices, other.gasPrices...)
+	fees.maxFees = append(fees.maxFees, other.maxFees...)
+	fees.maxPriorityFees = append(fees.maxPriorityFees, other.maxPriorityFees...)
+}
+
+// GasPriceSample is the gas price statistics of transactions in an epoch, or across the scanned range.
+type GasPriceSample struct {
+	Epoch          uint64 `json:",omitempty"`
+	NumTxs         int
+	GasPrice       *FeePercentiles `json:",omitempty"`
+	MaxFee         *FeePercentiles `json:",omitempty"`
+	MaxPriorityFee *FeePercentiles `json:",omitempty"`
+
+	fees gasFees
+}
+
+// gasPriceSampleOf aggregates gas prices of executed transactions in blocks of epoch.
+func gasPriceSampleOf(epoch uint64, blocks []*types.Block) GasPriceSample {
+	sample := GasPriceSample{Epoch: epoch}
+
+	for _, block := range blocks {
+		for i := range block.Transactions {
+			// skip transactions not executed in this block, e.g. duplicated ones
+			if tx := &block.Transactions[i]; tx.Status != nil && *tx.Status != txStatusSkipped {
+				sample.NumTxs++
+				sample.fees.add(tx)
+			}
+		}
+	}
+
+	sample.summarize()
+
+	return sample
+}
+
+func (sample *GasPriceSample) summarize() {
+	sample.GasPrice = feePercentilesOf(sample.fees.gasPrices)
+	sample.MaxFee = feePercentilesOf(sample.fees.maxFees)
+	sample.MaxPriorityFee = feePercentilesOf(sample.fees.maxPriorityFees)
+}
+
+// GasPriceStat profiles the fee market by gas prices of transactions per epoch and across the scanned range.
+type GasPriceStat struct {
+	numTxs int
+	fees   gasFees
+
+	Epochs []GasPriceSample
+}
+
+func (stat *GasPriceStat) Add(sample GasPriceSample) {
+	if sample.NumTxs == 0 {
+		return
+	}
+
+	stat.numTxs += sample.NumTxs
+	stat.fees.merge(sample.fees)
+
+	sample.fees = gasFees{}
+	stat.Epochs = append(stat.Epochs, sample)
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the statistics across the scanned range, along
+// with samples in epoch order regardless of the collection order, e.g. epochs retried at the end.
+func (stat *GasPriceStat) MarshalJSON() ([]byte, error) {
+	overall := GasPriceSample{NumTxs: stat.numTxs, fees: stat.fees}
+	overall.summarize()
+
+	epochs := slices.Clone(stat.Epochs)
+	slices.SortStableFunc(epochs, func(a, b GasPriceSample) int { return cmp.Compare(a.Epoch, b.Epoch) })
+
+	return json.Marshal(struct {
+		Overall GasPriceSample
+		Epochs  []GasPriceSample
+	}{overall, epochs})
+}
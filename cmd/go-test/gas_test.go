@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func testGasPriceBlock(gasPrices ...int64) *types.Block {
+	var block types.Block
+	for _, v := range gasPrices {
+		status := hexutil.Uint64(0)
+		block.Transactions = append(block.Transactions, types.Transaction{GasPrice: (*hexutil.Big)(big.NewInt(v)), Status: &status})
+	}
+
+	return &block
+}
+
+func TestGasPriceStat(t *testing.T) {
+	var stat GasPriceStat
+	stat.Add(gasPriceSampleOf(3, []*types.Block{testGasPriceBlock(30)}))
+	stat.Add(gasPriceSampleOf(1, []*types.Block{testGasPriceBlock(10, 20)}))
+	stat.Add(gasPriceSampleOf(4, nil)) // skipped without transactions
+	stat.Add(gasPriceSampleOf(2, []*types.Block{testGasPriceBlock(40)}))
+
+	encoded, err := json.Marshal(&stat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Overall GasPriceSample
+		Epochs  []GasPriceSample
+	}
+	if err = json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if overall := decoded.Overall; overall.NumTxs != 4 || overall.GasPrice.Min.Int64() != 10 ||
+		overall.GasPrice.Median.Int64() != 20 || overall.GasPrice.Max.Int64() != 40 {
+		t.Fatalf("Unexpected overall statistics %s", encoded)
+	}
+
+	if len(decoded.Epochs) != 3 || decoded.Epochs[0].Epoch != 1 || decoded.Epochs[1].Epoch != 2 || decoded.Epochs[2].Epoch != 3 {
+		t.Fatalf("Expected epochs in order, but got %s", encoded)
+	}
+}
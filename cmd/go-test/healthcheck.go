@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var healthcheckFlags struct {
+	MaxLatency time.Duration
+}
+
+func newHealthcheckCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "healthcheck",
+		Short: "Probe the latency and consistency of fullnode, and exit 0 if healthy or 1 otherwise with a one-line JSON result",
+		Long: `Probe the latency and consistency of fullnode, and exit 0 if healthy or 1 otherwise with a one-line JSON result,
+e.g. as a container liveness/readiness probe or an external blackbox probe. The fullnode is healthy if:
+  - the latest mined, state and finalized epoch numbers are in order.
+  - the pivot block of the latest state epoch is available and belongs to the epoch.
+  - the latency of each request is within --max-latency.`,
+		Run: healthcheck,
+	}
+
+	cmd.Flags().DurationVar(&healthcheckFlags.MaxLatency, "max-latency", time.Second, "Maximum latency of each request to regard fullnode as healthy")
+
+	return &cmd
+}
+
+// HealthResult is the one-line result of health probe.
+type HealthResult struct {
+	Healthy   bool
+	Error     string        `json:",omitempty"`
+	Mined     uint64        `json:",omitempty"`
+	State     uint64        `json:",omitempty"`
+	Finalized uint64        `json:",omitempty"`
+	Latency   time.Duration // maximum latency of requests
+}
+
+func healthcheck(*cobra.Command, []string) {
+	result, err := probeHealth()
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	result.Healthy = err == nil
+
+	data, _ := json.Marshal(result)
+	fmt.Println(string(data))
+
+	if !result.Healthy {
+		os.Exit(1)
+	}
+}
+
+func probeHealth() (result HealthResult, err error) {
+	client, err := sdk.NewClient(chaosUrl(flags.Url), flags.RpcOption)
+	if err != nil {
+		return result, errors.WithMessage(err, "Failed to create client")
+	}
+	defer client.Close()
+
+	measure := func(f func() error) error {
+		start := time.Now()
+		err := f()
+		elapsed := time.Since(start)
+
+		result.Latency = max(result.Latency, elapsed)
+
+		if err == nil && elapsed > healthcheckFlags.MaxLatency {
+			return errors.Errorf("Latency %v exceeds %v", elapsed, healthcheckFlags.MaxLatency)
+		}
+
+		return err
+	}
+
+	for _, v := range []struct {
+		epoch  *types.Epoch
+		number *uint64
+	}{
+		{types.EpochLatestMined, &result.Mined},
+		{types.EpochLatestState, &result.State},
+		{types.EpochLatestFinalized, &result.Finalized},
+	} {
+		if err = measure(func() error {
+			epoch, err := client.GetEpochNumber(v.epoch)
+			if err == nil {
+				*v.number = epoch.ToInt().Uint64()
+			}
+
+			return err
+		}); err != nil {
+			return result, errors.WithMessagef(err, "Failed to get epoch number of %v", v.epoch)
+		}
+	}
+
+	if result.Finalized > result.State || result.State > result.Mined {
+		return result, errors.New("Latest epoch numbers out of order")
+	}
+
+	var pivot *types.BlockSummary
+	if err = measure(func() (err error) {
+		pivot, err = getPivotBlock(client, types.NewEpochNumberUint64(result.State))
+		return
+	}); err != nil {
+		return result, errors.WithMessage(err, "Failed to get pivot block of latest state epoch")
+	}
+
+	if pivot.EpochNumber == nil || pivot.EpochNumber.ToInt().Uint64() != result.State {
+		return result, errors.Errorf("Pivot block %v not in latest state epoch", pivot.Hash)
+	}
+
+	return result, nil
+}
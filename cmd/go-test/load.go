@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+)
+
+// OpenLoopStat is the offered and achieved load of open-loop execution.
+type OpenLoopStat struct {
+	TargetQPS   float64
+	SentQPS     float64 // rate that requests are actually issued
+	AchievedQPS float64 // rate that requests are completed
+	MaxInflight int64
+}
+
+// RunOpenLoop issues tasks at the target QPS regardless of how long each task takes, unlike parallel.Serial
+// that issues the next task only when any routine is idle. Task results are collected in completion order.
+func RunOpenLoop[T any](ctx context.Context, tasks parallel.Interface[T], numTasks int, qps float64) (OpenLoopStat, error) {
+	stat := OpenLoopStat{TargetQPS: qps}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		inflight atomic.Int64
+		firstErr error
+	)
+
+	// at least 1ns, since ticker panics on non-positive interval, e.g. qps beyond 1e9
+	ticker := time.NewTicker(max(time.Duration(float64(time.Second)/qps), 1))
+	defer ticker.Stop()
+
+	start := time.Now()
+
+	for task := 0; task < numTasks; task++ {
+		select {
+		case <-ctx.Done():
+			return stat, ctx.Err()
+		case <-ticker.C:
+		}
+
+		wg.Add(1)
+		go func(task int) {
+			defer wg.Done()
+
+			current := inflight.Add(1)
+			value, err := tasks.ParallelDo(ctx, 0, task)
+			inflight.Add(-1)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			stat.MaxInflight = max(stat.MaxInflight, current)
+
+			if err := tasks.ParallelCollect(ctx, &parallel.Result[T]{Task: task, Value: value, Err: err}); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}(task)
+	}
+
+	stat.SentQPS = float64(numTasks) / time.Since(start).Seconds()
+
+	wg.Wait()
+
+	stat.AchievedQPS = float64(numTasks) / time.Since(start).Seconds()
+
+	return stat, firstErr
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+)
+
+type testOpenLoopTasks struct {
+	collected int
+}
+
+func (tasks *testOpenLoopTasks) ParallelDo(ctx context.Context, routine, task int) (int, error) {
+	return task, nil
+}
+
+func (tasks *testOpenLoopTasks) ParallelCollect(ctx context.Context, result *parallel.Result[int]) error {
+	tasks.collected++
+	return nil
+}
+
+func TestRunOpenLoopHighQPS(t *testing.T) {
+	for _, qps := range []float64{1e9, 2e9, math.Inf(1)} {
+		var tasks testOpenLoopTasks
+
+		stat, err := RunOpenLoop(context.Background(), &tasks, 3, qps)
+		if err != nil {
+			t.Fatalf("Failed to run at %v QPS: %v", qps, err)
+		}
+
+		if tasks.collected != 3 || stat.TargetQPS != qps {
+			t.Errorf("Unexpected %v tasks collected of stat %+v at %v QPS", tasks.collected, stat, qps)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"math/rand"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// LogFuzzResult is the result of querying logs with randomized filters at a sampled epoch.
+type LogFuzzResult struct {
+	Queries    int
+	Violations int // logs returned but not known from receipts or not matching the filter
+	Missing    int // logs known from receipts and matching the filter but not returned
+}
+
+func (result *LogFuzzResult) Add(other LogFuzzResult) {
+	result.Queries += other.Queries
+	result.Violations += other.Violations
+	result.Missing += other.Missing
+}
+
+// FuzzLogFilters queries cfx_getLogs at the given epoch with randomized filters, whose addresses and topics
+// are drawn from logs in receipts, and verifies the returned logs against the logs known from receipts.
+func FuzzLogFilters(client *sdk.Client, epochNumber uint64, receipts [][]types.TransactionReceipt, samples int, latency stats.MethodLatency) (result LogFuzzResult, err error) {
+	var known []types.Log
+	for _, blockReceipts := range receipts {
+		for _, receipt := range blockReceipts {
+			for _, log := range receipt.Logs {
+				if log.Space == nil || *log.Space == types.SPACE_NATIVE {
+					known = append(known, log)
+				}
+			}
+		}
+	}
+
+	if len(known) == 0 {
+		return result, nil
+	}
+
+	for i := 0; i < samples; i++ {
+		filter := randomLogFilter(known)
+		filter.FromEpoch = types.NewEpochNumberUint64(epochNumber)
+		filter.ToEpoch = types.NewEpochNumberUint64(epochNumber)
+
+		var logs []types.Log
+		if err = latency.Measure("cfx_getLogs", func() (err error) {
+			logs, err = client.GetLogs(filter)
+			return
+		}); err != nil {
+			return LogFuzzResult{}, errors.WithMessage(err, "Failed to get logs with random filter")
+		}
+
+		result.Queries++
+
+		// logs known from receipts that match the filter, counted by content
+		expected := make(map[string]int)
+		var numExpected int
+		for _, log := range known {
+			if logMatches(log, filter) {
+				expected[logKey(log)]++
+				numExpected++
+			}
+		}
+
+		var violations int
+		for _, log := range logs {
+			key := logKey(log)
+			if !logMatches(log, filter) || expected[key] == 0 {
+				violations++
+				continue
+			}
+
+			expected[key]--
+		}
+
+		missing := numExpected - (len(logs) - violations)
+
+		if violations > 0 || missing > 0 {
+			logrus.WithFields(logrus.Fields{
+				"epoch":      epochNumber,
+				"address":    filter.Address,
+				"topics":     filter.Topics,
+				"violations": violations,
+				"missing":    missing,
+				"curl":       curl("cfx_getLogs", filter),
+			}).Warn("Logs mismatch with random filter")
+			archiveMismatch(client, epochNumber, "logs mismatch with random filter", "cfx_getLogs", filter)
+		}
+
+		result.Violations += violations
+		result.Missing += missing
+	}
+
+	return result, nil
+}
+
+// randomLogFilter returns a valid log filter that matches a random log at least, with addresses and topics
+// optionally widened by other known logs or replaced with wildcards.
+func randomLogFilter(known []types.Log) (filter types.LogFilter) {
+	seed := known[rand.Intn(len(known))]
+
+	if rand.Intn(2) == 0 {
+		filter.Address = []types.Address{seed.Address}
+
+		if other := known[rand.Intn(len(known))]; rand.Intn(2) == 0 && other.Address.String() != seed.Address.String() {
+			filter.Address = append(filter.Address, other.Address)
+		}
+	}
+
+	for i, topic := range seed.Topics {
+		// wildcard
+		if rand.Intn(2) == 0 {
+			filter.Topics = append(filter.Topics, nil)
+			continue
+		}
+
+		topics := []types.Hash{topic}
+		if other := known[rand.Intn(len(known))]; rand.Intn(2) == 0 && len(other.Topics) > i && other.Topics[i] != topic {
+			topics = append(topics, other.Topics[i])
+		}
+
+		filter.Topics = append(filter.Topics, topics)
+	}
+
+	// trim trailing wildcards
+	for len(filter.Topics) > 0 && filter.Topics[len(filter.Topics)-1] == nil {
+		filter.Topics = filter.Topics[:len(filter.Topics)-1]
+	}
+
+	return filter
+}
+
+// logMatches returns true if the log matches the address and topics of filter.
+func logMatches(log types.Log, filter types.LogFilter) bool {
+	if len(filter.Address) > 0 {
+		var found bool
+		for _, v := range filter.Address {
+			if v.String() == log.Address.String() {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if len(filter.Topics) > len(log.Topics) {
+		return false
+	}
+
+	for i, topics := range filter.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+
+		var found bool
+		for _, v := range topics {
+			if v == log.Topics[i] {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// logKey returns the content key of log to match logs from receipts and cfx_getLogs.
+func logKey(log types.Log) string {
+	key := log.Address.String()
+
+	for _, topic := range log.Topics {
+		key += string(topic)
+	}
+
+	return key + log.Data.String()
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var logsFlags struct {
+	EpochTo     uint64
+	EpochRange  uint64
+	Windows     []int
+	NumRequests int
+	Addresses   []string
+	Topics      []string
+	CheckLimits bool
+}
+
+func newLogsCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "logs",
+		Short: "Benchmark cfx_getLogs over increasing epoch windows",
+		Run:   benchLogs,
+	}
+
+	cmd.Flags().Uint64Var(&logsFlags.EpochTo, "epoch-to", 0, "Epoch number to query logs until, by default the latest finalized epoch")
+	cmd.Flags().Uint64Var(&logsFlags.EpochRange, "epoch-range", 10000, "Range of epochs to randomly place the query windows in")
+	cmd.Flags().IntSliceVar(&logsFlags.Windows, "windows", []int{1, 10, 100, 1000}, "Epoch window sizes to sweep")
+	cmd.Flags().IntVar(&logsFlags.NumRequests, "count", 10, "Number of requests for each window size")
+	cmd.Flags().StringSliceVar(&logsFlags.Addresses, "address", nil, "Contract addresses to filter logs, by default the most active one in recent logs")
+	cmd.Flags().StringArrayVar(&logsFlags.Topics, "topic", nil, "Event signature or 32-byte topic hash to filter logs, e.g. \"Transfer(address,address,uint256)\", could be repeated, by default the most active one in recent logs")
+	cmd.Flags().BoolVar(&logsFlags.CheckLimits, "check-limits", false, "Check server limits of cfx_getLogs and whether splitting the window reproduces the full result set")
+
+	return &cmd
+}
+
+func benchLogs(*cobra.Command, []string) {
+	client := mustNewClient()
+	defer client.Close()
+
+	epochTo := logsFlags.EpochTo
+	if epochTo == 0 {
+		latestFinalizedEpoch, err := client.GetEpochNumber(types.EpochLatestFinalized)
+		if err != nil {
+			fatal(ExitPreflight, logrus.WithError(err), "Failed to get latest epoch number")
+		}
+		epochTo = latestFinalizedEpoch.ToInt().Uint64()
+	}
+
+	filter, err := resolveLogFilter(client, epochTo)
+	if err != nil {
+		fatal(ExitConfig, logrus.WithError(err), "Failed to resolve log filter")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"address": filter.Address,
+		"topics":  filter.Topics,
+	}).Info("Log filter resolved")
+
+	start := time.Now()
+
+	var result struct {
+		Windows []*LogsWindowStat
+		Limits  *LogsLimitStat `json:",omitempty"`
+	}
+
+	for _, window := range logsFlags.Windows {
+		for _, filtered := range []bool{false, true} {
+			stat := LogsWindowStat{
+				Window:   uint64(window),
+				Filtered: filtered,
+				Latency:  &stats.LatencyStat{},
+			}
+
+			for i := 0; i < logsFlags.NumRequests; i++ {
+				from, to := randomWindow(epochTo, logsFlags.EpochRange, stat.Window)
+
+				query := types.LogFilter{
+					FromEpoch: types.NewEpochNumberUint64(from),
+					ToEpoch:   types.NewEpochNumberUint64(to),
+				}
+				if filtered {
+					query.Address = filter.Address
+					query.Topics = filter.Topics
+				}
+
+				queryStart := time.Now()
+				logs, err := client.GetLogs(query)
+				if err != nil {
+					logrus.WithError(err).WithFields(logrus.Fields{
+						"from":     from,
+						"to":       to,
+						"filtered": filtered,
+					}).Warn("Failed to get logs")
+					stat.NumErrors++
+					continue
+				}
+
+				stat.Latency.Add(time.Since(queryStart))
+				stat.NumLogs += len(logs)
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"window":   window,
+				"filtered": filtered,
+				"avg":      stat.Latency.Summary().Avg,
+			}).Info("Window swept")
+
+			result.Windows = append(result.Windows, &stat)
+		}
+	}
+
+	if logsFlags.CheckLimits {
+		result.Limits = &LogsLimitStat{}
+
+		for _, window := range logsFlags.Windows {
+			from, to := randomWindow(epochTo, logsFlags.EpochRange, uint64(window))
+
+			check, err := CheckLogsLimit(client, from, to)
+			if err != nil {
+				fatal(ExitFailure, logrus.WithError(err), "Failed to check logs limit")
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"window":     window,
+				"logs":       check.NumLogs,
+				"splitLogs":  check.NumSplitLogs,
+				"truncated":  check.Truncated,
+				"reproduced": check.Reproduced,
+			}).Info("Logs limit checked")
+
+			result.Limits.Add(check)
+		}
+	}
+
+	printResult(result)
+
+	printInfo("Total elapsed: %v", time.Since(start))
+}
+
+// randomWindow returns a random epoch window of given size within the range that ends at epochTo.
+func randomWindow(epochTo, epochRange, window uint64) (from, to uint64) {
+	epochRange = min(epochRange, epochTo+1)
+	window = max(min(window, epochRange), 1)
+
+	from = epochTo + 1 - epochRange + uint64(rand.Int63n(int64(epochRange-window+1)))
+
+	return from, from + window - 1
+}
+
+// resolveLogFilter returns the address and topic filter from flags, or the most active ones in recent logs.
+func resolveLogFilter(client *sdk.Client, epochTo uint64) (filter types.LogFilter, err error) {
+	for _, v := range logsFlags.Addresses {
+		address, err := client.NewAddress(v)
+		if err != nil {
+			return types.LogFilter{}, errors.WithMessagef(err, "Invalid address %v", v)
+		}
+
+		filter.Address = append(filter.Address, address)
+	}
+
+	if len(logsFlags.Topics) > 0 {
+		var topics []types.Hash
+		for _, v := range logsFlags.Topics {
+			topic, err := eventTopic(v)
+			if err != nil {
+				return types.LogFilter{}, err
+			}
+
+			topics = append(topics, topic)
+		}
+		filter.Topics = [][]types.Hash{topics}
+	}
+
+	if len(filter.Address) > 0 && len(filter.Topics) > 0 {
+		return filter, nil
+	}
+
+	// find out the most active address and topic in recent logs
+	from, to := randomWindow(epochTo, 100, 100)
+	logs, err := client.GetLogs(types.LogFilter{
+		FromEpoch: types.NewEpochNumberUint64(from),
+		ToEpoch:   types.NewEpochNumberUint64(to),
+	})
+	if err != nil {
+		return types.LogFilter{}, errors.WithMessage(err, "Failed to get recent logs")
+	}
+
+	var active *types.Log
+	var maxCount int
+	counts := make(map[string]int)
+	for i, log := range logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+
+		key := log.Address.String() + string(log.Topics[0])
+		counts[key]++
+
+		if counts[key] > maxCount {
+			maxCount = counts[key]
+			active = &logs[i]
+		}
+	}
+
+	if active == nil {
+		return types.LogFilter{}, errors.New("No logs in recent epochs")
+	}
+
+	if len(filter.Address) == 0 {
+		filter.Address = []types.Address{active.Address}
+	}
+
+	if len(filter.Topics) == 0 {
+		filter.Topics = [][]types.Hash{{active.Topics[0]}}
+	}
+
+	return filter, nil
+}
+
+// eventTopic returns the topic of event signature, e.g. Transfer(address,address,uint256), or the topic hash
+// as it is if 0x prefixed.
+func eventTopic(v string) (types.Hash, error) {
+	if strings.HasPrefix(v, "0x") {
+		topic, err := hexutil.Decode(v)
+		if err != nil || len(topic) != common.HashLength {
+			return "", errors.Errorf("Invalid topic %v, expected 32 bytes in hex", v)
+		}
+
+		return types.Hash(hexutil.Encode(topic)), nil
+	}
+
+	if name, params, ok := strings.Cut(v, "("); !ok || len(name) == 0 || !strings.HasSuffix(params, ")") || strings.ContainsAny(v, " \t") {
+		return "", errors.Errorf("Invalid event signature %v, expected e.g. Transfer(address,address,uint256)", v)
+	}
+
+	return types.Hash(hexutil.Encode(crypto.Keccak256([]byte(v)))), nil
+}
+
+// LogsWindowStat is the statistics of cfx_getLogs for a window size.
+type LogsWindowStat struct {
+	Window    uint64
+	Filtered  bool
+	NumLogs   int
+	NumErrors int
+	Latency   *stats.LatencyStat
+}
+
+// LogsLimitCheck is the result of checking cfx_getLogs limits on an epoch window.
+type LogsLimitCheck struct {
+	From uint64
+	To   uint64
+
+	NumLogs   int    // number of logs returned by a single request for the whole window
+	ErrorCode int    `json:",omitempty"` // error code if the single request failed
+	Error     string `json:",omitempty"`
+
+	NumSplitLogs    int // number of logs returned by splitting the window
+	NumSplitQueries int
+
+	Truncated  bool // single request succeeded but returned fewer logs than splitting
+	Reproduced bool // splitting the window returned the full result set
+}
+
+// CheckLogsLimit queries logs of the whole window with a single request, and then with the window split
+// into halves recursively whenever the server rejects, to detect errors and truncation at the server limits.
+func CheckLogsLimit(client *sdk.Client, from, to uint64) (check LogsLimitCheck, err error) {
+	check.From, check.To = from, to
+
+	logs, err := client.GetLogs(types.LogFilter{
+		FromEpoch: types.NewEpochNumberUint64(from),
+		ToEpoch:   types.NewEpochNumberUint64(to),
+	})
+	if err != nil && !stats.IsRpcError(err) {
+		return LogsLimitCheck{}, errors.WithMessagef(err, "Failed to get logs from %v to %v", from, to)
+	}
+
+	singleOk := err == nil
+	if singleOk {
+		check.NumLogs = len(logs)
+	} else {
+		check.ErrorCode = stats.RpcErrorCode(err)
+		check.Error = err.Error()
+	}
+
+	// always split at least once, so as to compare with the single request
+	mid := from + (to-from)/2
+	ranges := [][2]uint64{{from, mid}}
+	if mid < to {
+		ranges = append(ranges, [2]uint64{mid + 1, to})
+	}
+
+	check.Reproduced = true
+	for _, r := range ranges {
+		numLogs, ok, err := getLogsSplit(client, r[0], r[1], &check.NumSplitQueries)
+		if err != nil {
+			return LogsLimitCheck{}, err
+		}
+
+		check.NumSplitLogs += numLogs
+		check.Reproduced = check.Reproduced && ok
+	}
+
+	if singleOk && check.Reproduced {
+		check.Truncated = check.NumLogs < check.NumSplitLogs
+		check.Reproduced = check.NumLogs <= check.NumSplitLogs
+	}
+
+	return check, nil
+}
+
+// getLogsSplit returns the number of logs in the window by splitting it into halves recursively whenever
+// the server rejects, or false if the server rejects even a single epoch.
+func getLogsSplit(client *sdk.Client, from, to uint64, queries *int) (numLogs int, ok bool, err error) {
+	*queries++
+
+	logs, err := client.GetLogs(types.LogFilter{
+		FromEpoch: types.NewEpochNumberUint64(from),
+		ToEpoch:   types.NewEpochNumberUint64(to),
+	})
+	if err == nil {
+		return len(logs), true, nil
+	}
+
+	if !stats.IsRpcError(err) {
+		return 0, false, errors.WithMessagef(err, "Failed to get logs from %v to %v", from, to)
+	}
+
+	if from == to {
+		logrus.WithError(err).WithField("epoch", from).Warn("Failed to get logs of a single epoch")
+		return 0, false, nil
+	}
+
+	mid := from + (to-from)/2
+
+	left, leftOk, err := getLogsSplit(client, from, mid, queries)
+	if err != nil {
+		return 0, false, err
+	}
+
+	right, rightOk, err := getLogsSplit(client, mid+1, to, queries)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return left + right, leftOk && rightOk, nil
+}
+
+// LogsLimitStat documents the effective limits of cfx_getLogs observed on the endpoint.
+type LogsLimitStat struct {
+	Checks []LogsLimitCheck
+
+	MaxWindow   uint64      // largest window served in full with a single request
+	MaxLogs     int         // largest number of logs served with a single request
+	MinRejected uint64      `json:",omitempty"` // smallest window rejected or truncated
+	ErrorCodes  map[int]int `json:",omitempty"`
+}
+
+func (stat *LogsLimitStat) Add(check LogsLimitCheck) {
+	stat.Checks = append(stat.Checks, check)
+
+	window := check.To - check.From + 1
+
+	if check.Error == "" && !check.Truncated {
+		stat.MaxWindow = max(stat.MaxWindow, window)
+		stat.MaxLogs = max(stat.MaxLogs, check.NumLogs)
+		return
+	}
+
+	if stat.MinRejected == 0 || window < stat.MinRejected {
+		stat.MinRejected = window
+	}
+
+	if check.Error != "" {
+		if stat.ErrorCodes == nil {
+			stat.ErrorCodes = make(map[int]int)
+		}
+
+		stat.ErrorCodes[check.ErrorCode]++
+	}
+}
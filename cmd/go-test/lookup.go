@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var lookupFlags struct {
+	EpochRange  uint64
+	NumSamples  int
+	NumRequests int
+	QPS         float64
+}
+
+func newLookupCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "receipt-lookup",
+		Short: "Benchmark cfx_getTransactionReceipt of random historical transactions",
+		Long: `Benchmark point lookups of transaction receipts, which are not measured by the epoch crawl.
+
+Transactions are sampled from pivot blocks of random epochs within the range that ends at the latest
+finalized epoch, and then receipts of random sampled transactions are queried by threads, or at the
+target QPS regardless of response latency.`,
+		Run: benchLookup,
+	}
+
+	cmd.Flags().Uint64Var(&lookupFlags.EpochRange, "epoch-range", 100000, "Range of epochs to randomly sample transactions in")
+	cmd.Flags().IntVar(&lookupFlags.NumSamples, "samples", 1000, "Number of transactions to sample")
+	cmd.Flags().IntVar(&lookupFlags.NumRequests, "count", 10000, "Number of receipt lookups")
+	cmd.Flags().Float64Var(&lookupFlags.QPS, "qps", 0, "Target QPS to issue requests regardless of response latency, 0 to issue by threads")
+
+	return &cmd
+}
+
+func benchLookup(*cobra.Command, []string) {
+	client := mustNewClient()
+	defer client.Close()
+
+	clients := mustNewShardClients(client)
+	for _, v := range clients[1:] {
+		defer v.Close()
+	}
+
+	latestFinalizedEpoch, err := client.GetEpochNumber(types.EpochLatestFinalized)
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get latest epoch number")
+	}
+
+	txs, err := sampleTransactions(client, latestFinalizedEpoch.ToInt().Uint64())
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to sample transactions")
+	}
+
+	logrus.WithField("txs", len(txs)).Info("Transactions sampled")
+
+	start := time.Now()
+	stat := LookupStat{
+		clients:    clients,
+		txs:        txs,
+		NumSamples: len(txs),
+		Latency:    &stats.LatencyStat{},
+		ErrorCodes: make(stats.ErrorStats),
+		Timeouts:   &timeoutHistogram,
+	}
+
+	if lookupFlags.QPS > 0 {
+		load, err := RunOpenLoop(context.Background(), &stat, lookupFlags.NumRequests, lookupFlags.QPS)
+		if err != nil {
+			fatal(ExitFailure, logrus.WithError(err), "Failed to look up receipts at target QPS")
+		}
+		stat.Load = &load
+	} else if err = parallel.Serial(context.Background(), &stat, lookupFlags.NumRequests, flags.ParallelOption); err != nil {
+		fatal(ExitFailure, logrus.WithError(err), "Failed to parallel look up receipts")
+	}
+
+	printResult(stat)
+
+	printInfo("Total elapsed: %v", time.Since(start))
+}
+
+// sampleTransactions samples transaction hashes from pivot blocks of random epochs, and gives up after
+// too many empty blocks, e.g. on a quiet chain.
+func sampleTransactions(client *sdk.Client, epochTo uint64) ([]types.Hash, error) {
+	var txs []types.Hash
+
+	for attempts := 0; len(txs) < lookupFlags.NumSamples && attempts < lookupFlags.NumSamples*10; attempts++ {
+		epoch, _ := randomWindow(epochTo, lookupFlags.EpochRange, 1)
+
+		block, err := client.GetBlockSummaryByEpoch(types.NewEpochNumberUint64(epoch))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Failed to get pivot block summary of epoch %v", epoch)
+		}
+
+		if len(block.Transactions) > 0 {
+			txs = append(txs, block.Transactions[rand.Intn(len(block.Transactions))])
+		}
+	}
+
+	if len(txs) == 0 {
+		return nil, errors.Errorf("No transaction found in %v epochs", lookupFlags.NumSamples*10)
+	}
+
+	return txs, nil
+}
+
+type LookupStat struct {
+	clients []*sdk.Client // requests are sharded across clients by task
+	txs     []types.Hash
+
+	NumSamples int
+	Latency    *stats.LatencyStat
+	Load       *OpenLoopStat `json:",omitempty"`
+
+	NumNotFound int // receipts not found, e.g. pruned by fullnode
+	NumErrors   int
+	ErrorCodes  stats.ErrorStats `json:",omitempty"`
+	Timeouts    *stats.TimeoutHistogram
+}
+
+// lookupResult is the latency of a receipt lookup, and whether the receipt found.
+type lookupResult struct {
+	latency time.Duration
+	found   bool
+}
+
+func (stat *LookupStat) ParallelDo(ctx context.Context, routine, task int) (lookupResult, error) {
+	txHash := stat.txs[rand.Intn(len(stat.txs))]
+
+	start := time.Now()
+	receipt, err := shardClient(stat.clients, task).GetTransactionReceipt(txHash)
+	if err != nil {
+		return lookupResult{}, errors.WithMessagef(err, "Failed to get transaction receipt by hash %v", txHash)
+	}
+
+	return lookupResult{time.Since(start), receipt != nil}, nil
+}
+
+func (stat *LookupStat) ParallelCollect(ctx context.Context, result *parallel.Result[lookupResult]) error {
+	if result.Err != nil {
+		logrus.WithError(result.Err).Warn("Failed to look up receipt")
+		stat.NumErrors++
+		stat.ErrorCodes.Add(result.Err)
+		return nil
+	}
+
+	stat.Latency.Add(result.Value.latency)
+
+	if !result.Value.found {
+		stat.NumNotFound++
+	}
+
+	return nil
+}
@@ -0,0 +1,1168 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+	"github.com/boqiu/go-test/pkg/chaos"
+	"github.com/boqiu/go-test/pkg/collect"
+	"github.com/boqiu/go-test/pkg/fetch"
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/boqiu/go-test/pkg/scan"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/openweb3/web3go"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var flags struct {
+	Network   string
+	Quiet     bool
+	LogLevel  string
+	Schedule  string
+	Output    string
+	Json      bool
+	Color     string
+	Manifest  string
+	Url       string
+	RpcOption sdk.ClientOption
+	ChainId   uint64
+	EspaceUrl string
+	ScanUrl   string
+
+	TimeoutBlocks   time.Duration
+	TimeoutReceipts time.Duration
+	TimeoutTraces   time.Duration
+	MethodTimeouts  map[string]string
+	SlowThreshold   time.Duration
+
+	Endpoints       []string
+	BreakerFailures int
+	BreakerCooldown time.Duration
+
+	EpochFrom uint64
+	NumEpochs uint64
+
+	ParallelOption parallel.SerialOption
+	FetchOption    fetch.Option
+	RawOption      fetch.RawOption
+	Jitter         time.Duration
+	NumClients     int
+	ReportInterval time.Duration
+
+	TraceSamples    int
+	SupplyInterval  uint64
+	AccountSamples  int
+	ContractSamples int
+	SponsorInfo     bool
+	StakingSamples  int
+	CheckNonce      bool
+	EstimateSamples int
+	CallSamples     int
+	BalanceSamples  int
+	LogFuzzSamples  int
+	ScanSamples     int
+	ReceiptsByPivot bool
+	VerifyBloom     bool
+	TxRootSamples   int
+	VerifyGas       bool
+	VerifyRefs      bool
+	VerifyTraces    bool
+	TraceBreakdown  bool
+	BlockCache      int
+	CrossSpace      bool
+	Espace          bool
+	CrossSpaceStats bool
+	GasPriceStats   bool
+	BaseFeeStats    bool
+	TopContracts    int
+	TopSenders      int
+	SendersCsv      string
+	Raw             bool
+	Stream          bool
+
+	FilterPollInterval time.Duration
+	ResourceInterval   time.Duration
+	RateInterval       time.Duration
+	SeriesWindow       time.Duration
+	CorrelationCsv     string
+	SkipPreflight      bool
+	DryRun             bool
+	DryRunSamples      int
+	FailFast           bool
+	RetryTimeout       time.Duration
+	EpochsFile         string
+	FailedEpochsFile   string
+	History            string
+	Sqlite             string
+	Postgres           string
+	GraphiteAddr       string
+	GraphitePrefix     string
+	MetricsTextfile    string
+	GithubAnnotations  bool
+	ErrorBudget        float64
+	Collectors         []string
+	Asserts            []string
+
+	Chaos      chaos.Option
+	ArchiveDir string
+	Strict     bool
+	CacheDir   string
+	CacheTTL   time.Duration
+}
+
+func main() {
+	cmd := cobra.Command{
+		Use:   "go-test",
+		Short: "QB test tool",
+		Long:  "QB test tool, of which flags could also be specified via environment variables, e.g. GOTEST_URL for --url.\n\n" + exitCodes,
+		Run:   test,
+
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := bindEnv(cmd); err != nil {
+				return err
+			}
+
+			if err := setupOutput(); err != nil {
+				return err
+			}
+
+			if len(flags.Schedule) > 0 {
+				err := runSchedule()
+				if errors.Is(err, errScheduleStopped) {
+					cmd.SilenceErrors, cmd.SilenceUsage = true, true
+				}
+
+				return err
+			}
+
+			if err := setupArchive(); err != nil {
+				return err
+			}
+
+			setupStrict()
+
+			if err := setupResponseCache(); err != nil {
+				return err
+			}
+
+			if v := flags.RawOption.Compression; v != "gzip" && v != "none" {
+				return errors.Errorf("Invalid compression %v, expected gzip or none", v)
+			}
+
+			if v := flags.RawOption.HttpVersion; v != "" && v != "1.1" && v != "2" {
+				return errors.Errorf("Invalid HTTP version %v, expected 1.1 or 2", v)
+			}
+
+			if err := applyNetworkPreset(cmd, args); err != nil {
+				return err
+			}
+
+			startManifest(cmd)
+
+			return nil
+		},
+		PersistentPostRun: func(*cobra.Command, []string) {
+			reportChaos()
+			reportResponseCache()
+		},
+	}
+
+	cmd.PersistentFlags().BoolVar(&flags.Quiet, "quiet", false, "Whether to only output the result and warnings")
+	cmd.PersistentFlags().StringVar(&flags.LogLevel, "log-level", "info", "Log level, e.g. debug to log failed RPC requests and trace to log all RPC requests with correlation ID and timing")
+	cmd.PersistentFlags().StringVar(&flags.Schedule, "schedule", "", "Cron-style schedule to stay resident and run the command periodically, e.g. \"0 */6 * * *\", where results are published to --output, --manifest or --history of each run")
+	cmd.PersistentFlags().StringVar(&flags.Output, "output", "", "File to output the result in JSON format, defaults to stdout")
+	cmd.PersistentFlags().BoolVar(&flags.Json, "json", false, "Whether to output the result in JSON format to stdout instead of the summary table")
+	cmd.PersistentFlags().StringVar(&flags.Color, "color", "auto", "Whether to color the summary table, auto, always or never, in which auto colors only on terminal unless NO_COLOR is set")
+	cmd.PersistentFlags().StringVar(&flags.Manifest, "manifest", "", "File to output the run manifest, including flags, tool version, time, endpoint and result")
+	cmd.PersistentFlags().StringVar(&flags.Network, "network", "mainnet", "Network preset of public endpoints and chain ID, "+networkNames())
+	cmd.PersistentFlags().StringVar(&flags.Url, "url", "", "Fullnode RPC endpoint, defaults to the public endpoint of network")
+	cmd.PersistentFlags().DurationVar(&flags.RpcOption.RequestTimeout, "rpc-timeout", 3*time.Second, "Fullnode RPC timeout")
+	cmd.PersistentFlags().DurationVar(&flags.TimeoutBlocks, "timeout-blocks", 0, "RPC timeout of block queries, 0 to use --rpc-timeout")
+	cmd.PersistentFlags().DurationVar(&flags.TimeoutReceipts, "timeout-receipts", 0, "RPC timeout of receipt queries, 0 to use --rpc-timeout")
+	cmd.PersistentFlags().DurationVar(&flags.TimeoutTraces, "timeout-traces", 0, "RPC timeout of trace queries, 0 to use --rpc-timeout")
+	cmd.PersistentFlags().StringToStringVar(&flags.MethodTimeouts, "method-timeout", nil, "RPC timeout of specific methods, e.g. cfx_getLogs=10s")
+	cmd.PersistentFlags().DurationVar(&flags.SlowThreshold, "slow-threshold", 0, "Log RPC requests slower than the threshold immediately with method, params and duration, e.g. 2s, 0 to disable")
+	cmd.PersistentFlags().Uint64Var(&flags.ChainId, "chain-id", 0, "Expected chain ID of fullnode, 0 to skip the verification")
+	cmd.PersistentFlags().StringVar(&flags.EspaceUrl, "espace-url", "", "eSpace RPC endpoint of the same network, defaults to the public endpoint of network")
+	cmd.PersistentFlags().IntVar(&flags.ParallelOption.Routines, "threads", 1, "Number of threads to query RPC")
+	cmd.PersistentFlags().IntVar(&flags.NumClients, "clients", 1, "Number of independent clients with separate connections to shard threads across, since a single client may become the bottleneck at high thread counts")
+	cmd.PersistentFlags().DurationVar(&flags.Jitter, "jitter", 0, "Maximum random delay before each RPC request, so as to avoid synchronized bursts from threads")
+	cmd.PersistentFlags().StringVar(&flags.ArchiveDir, "archive-dir", "", "Directory to save raw responses of decode errors or verification failures with method and params, so as to report to node developers")
+	cmd.PersistentFlags().BoolVar(&flags.Strict, "strict", false, "Whether to detect and report fields responded from fullnode but dropped by SDK, e.g. to catch protocol drift")
+	cmd.PersistentFlags().StringVar(&flags.CacheDir, "cache-dir", "", "Directory to cache RPC responses of lookups by hash or explicit epoch number, so as not to query fullnode repeatedly during development")
+	cmd.PersistentFlags().DurationVar(&flags.CacheTTL, "cache-ttl", time.Hour, "TTL of cached RPC responses, 0 to never expire")
+	cmd.PersistentFlags().Float64Var(&flags.Chaos.DropRate, "chaos-drop-rate", 0, "Probability in [0, 1] to drop requests via an internal fault injection proxy")
+	cmd.PersistentFlags().Float64Var(&flags.Chaos.DelayRate, "chaos-delay-rate", 0, "Probability in [0, 1] to delay requests by --chaos-delay via an internal fault injection proxy")
+	cmd.PersistentFlags().DurationVar(&flags.Chaos.Delay, "chaos-delay", time.Second, "Delay of requests injected by chaos proxy")
+	cmd.PersistentFlags().Float64Var(&flags.Chaos.MalformedRate, "chaos-malformed-rate", 0, "Probability in [0, 1] to respond malformed JSON via an internal fault injection proxy")
+	cmd.PersistentFlags().IntVar(&flags.ParallelOption.Window, "window", 100, "Maximum number of task results buffered for in-order collection, so that fast threads cannot race far ahead, 0 for no limit")
+	cmd.Flags().StringSliceVar(&flags.Endpoints, "endpoints", nil, "Additional fullnode RPC endpoints of the same network to distribute epochs across")
+	cmd.Flags().IntVar(&flags.BreakerFailures, "breaker-failures", 5, "Number of consecutive failures to eject an endpoint temporarily")
+	cmd.Flags().DurationVar(&flags.BreakerCooldown, "breaker-cooldown", 30*time.Second, "Duration to eject an endpoint before probing it again")
+	cmd.Flags().Uint64Var(&flags.EpochFrom, "epoch-from", 0, "Epoch number to test from")
+	cmd.Flags().Uint64Var(&flags.NumEpochs, "epoch-count", 30, "Number of epochs to test")
+	cmd.Flags().DurationVar(&flags.ReportInterval, "report-interval", time.Second, "Interval to report progress")
+	cmd.Flags().IntVar(&flags.TraceSamples, "trace-samples", 0, "Number of transactions per epoch to verify trace_transaction against block traces")
+	cmd.Flags().Uint64Var(&flags.SupplyInterval, "supply-interval", 0, "Interval of epochs to sample supply and interest rate, 0 to disable")
+	cmd.Flags().IntVar(&flags.AccountSamples, "account-samples", 0, "Number of transaction senders per epoch to query balance and nonce at the epoch")
+	cmd.Flags().IntVar(&flags.ContractSamples, "contract-samples", 0, "Number of contracts in receipts per epoch to query code and storage at the epoch")
+	cmd.Flags().BoolVar(&flags.SponsorInfo, "sponsor-info", false, "Whether to query sponsor info of all contracts in receipts")
+	cmd.Flags().IntVar(&flags.StakingSamples, "staking-samples", 0, "Number of transaction senders per epoch to query deposit and vote list at the epoch")
+	cmd.Flags().BoolVar(&flags.CheckNonce, "check-nonce", false, "Whether to validate nonce continuity of transaction senders")
+	cmd.Flags().IntVar(&flags.EstimateSamples, "estimate-samples", 0, "Number of transactions per epoch to replay gas estimation against the parent epoch")
+	cmd.Flags().IntVar(&flags.CallSamples, "call-samples", 0, "Number of contract calls per epoch to replay via cfx_call at the epoch")
+	cmd.Flags().IntVar(&flags.BalanceSamples, "balance-check-samples", 0, "Number of contract calls per epoch to check balance against transaction at the epoch")
+	cmd.Flags().IntVar(&flags.LogFuzzSamples, "log-fuzz-samples", 0, "Number of random log filters per epoch to verify cfx_getLogs against receipts")
+	cmd.Flags().IntVar(&flags.BlockCache, "block-cache", 0, "Capacity of LRU cache of fetched blocks by hash so as not to fetch the same block twice, e.g. in retry, 0 to disable")
+	cmd.Flags().BoolVar(&flags.ReceiptsByPivot, "receipts-by-pivot", false, "Whether to also query epoch receipts by pivot block hash and compare against those by epoch number")
+	cmd.Flags().BoolVar(&flags.VerifyBloom, "verify-bloom", false, "Whether to verify logs bloom of receipts against the bloom recomputed from logs")
+	cmd.Flags().IntVar(&flags.TxRootSamples, "txroot-samples", 0, "Number of blocks per epoch to verify transactions root against the transaction list, -1 for all")
+	cmd.Flags().BoolVar(&flags.VerifyGas, "verify-gas", false, "Whether to verify the sum of receipt gasUsed against gas fields of each block")
+	cmd.Flags().BoolVar(&flags.VerifyRefs, "verify-receipt-refs", false, "Whether to verify epochNumber, blockHash, transactionHash and index of receipts against the block returned under")
+	cmd.Flags().BoolVar(&flags.VerifyTraces, "verify-trace-structure", false, "Whether to verify that every call or create action in traces has a matching result with gas left within gas of action, and that traces agree with receipts, i.e. executed transactions are traced from their own call or create, while skipped ones have no traces")
+	cmd.Flags().BoolVar(&flags.TraceBreakdown, "trace-breakdown", false, "Whether to report the number of traces by type and call type per epoch and overall")
+	cmd.Flags().IntVar(&flags.ScanSamples, "scan-samples", 0, "Number of transactions per epoch to cross-verify along with the pivot block against ConfluxScan, -1 for all")
+	cmd.Flags().StringVar(&flags.ScanUrl, "scan-url", "", "ConfluxScan website to cross-verify blocks and transactions via its /v1/block and /v1/transaction routes, defaults to the one of network")
+	cmd.Flags().BoolVar(&flags.Espace, "espace", false, "Whether to verify eth_ RPCs of eSpace blocks against core space epochs")
+	cmd.Flags().BoolVar(&flags.CrossSpaceStats, "cross-space-stats", false, "Whether to report cross-space transfer counts and volumes per epoch")
+	cmd.Flags().BoolVar(&flags.GasPriceStats, "gas-price-stats", false, "Whether to report min, median and p95 of gas price, max fee and max priority fee of transactions per epoch")
+	cmd.Flags().BoolVar(&flags.BaseFeeStats, "base-fee-stats", false, "Whether to report the base fee of pivot block per epoch and its correlation with gas usage")
+	cmd.Flags().IntVar(&flags.TopContracts, "top-contracts", 0, "Number of top contracts to report by logs, calls in traces and gas used of transactions, 0 to disable")
+	cmd.Flags().IntVar(&flags.TopSenders, "top-senders", 0, "Number of top transaction senders to report by transactions and gas fee, 0 to disable")
+	cmd.Flags().StringVar(&flags.SendersCsv, "senders-csv", "", "CSV file to export transactions, gas used and gas fee of all senders")
+	cmd.Flags().BoolVar(&flags.Raw, "raw", false, "Whether to issue the same requests via a raw JSON-RPC client to measure SDK overhead")
+	cmd.PersistentFlags().StringVar(&flags.RawOption.Compression, "compression", "gzip", "Accept-Encoding of raw client, gzip or none, to report compressed and decompressed bytes of responses, while SDK client never requests compression")
+	cmd.PersistentFlags().StringVar(&flags.RawOption.HttpVersion, "http-version", "", "HTTP protocol of raw client, e.g. in --raw mode and diff subcommand, \"1.1\" to force HTTP/1.1 or \"2\" to enable HTTP/2, while SDK client always uses HTTP/1.1")
+	cmd.Flags().BoolVar(&flags.Stream, "stream", false, "Whether to decode epoch receipts and traces in streaming to only count objects, which is incompatible with features requiring receipts or traces")
+	cmd.Flags().BoolVar(&flags.FetchOption.SkipEmpty, "skip-empty", false, "Whether to skip querying traces of blocks and receipts of epochs without transactions, which are provably empty, so as to speed up scans of quiet chain periods")
+	cmd.Flags().BoolVar(&flags.CrossSpace, "cross-space", false, "Whether to verify eSpace phantom transactions against cross-space calls in traces")
+	cmd.Flags().DurationVar(&flags.FilterPollInterval, "filter-poll-interval", 0, "Interval to poll log and block filters during test, 0 to disable filter test")
+
+	cmd.Flags().DurationVar(&flags.ResourceInterval, "resource-interval", time.Second, "Interval to sample resource usage of this tool, 0 to disable")
+	cmd.Flags().DurationVar(&flags.RateInterval, "rate-interval", 5*time.Second, "Interval to display and sample requests and epochs per second, 0 to disable")
+	cmd.Flags().DurationVar(&flags.SeriesWindow, "series-window", 0, "Time window to bucket completed RPC requests into a series of QPS, errors and latency in result, 0 to disable")
+
+	cmd.Flags().StringVar(&flags.CorrelationCsv, "correlation-csv", "", "CSV file to output epoch payload size and fetch latency, and report their correlation")
+	cmd.Flags().BoolVar(&flags.SkipPreflight, "skip-preflight", false, "Whether to skip the health check of fullnode before test")
+	cmd.Flags().BoolVar(&flags.DryRun, "dry-run", false, "Whether to only print the planned requests, payload volume and duration estimated from a few sample epochs")
+	cmd.Flags().IntVar(&flags.DryRunSamples, "dry-run-samples", 5, "Number of sample epochs to estimate the plan in dry-run mode")
+	cmd.Flags().BoolVar(&flags.FailFast, "fail-fast", false, "Whether to stop test on the first failure, e.g. when used as a correctness gate")
+	cmd.Flags().StringVar(&flags.EpochsFile, "epochs-file", "", "File of epoch numbers to test line by line instead of --epoch-from and --epoch-count, e.g. failed epochs of previous test")
+	cmd.Flags().StringVar(&flags.FailedEpochsFile, "failed-epochs-file", "", "File to output the failed epochs with error details, which could be fed back via --epochs-file")
+	cmd.Flags().StringArrayVar(&flags.Asserts, "assert", nil, "Assertion evaluated per epoch, e.g. \"epoch.numBlocks >= 1 && epoch.latency < 2s\", variables: epoch.{number,numBlocks,numTxs,numReceipts,numLogs,numTraces,latency,elapsed} and latency.<method>")
+	cmd.Flags().StringSliceVar(&flags.Collectors, "collectors", nil, "Custom collectors to aggregate epoch data, available: "+strings.Join(collect.Names(), ", "))
+	cmd.Flags().StringVar(&flags.History, "history", "", "File to append the summary of this run, so as to analyze trends via the trend subcommand")
+	cmd.Flags().StringVar(&flags.Sqlite, "sqlite", "", "SQLite database file to insert per-epoch metrics, errors and verification outcomes, e.g. results.db")
+	cmd.Flags().StringVar(&flags.Postgres, "postgres", "", "PostgreSQL DSN to insert per-epoch metrics, errors and verification outcomes, e.g. postgres://user@host:5432/db with password via PGPASSWORD or PGPASSFILE")
+	cmd.MarkFlagsMutuallyExclusive("sqlite", "postgres")
+	cmd.Flags().StringVar(&flags.GraphiteAddr, "graphite-addr", "", "Carbon address to push metrics of this run in Graphite plaintext protocol, e.g. localhost:2003")
+	cmd.Flags().StringVar(&flags.GraphitePrefix, "graphite-prefix", "gotest", "Prefix of metric paths pushed to Graphite")
+	cmd.Flags().StringVar(&flags.MetricsTextfile, "metrics-textfile", "", "File to write metrics of this run in OpenMetrics text format, e.g. for node_exporter textfile collector with *.prom file")
+	cmd.Flags().BoolVar(&flags.GithubAnnotations, "github-annotations", false, "Whether to print GitHub Actions annotations to stdout for failed assertions, verifications and epochs, in which case --output is recommended to separate the result")
+	cmd.Flags().Float64Var(&flags.ErrorBudget, "error-budget", 0, "Maximum ratio of epochs failed persistently, beyond which exits with code 4")
+	cmd.Flags().DurationVar(&flags.RetryTimeout, "retry-timeout", 10*time.Second, "RPC timeout to retry failed epochs once at the end, 0 to disable retry")
+
+	cmd.AddCommand(newPosCmd())
+	cmd.AddCommand(newTxpoolCmd())
+	cmd.AddCommand(newLogsCmd())
+	cmd.AddCommand(newCallCmd())
+	cmd.AddCommand(newFollowCmd())
+	cmd.AddCommand(newFinalityCmd())
+	cmd.AddCommand(newProbeCmd())
+	cmd.AddCommand(newTrendCmd())
+	cmd.AddCommand(newMockServerCmd())
+	cmd.AddCommand(newDiffCmd())
+	cmd.AddCommand(newHealthcheckCmd())
+	cmd.AddCommand(newClockCmd())
+	cmd.AddCommand(newBestBlockCmd())
+	cmd.AddCommand(newExportCmd())
+	cmd.AddCommand(newDashboardCmd())
+	cmd.AddCommand(newLookupCmd())
+
+	if err := cmd.Execute(); errors.Is(err, errScheduleStopped) {
+		return
+	} else if err != nil {
+		fatal(ExitConfig, logrus.WithError(err), "Failed to execute command")
+	}
+
+	if exitCode != 0 {
+		logrus.Exit(exitCode)
+	}
+}
+
+func mustNewClient() *sdk.Client {
+	return mustNewClientOf(flags.Url)
+}
+
+func mustNewClientOf(url string) *sdk.Client {
+	client, err := newClientOf(url, 0)
+	if err != nil {
+		fatal(ExitConfig, logrus.WithError(err), "Failed to create client")
+	}
+
+	if flags.ChainId > 0 || flags.Manifest != "" {
+		verifyChainId(client, flags.ChainId)
+	}
+
+	return client
+}
+
+// newClientOf creates a client of fullnode with all hooks registered. If retryTimeout is positive, the client
+// is to retry failed epochs, of which the RPC timeout of all methods is overridden by retryTimeout.
+func newClientOf(url string, retryTimeout time.Duration) (*sdk.Client, error) {
+	timeouts, err := methodTimeouts()
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to parse method timeouts")
+	}
+
+	option := flags.RpcOption
+	if retryTimeout > 0 {
+		option.RequestTimeout = retryTimeout
+		timeouts = nil
+	}
+
+	client, err := sdk.NewClient(chaosUrl(url), option)
+	if err != nil {
+		return nil, err
+	}
+
+	hookResponseCache(client, url)
+	hookMethodTimeouts(client, timeouts)
+	hookTimeoutHistogram(client, timeouts, option.RequestTimeout)
+	hookRequestCounter(client)
+	hookRetryStats(client, retryTimeout > 0)
+	hookRequestId(client, url)
+	hookCurl(client, url)
+	hookDecode(client, url)
+
+	return client, nil
+}
+
+// mustNewShardClients creates additional clients of fullnode under test besides the given one, so that threads
+// could be sharded across clients by routine.
+func mustNewShardClients(client *sdk.Client) []*sdk.Client {
+	clients := []*sdk.Client{client}
+	for len(clients) < flags.NumClients {
+		clients = append(clients, mustNewClient())
+	}
+
+	return clients
+}
+
+// shardClient returns the client of shard, e.g. routine or task.
+func shardClient(clients []*sdk.Client, shard int) *sdk.Client {
+	return clients[shard%len(clients)]
+}
+
+func mustNewEspaceClient() *web3go.Client {
+	var option web3go.ClientOption
+	option.WithTimout(flags.RpcOption.RequestTimeout)
+
+	client, err := web3go.NewClientWithOption(chaosUrl(flags.EspaceUrl), option)
+	if err != nil {
+		fatal(ExitConfig, logrus.WithError(err), "Failed to create eSpace client")
+	}
+
+	return client
+}
+
+// verifyChainId records the chain ID of fullnode in manifest, and aborts in case of fullnode pointed to
+// an unexpected network, e.g. testnet. Set chainId to 0 to skip the verification.
+func verifyChainId(client *sdk.Client, chainId uint64) {
+	status, err := client.GetStatus()
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get status")
+	}
+
+	manifest.ChainId = uint64(status.ChainID)
+
+	if chainId > 0 && uint64(status.ChainID) != chainId {
+		fatal(ExitPreflight, logrus.WithFields(logrus.Fields{
+			"expected":  chainId,
+			"chainId":   uint64(status.ChainID),
+			"networkId": uint64(status.NetworkID),
+		}), "Chain ID mismatch")
+	}
+}
+
+func test(*cobra.Command, []string) {
+	if flags.Stream && (flags.TraceSamples > 0 || flags.ContractSamples > 0 || flags.SponsorInfo ||
+		flags.EstimateSamples > 0 || flags.CallSamples > 0 || flags.BalanceSamples > 0 || flags.LogFuzzSamples > 0 || flags.ReceiptsByPivot || flags.VerifyBloom || flags.VerifyGas || flags.VerifyRefs || flags.VerifyTraces || flags.TraceBreakdown ||
+		flags.CrossSpace || flags.Espace || flags.CrossSpaceStats || flags.TopContracts > 0 || flags.TopSenders > 0 || flags.SendersCsv != "" || flags.Raw || flags.FilterPollInterval > 0 || len(flags.Collectors) > 0) {
+		fatal(ExitConfig, logrus.NewEntry(logrus.StandardLogger()), "Streaming mode is incompatible with features requiring receipts or traces")
+	}
+
+	// epochs to test from file
+	var epochs []uint64
+	if flags.EpochsFile != "" {
+		if flags.CheckNonce || flags.FilterPollInterval > 0 {
+			fatal(ExitConfig, logrus.NewEntry(logrus.StandardLogger()), "Epochs file is incompatible with features requiring continuous epochs")
+		}
+
+		var err error
+		if epochs, err = readEpochsFile(flags.EpochsFile); err != nil {
+			fatal(ExitConfig, logrus.WithError(err).WithField("file", flags.EpochsFile), "Failed to read epochs file")
+		}
+
+		if len(epochs) == 0 {
+			fatal(ExitConfig, logrus.WithField("file", flags.EpochsFile), "No epoch to test in epochs file")
+		}
+
+		flags.EpochFrom, flags.NumEpochs = epochs[0], uint64(len(epochs))
+	}
+
+	// create client
+	client := mustNewClient()
+	defer client.Close()
+
+	// verify latest finalized epoch
+	latestFinalizedEpoch, err := client.GetEpochNumber(types.EpochLatestFinalized)
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get latest epoch number")
+	}
+	epochTo := flags.EpochFrom + flags.NumEpochs
+	if len(epochs) > 0 {
+		epochTo = epochs[len(epochs)-1] + 1
+	}
+	if epochTo > latestFinalizedEpoch.ToInt().Uint64() {
+		fatal(ExitPreflight, logrus.WithField("finalized", latestFinalizedEpoch.ToInt()), "Not enough finalized epochs to test")
+	}
+
+	// check whether fullnode could serve the requested data
+	if !flags.SkipPreflight {
+		mustPreflight(client, flags.EpochFrom)
+	}
+
+	if flags.DryRun {
+		plan, err := PlanDryRun(client, flags.EpochFrom, flags.NumEpochs, flags.DryRunSamples, flags.ParallelOption.Routines)
+		if err != nil {
+			fatal(ExitFailure, logrus.WithError(err), "Failed to plan dry run")
+		}
+
+		printResult(plan)
+
+		return
+	}
+
+	// retrieve data from RPC server
+	start := time.Now()
+	stat := RpcStat{
+		Stat:           scan.NewStat(),
+		lastReportTime: start,
+		Timeouts:       &timeoutHistogram,
+		UnknownFields:  unknownFields,
+	}
+	options := scan.Options{
+		Client:    client,
+		EpochFrom: flags.EpochFrom,
+		NumEpochs: flags.NumEpochs,
+		Epochs:    epochs,
+		Stream:    flags.Stream,
+		Jitter:    flags.Jitter,
+		Fetch:     flags.FetchOption,
+		Parallel:  flags.ParallelOption,
+		FailFast:  flags.FailFast,
+		Retry:     flags.RetryTimeout > 0,
+	}
+	if flags.SponsorInfo {
+		stat.Sponsor = NewSponsorStat()
+	}
+	if flags.CheckNonce {
+		stat.Nonce = NewNonceChecker()
+	}
+	if flags.EstimateSamples > 0 {
+		stat.Estimate = &EstimateStat{}
+	}
+	if flags.CallSamples > 0 {
+		stat.Call = &CallStat{}
+	}
+	if flags.BalanceSamples > 0 {
+		stat.BalanceCheck = &CallStat{}
+	}
+	if flags.LogFuzzSamples > 0 {
+		stat.LogFuzz = &LogFuzzResult{}
+	}
+	if flags.ReceiptsByPivot {
+		stat.ReceiptsByPivot = &ReceiptsVariantResult{}
+	}
+	if flags.VerifyBloom {
+		stat.LogsBloom = &BloomResult{}
+	}
+	if flags.TxRootSamples != 0 {
+		stat.TxRoot = &TxRootResult{}
+	}
+	if flags.VerifyGas {
+		stat.GasAccounting = &GasAccountingResult{}
+	}
+	if flags.VerifyRefs {
+		stat.ReceiptRefs = &ReceiptRefResult{}
+	}
+	if flags.VerifyTraces {
+		stat.TraceStructure = &TraceStructureResult{}
+	}
+	if flags.TraceBreakdown {
+		stat.TraceBreakdown = NewTraceBreakdownStat()
+	}
+	if flags.BlockCache > 0 {
+		stat.BlockCache = fetch.NewBlockCache(flags.BlockCache)
+		options.Fetch.Blocks = stat.BlockCache
+	}
+	if flags.ScanSamples != 0 {
+		stat.scan = NewScanClient(flags.ScanUrl, flags.RpcOption.RequestTimeout)
+		stat.Scan = &ScanResult{}
+	}
+	if flags.CrossSpace || flags.Espace {
+		stat.espace = mustNewEspaceClient()
+		defer stat.espace.Close()
+	}
+	if flags.CrossSpace {
+		stat.CrossSpace = &CrossSpaceResult{}
+	}
+	if flags.Espace {
+		stat.Espace = &EspaceParityResult{}
+	}
+	if flags.CrossSpaceStats {
+		stat.CrossSpaceTraffic = NewCrossSpaceTrafficStat()
+	}
+	if flags.GasPriceStats {
+		stat.GasPrice = &GasPriceStat{}
+	}
+	if flags.BaseFeeStats {
+		stat.BaseFee = &BaseFeeStat{}
+	}
+	if flags.TopContracts > 0 {
+		stat.TopContracts = NewTopContractStat(flags.TopContracts)
+	}
+	if flags.TopSenders > 0 || flags.SendersCsv != "" {
+		stat.TopSenders = NewTopSenderStat(flags.TopSenders)
+	}
+	if flags.Raw || flags.Stream {
+		// streaming decodes receipts and traces via raw client too
+		stat.raw = fetch.NewRawClient(chaosUrl(flags.Url), flags.RpcOption.RequestTimeout, flags.RawOption)
+		options.Raw = stat.raw
+	}
+	if flags.Raw {
+		stat.Raw = stats.NewRawStat()
+	}
+	if flags.FilterPollInterval > 0 {
+		if stat.Filter, err = NewFilterTester(client, flags.EpochFrom, epochTo-1); err != nil {
+			fatal(ExitPreflight, logrus.WithError(err), "Failed to install filters")
+		}
+		stat.Filter.Start(flags.FilterPollInterval)
+	}
+	if flags.CorrelationCsv != "" {
+		stat.Correlation = &SizeCorrelation{}
+	}
+	if len(flags.Asserts) > 0 {
+		if stat.Assert, err = NewAssertStat(flags.Asserts); err != nil {
+			fatal(ExitConfig, logrus.WithError(err), "Failed to parse assertions")
+		}
+	}
+	if len(flags.Collectors) > 0 {
+		if stat.collectors, err = collect.New(flags.Collectors); err != nil {
+			fatal(ExitConfig, logrus.WithError(err), "Failed to create collectors")
+		}
+	}
+	if len(flags.Endpoints) > 0 {
+		clients := []*sdk.Client{client}
+		for _, url := range flags.Endpoints {
+			clients = append(clients, mustNewClientOf(url))
+			defer clients[len(clients)-1].Close()
+		}
+		stat.Endpoints = NewEndpointPool(clients, append([]string{flags.Url}, flags.Endpoints...), flags.BreakerFailures, flags.BreakerCooldown)
+		options.Pool = stat.Endpoints
+	}
+	if flags.NumClients > 1 {
+		options.Shards = mustNewShardClients(client)
+		for _, v := range options.Shards[1:] {
+			defer v.Close()
+		}
+	}
+	if flags.ResourceInterval > 0 {
+		stat.Resource = &ResourceSampler{}
+		stat.Resource.Start(flags.ResourceInterval)
+	}
+	if flags.RateInterval > 0 || flags.SeriesWindow > 0 {
+		stat.Rate = &RateMeter{}
+		stat.Rate.Start(flags.RateInterval, flags.SeriesWindow)
+	}
+	if flags.RetryTimeout > 0 {
+		stat.Retries = &retryStats
+	}
+	if flags.Sqlite != "" {
+		sink, err := report.NewSqliteSink(flags.Sqlite, resultTables)
+		if err != nil {
+			fatal(ExitConfig, logrus.WithError(err), "Failed to open SQLite database")
+		}
+		stat.sink = NewResultSink(sink)
+	}
+	if flags.Postgres != "" {
+		sink, err := report.NewPostgresSink(flags.Postgres, resultTables)
+		if err != nil {
+			fatal(ExitConfig, logrus.WithError(err), "Failed to connect PostgreSQL database")
+		}
+		stat.sink = NewResultSink(sink)
+	}
+
+	// failures once the test run started are reported at the end, so that results collected so far are
+	// still output, e.g. aborted by --fail-fast
+	stat.scanner = scan.New(stat.Stat, options, &stat)
+
+	var failure runFailure
+	if err = stat.scanner.Run(context.Background()); err != nil {
+		logFailFast(err)
+		failure.set(exitCodeOfError(err), logrus.WithError(err), "Failed to parallel execute RPC statistics")
+	} else if flags.RetryTimeout > 0 {
+		if err = stat.RetryFailed(context.Background(), flags.RetryTimeout); err != nil {
+			failure.set(exitCodeOfError(err), logrus.WithError(err), "Failed to retry failed epochs")
+		}
+	}
+	if stat.sink != nil {
+		if err = stat.sink.Close(); err != nil {
+			failure.set(ExitFailure, logrus.WithError(err), "Failed to insert results into database")
+		}
+	}
+	if flags.FailedEpochsFile != "" {
+		if err = writeFailedEpochs(flags.FailedEpochsFile, stat.FailedEpochs); err != nil {
+			failure.set(ExitFailure, logrus.WithError(err), "Failed to write failed epochs file")
+		}
+	}
+	if len(stat.collectors) > 0 {
+		stat.Collectors = make(map[string]any)
+		for i, collector := range stat.collectors {
+			stat.Collectors[flags.Collectors[i]] = collector.Finalize()
+		}
+	}
+	if flags.History != "" {
+		if err = report.AppendHistory(flags.History, newHistoryRecord(&stat)); err != nil {
+			failure.set(ExitFailure, logrus.WithError(err), "Failed to append history")
+		}
+	}
+	if stat.Resource != nil {
+		stat.Resource.Stop()
+	}
+	if stat.Rate != nil {
+		stat.Rate.Stop()
+	}
+	if stat.Raw != nil {
+		stat.Raw.Protocols = stat.raw.Protocols()
+		stat.Raw.CompressedBytes, stat.Raw.DecompressedBytes = stat.raw.Bytes()
+	}
+	if stat.Correlation != nil {
+		if err = stat.Correlation.WriteCSV(flags.CorrelationCsv); err != nil {
+			failure.set(ExitFailure, logrus.WithError(err), "Failed to write correlation CSV")
+		}
+	}
+	if flags.SendersCsv != "" {
+		if err = stat.TopSenders.WriteCSV(flags.SendersCsv); err != nil {
+			failure.set(ExitFailure, logrus.WithError(err), "Failed to write senders CSV")
+		}
+	}
+	if stat.Filter != nil {
+		if err = stat.Filter.Stop(); err != nil {
+			failure.set(ExitFailure, logrus.WithError(err), "Failed to verify filters")
+		}
+	}
+
+	printResult(&stat)
+
+	if flags.GithubAnnotations {
+		printAnnotations(&stat)
+	}
+
+	elapsed := time.Since(start)
+	printInfo("Total elapsed: %v", elapsed)
+
+	printInfo("Avg epoch latency: %v", time.Since(start)/time.Duration(flags.NumEpochs))
+
+	if stat.Assert != nil {
+		if stat.Assert.NumFailed > 0 {
+			printInfo("Assertions FAILED: %v of %v checks", stat.Assert.NumFailed, stat.Assert.NumChecks)
+		} else {
+			printInfo("Assertions PASSED: %v checks", stat.Assert.NumChecks)
+		}
+	}
+
+	if stat.Raw != nil {
+		overheads := stat.Raw.Overhead(stat.Latencies)
+
+		var methods []string
+		for method := range overheads {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			printInfo("SDK overhead of %v: %v", method, overheads[method])
+		}
+
+		printInfo("Raw response bytes: %v on the wire, %v decompressed", stat.Raw.CompressedBytes, stat.Raw.DecompressedBytes)
+	}
+
+	metrics := metricsOf(&stat, elapsed)
+
+	if flags.GraphiteAddr != "" {
+		if err = report.PushGraphite(flags.GraphiteAddr, flags.GraphitePrefix, metrics, time.Now()); err != nil {
+			logrus.WithError(err).WithField("addr", flags.GraphiteAddr).Warn("Failed to push metrics to Graphite")
+		}
+	}
+
+	if flags.MetricsTextfile != "" {
+		if err = report.WriteTextfile(flags.MetricsTextfile, metrics); err != nil {
+			logrus.WithError(err).WithField("file", flags.MetricsTextfile).Warn("Failed to write metrics textfile")
+		}
+	}
+
+	if failure.code != 0 {
+		exitCode = failure.code
+		return
+	}
+
+	var reason string
+	if exitCode, reason = exitCodeOf(&stat); exitCode != 0 {
+		logrus.WithField("exitCode", exitCode).Error(reason)
+	}
+}
+
+// EpochSummary is the per epoch result computed by worker, which is small enough to pass to the collector
+// rather than full blocks, receipts and traces.
+// EpochSummary is the per epoch result computed by worker, which is small enough to pass to the collector
+// rather than full blocks, receipts and traces.
+type EpochSummary struct {
+	scan.Summary
+
+	TraceChecks     int
+	TraceMismatches int
+
+	Supply   *SupplySample
+	State    StateResult
+	Sponsors map[string]types.SponsorInfo
+	Nonces   []NonceTx
+
+	Estimates        []EstimateResult
+	EstimateFailures int
+
+	Calls          int
+	CallsSucceeded int
+
+	BalanceChecks          int
+	BalanceChecksSucceeded int
+
+	LogFuzz           LogFuzzResult
+	Scan              ScanResult
+	ReceiptsByPivot   ReceiptsVariantResult
+	LogsBloom         BloomResult
+	TxRoot            TxRootResult
+	GasAccounting     GasAccountingResult
+	ReceiptRefs       ReceiptRefResult
+	TraceStructure    TraceStructureResult
+	TraceBreakdown    TraceBreakdown
+	CrossSpace        CrossSpaceResult
+	Espace            EspaceParityResult
+	CrossSpaceTraffic CrossSpaceTraffic
+	GasPrice          GasPriceSample
+	BaseFee           *BaseFeeSample
+	Contracts         map[string]ContractActivity
+	Senders           map[string]SenderActivity
+
+	RawLatency stats.MethodLatency
+	RawDecode  stats.MethodLatency
+
+	Size *EpochSize
+
+	Collected []any // metrics staged by collectors in order, to commit on success
+}
+
+// RpcStat runs the enabled tests against epochs scanned, on top of the statistics of scan.
+type RpcStat struct {
+	*scan.Stat
+
+	scanner *scan.Scanner[EpochSummary]
+	espace  *web3go.Client
+	scan    *ScanClient
+	raw     *fetch.RawClient
+
+	lastReportTime time.Time
+
+	NumTraceChecks     int
+	NumTraceMismatches int
+
+	NumStateReads       int
+	NumStatePruned      int
+	MaxStatePrunedEpoch uint64
+	NumStateDecodeErrs  int
+
+	FailedEpochs []FailedEpoch `json:",omitempty"`
+	Timeouts     *stats.TimeoutHistogram
+	Retries      *stats.RetryStats `json:",omitempty"` // attempts vs successes by method
+
+	Supplies []SupplySample `json:",omitempty"`
+	Sponsor  *SponsorStat   `json:",omitempty"`
+	Nonce    *NonceChecker  `json:",omitempty"`
+	Estimate *EstimateStat  `json:",omitempty"`
+	Call     *CallStat      `json:",omitempty"`
+
+	BalanceCheck *CallStat           `json:",omitempty"`
+	Filter       *FilterTester       `json:",omitempty"`
+	LogFuzz      *LogFuzzResult      `json:",omitempty"`
+	Scan         *ScanResult         `json:",omitempty"`
+	CrossSpace   *CrossSpaceResult   `json:",omitempty"`
+	Espace       *EspaceParityResult `json:",omitempty"`
+
+	CrossSpaceTraffic *CrossSpaceTrafficStat `json:",omitempty"`
+	GasPrice          *GasPriceStat          `json:",omitempty"`
+	BaseFee           *BaseFeeStat           `json:",omitempty"`
+	TopContracts      *TopContractStat       `json:",omitempty"`
+	TopSenders        *TopSenderStat         `json:",omitempty"`
+	ReceiptsByPivot   *ReceiptsVariantResult `json:",omitempty"`
+	LogsBloom         *BloomResult           `json:",omitempty"`
+	TxRoot            *TxRootResult          `json:",omitempty"`
+	GasAccounting     *GasAccountingResult   `json:",omitempty"`
+	ReceiptRefs       *ReceiptRefResult      `json:",omitempty"`
+	TraceStructure    *TraceStructureResult  `json:",omitempty"`
+	TraceBreakdown    *TraceBreakdownStat    `json:",omitempty"`
+	BlockCache        *fetch.BlockCache      `json:",omitempty"`
+	Raw               *stats.RawStat         `json:",omitempty"`
+	Resource          *ResourceSampler       `json:",omitempty"`
+	Rate              *RateMeter             `json:",omitempty"`
+	Correlation       *SizeCorrelation       `json:",omitempty"`
+	Endpoints         *EndpointPool          `json:",omitempty"`
+	Collectors        map[string]any         `json:",omitempty"`
+	Assert            *AssertStat            `json:",omitempty"`
+	UnknownFields     *UnknownFields         `json:",omitempty"`
+
+	collectors []collect.Collector
+	sink       *ResultSink // nil if not inserting results into database
+}
+
+// Check runs enabled tests against the epoch fetched by worker.
+func (stat *RpcStat) Check(epoch *scan.Epoch) (EpochSummary, error) {
+	client, epochNumber, data := epoch.Client, epoch.Number, epoch.Data
+	summary := EpochSummary{Summary: epoch.Summary}
+
+	var err error
+
+	if flags.CorrelationCsv != "" {
+		summary.Size = &EpochSize{
+			Epoch:   epochNumber,
+			Txs:     summary.NumTxs,
+			Logs:    summary.NumLogs,
+			Bytes:   epochBytes(data),
+			Latency: summary.Fetch,
+		}
+	}
+
+	if flags.Raw {
+		summary.RawLatency, summary.RawDecode = stats.NewMethodLatency(flags.Jitter), stats.NewMethodLatency(0)
+		if err = fetch.QueryEpochDataRaw(epoch.Raw, epochNumber, summary.RawLatency, summary.RawDecode); err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to query epoch data via raw client")
+		}
+	}
+
+	if flags.TraceSamples > 0 {
+		summary.TraceChecks, summary.TraceMismatches, err = VerifyTransactionTraces(client, epochNumber, data.Traces, flags.TraceSamples)
+		if err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to verify transaction traces")
+		}
+	}
+
+	if flags.SupplyInterval > 0 && uint64(epoch.Task)%flags.SupplyInterval == 0 {
+		supply, err := QuerySupplyInfo(client, epochNumber, data.Latency)
+		if err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to sample supply info")
+		}
+		summary.Supply = &supply
+	}
+
+	if flags.AccountSamples > 0 {
+		state, err := QueryAccountStates(client, epochNumber, data.Blocks, flags.AccountSamples, data.Latency)
+		if err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to query account states")
+		}
+		summary.State.Add(state)
+	}
+
+	if flags.ContractSamples > 0 {
+		state, err := QueryContractStates(client, epochNumber, data.Receipts, flags.ContractSamples, data.Latency)
+		if err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to query contract states")
+		}
+		summary.State.Add(state)
+	}
+
+	if flags.StakingSamples > 0 {
+		state, err := QueryStakingStates(client, epochNumber, data.Blocks, flags.StakingSamples, data.Latency)
+		if err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to query staking states")
+		}
+		summary.State.Add(state)
+	}
+
+	if flags.EstimateSamples > 0 {
+		summary.Estimates, summary.EstimateFailures, err = ReplayEstimates(client, epochNumber, data.Blocks, data.Receipts, flags.EstimateSamples, data.Latency)
+		if err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to replay gas estimation")
+		}
+	}
+
+	if flags.CallSamples > 0 {
+		summary.Calls, summary.CallsSucceeded, err = ReplayCalls(client, epochNumber, data.Blocks, data.Receipts, flags.CallSamples, data.Latency)
+		if err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to replay calls")
+		}
+	}
+
+	if flags.BalanceSamples > 0 {
+		summary.BalanceChecks, summary.BalanceChecksSucceeded, err = CheckBalances(client, epochNumber, data.Blocks, data.Receipts, flags.BalanceSamples, data.Latency)
+		if err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to check balance against transactions")
+		}
+	}
+
+	if flags.LogFuzzSamples > 0 {
+		if summary.LogFuzz, err = FuzzLogFilters(client, epochNumber, data.Receipts, flags.LogFuzzSamples, data.Latency); err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to fuzz log filters")
+		}
+	}
+
+	if flags.ReceiptsByPivot {
+		if summary.ReceiptsByPivot, err = VerifyReceiptsByPivot(client, epochNumber, data); err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to verify epoch receipts by pivot block hash")
+		}
+	}
+
+	if flags.VerifyBloom {
+		summary.LogsBloom = VerifyLogsBloom(epochNumber, data.Receipts)
+	}
+
+	if flags.TxRootSamples != 0 {
+		summary.TxRoot = VerifyTransactionsRoot(epochNumber, data.Blocks, flags.TxRootSamples)
+	}
+
+	if flags.VerifyGas {
+		summary.GasAccounting = VerifyGasAccounting(epochNumber, data.Blocks, data.Receipts)
+	}
+
+	if flags.VerifyRefs {
+		summary.ReceiptRefs = VerifyReceiptRefs(epochNumber, data.Blocks, data.Receipts)
+	}
+
+	if flags.VerifyTraces {
+		summary.TraceStructure = VerifyTraceStructure(epochNumber, data.Traces, data.Receipts)
+	}
+
+	if flags.TraceBreakdown {
+		summary.TraceBreakdown = traceBreakdownOf(epochNumber, data.Traces)
+	}
+
+	if stat.scan != nil {
+		summary.Scan = VerifyScan(stat.scan, epochNumber, data.Blocks, flags.ScanSamples)
+	}
+
+	if flags.CrossSpace {
+		if summary.CrossSpace, err = VerifyCrossSpace(stat.espace, epochNumber, data.Traces, data.Latency); err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to verify cross-space calls")
+		}
+	}
+
+	if flags.Espace {
+		if summary.Espace, err = VerifyEspaceParity(stat.espace, epochNumber, data.Blocks, data.Receipts, data.Latency); err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to verify eSpace parity")
+		}
+	}
+
+	if flags.SponsorInfo {
+		if summary.Sponsors, err = QuerySponsorInfos(client, epochNumber, data.Receipts, data.Latency); err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to query sponsor infos")
+		}
+	}
+
+	if flags.CheckNonce {
+		summary.Nonces = executedNonces(data.Blocks)
+	}
+
+	if flags.CrossSpaceStats {
+		summary.CrossSpaceTraffic = crossSpaceTraffic(epochNumber, data.Traces, data.Receipts)
+	}
+
+	if flags.GasPriceStats {
+		summary.GasPrice = gasPriceSampleOf(epochNumber, data.Blocks)
+	}
+
+	if flags.BaseFeeStats {
+		summary.BaseFee = baseFeeSampleOf(epochNumber, data.Blocks)
+	}
+
+	if flags.TopContracts > 0 {
+		summary.Contracts = contractActivitiesOf(data.Traces, data.Receipts)
+	}
+
+	if flags.TopSenders > 0 || flags.SendersCsv != "" {
+		summary.Senders = senderActivitiesOf(data.Receipts)
+	}
+
+	// stage the collected metrics, which are committed only if epoch succeeded so as to never collect twice
+	for _, collector := range stat.collectors {
+		metrics, err := collector.OnEpoch(data, collect.Timing{Epoch: epochNumber, Fetch: summary.Fetch})
+		if err != nil {
+			return EpochSummary{}, errors.WithMessage(err, "Failed to collect epoch data")
+		}
+
+		summary.Collected = append(summary.Collected, metrics)
+	}
+
+	return summary, nil
+}
+
+// Collect aggregates the results of epoch in order, except for epochs retried at the end.
+func (stat *RpcStat) Collect(result *scan.Result[EpochSummary]) error {
+	// report progress
+	if flags.ReportInterval > 0 && time.Since(stat.lastReportTime) > flags.ReportInterval {
+		logrus.WithField("completed", result.Task+1).WithField("total", flags.NumEpochs).Debug("Progress update")
+		stat.lastReportTime = time.Now()
+	}
+
+	if stat.Rate != nil && !result.Retried {
+		stat.Rate.AddEpoch()
+	}
+
+	// epochs failed or retried out of order
+	if stat.Nonce != nil && (result.Err != nil || result.Retried) {
+		stat.Nonce.Reset()
+	}
+
+	if result.Err != nil {
+		if result.Retry {
+			logrus.WithError(result.Err).WithField("epoch", result.Epoch).Debug("Failed to query epoch data, retry later")
+			return nil
+		}
+
+		logrus.WithError(result.Err).WithField("epoch", result.Epoch).Warn("Failed to query epoch data")
+		stat.FailedEpochs = append(stat.FailedEpochs, FailedEpoch{
+			Epoch:  result.Epoch,
+			Method: stats.RpcMethod(result.Err),
+			Error:  result.Err.Error(),
+			Curl:   curlOf(result.Err),
+		})
+
+		if stat.sink != nil {
+			return stat.sink.AddError(stat.FailedEpochs[len(stat.FailedEpochs)-1])
+		}
+
+		return nil
+	}
+
+	epoch := result.Epoch
+	summary := &result.Value
+	summary.Summary = result.Summary
+
+	if stat.Assert != nil {
+		stat.Assert.Check(epoch, summary)
+	}
+
+	if stat.sink != nil {
+		if err := stat.sink.AddEpoch(epoch, summary); err != nil {
+			return errors.WithMessage(err, "Failed to insert epoch results into database")
+		}
+	}
+
+	stat.NumTraceChecks += summary.TraceChecks
+	stat.NumTraceMismatches += summary.TraceMismatches
+
+	for i, collector := range stat.collectors {
+		collector.Commit(summary.Collected[i])
+	}
+
+	stat.NumStateReads += summary.State.Reads
+	stat.NumStatePruned += summary.State.Pruned
+	stat.NumStateDecodeErrs += summary.State.DecodeErrors
+	if summary.State.Pruned > 0 {
+		stat.MaxStatePrunedEpoch = epoch
+	}
+
+	if stat.Raw != nil {
+		stat.Raw.Latencies.Add(summary.RawLatency)
+		stat.Raw.Decode.Add(summary.RawDecode)
+	}
+	if summary.Supply != nil {
+		stat.Supplies = append(stat.Supplies, *summary.Supply)
+	}
+	if stat.Sponsor != nil {
+		stat.Sponsor.Add(summary.Sponsors, summary.NumReceipts, summary.NumTxsGasCovered)
+	}
+	if stat.Nonce != nil {
+		stat.Nonce.Check(epoch, summary.Nonces)
+	}
+	if stat.Estimate != nil {
+		stat.Estimate.Add(summary.Estimates, summary.EstimateFailures)
+	}
+	if stat.Call != nil {
+		stat.Call.Add(summary.Calls, summary.CallsSucceeded)
+	}
+	if stat.BalanceCheck != nil {
+		stat.BalanceCheck.Add(summary.BalanceChecks, summary.BalanceChecksSucceeded)
+	}
+	if stat.LogFuzz != nil {
+		stat.LogFuzz.Add(summary.LogFuzz)
+	}
+	if stat.Scan != nil {
+		stat.Scan.Add(summary.Scan)
+	}
+	if stat.ReceiptsByPivot != nil {
+		stat.ReceiptsByPivot.Add(summary.ReceiptsByPivot)
+	}
+	if stat.LogsBloom != nil {
+		stat.LogsBloom.Add(summary.LogsBloom)
+	}
+	if stat.TxRoot != nil {
+		stat.TxRoot.Add(summary.TxRoot)
+	}
+	if stat.GasAccounting != nil {
+		stat.GasAccounting.Add(summary.GasAccounting)
+	}
+	if stat.ReceiptRefs != nil {
+		stat.ReceiptRefs.Add(summary.ReceiptRefs)
+	}
+	if stat.TraceStructure != nil {
+		stat.TraceStructure.Add(summary.TraceStructure)
+	}
+	if stat.TraceBreakdown != nil {
+		stat.TraceBreakdown.Add(summary.TraceBreakdown)
+	}
+	if stat.CrossSpace != nil {
+		stat.CrossSpace.Add(summary.CrossSpace)
+	}
+	if stat.Espace != nil {
+		stat.Espace.Add(summary.Espace)
+	}
+	if stat.CrossSpaceTraffic != nil {
+		stat.CrossSpaceTraffic.Add(summary.CrossSpaceTraffic)
+	}
+	if stat.GasPrice != nil {
+		stat.GasPrice.Add(summary.GasPrice)
+	}
+	if stat.BaseFee != nil {
+		stat.BaseFee.Add(summary.BaseFee)
+	}
+	if stat.TopContracts != nil {
+		stat.TopContracts.Add(summary.Contracts)
+	}
+	if stat.TopSenders != nil {
+		stat.TopSenders.Add(summary.Senders)
+	}
+	if stat.Correlation != nil && summary.Size != nil {
+		stat.Correlation.Add(*summary.Size)
+	}
+	if stat.Filter != nil {
+		stat.Filter.AddEpoch(epoch, summary.NumLogs)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/boqiu/go-test/pkg/report"
+)
+
+// metricsOf returns the metric samples of the test run as described in catalog.
+func metricsOf(stat *RpcStat, elapsed time.Duration) []report.Metric {
+	endpoint := report.Label{Name: "endpoint", Value: flags.Url}
+
+	var metrics []report.Metric
+	add := func(name string, value float64, labels ...report.Label) {
+		metrics = append(metrics, report.Metric{
+			Name:   name,
+			Labels: append([]report.Label{endpoint}, labels...),
+			Value:  value,
+		})
+	}
+
+	add(report.MetricEpochs, float64(flags.NumEpochs))
+	add(report.MetricBlocks, float64(stat.NumBlocks))
+	add(report.MetricTransactions, float64(stat.NumTxs))
+	add(report.MetricLogs, float64(stat.NumLogs))
+	add(report.MetricTraces, float64(stat.NumTraces))
+	add(report.MetricErrors, float64(stat.NumErrors))
+	add(report.MetricRetried, float64(stat.NumRetried))
+	add(report.MetricRecovered, float64(stat.NumRecovered))
+
+	var methods []string
+	for method := range stat.Latencies {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		summary := stat.Latencies[method].Summary()
+		label := report.Label{Name: "method", Value: method}
+
+		add(report.MetricRpcRequests, float64(summary.Count), label)
+
+		quantiles := []struct {
+			quantile string
+			latency  time.Duration
+		}{{"0.5", summary.P50}, {"0.9", summary.P90}, {"0.99", summary.P99}}
+
+		for _, v := range quantiles {
+			add(report.MetricRpcLatency, v.latency.Seconds(), label, report.Label{Name: "quantile", Value: v.quantile})
+		}
+	}
+
+	for _, v := range verificationsOf(stat) {
+		label := report.Label{Name: "name", Value: v.name}
+		add(report.MetricVerificationChecks, float64(v.checks), label)
+		add(report.MetricVerificationFailures, float64(v.failures), label)
+	}
+
+	add(report.MetricDuration, elapsed.Seconds())
+	add(report.MetricLastRun, float64(time.Now().Unix()))
+
+	return metrics
+}
+
+// verification is the number of checks and failures of an enabled verification.
+type verification struct {
+	name             string
+	checks, failures int
+}
+
+// verificationsOf returns the outcomes of enabled verifications.
+func verificationsOf(stat *RpcStat) []verification {
+	var result []verification
+
+	if flags.TraceSamples > 0 {
+		result = append(result, verification{"trace", stat.NumTraceChecks, stat.NumTraceMismatches})
+	}
+	if stat.Estimate != nil {
+		result = append(result, verification{"estimate", stat.Estimate.NumEstimates + stat.Estimate.NumFailures, stat.Estimate.NumFailures})
+	}
+	if stat.Call != nil {
+		result = append(result, verification{"call", stat.Call.NumCalls, stat.Call.NumCalls - stat.Call.NumSucceeded})
+	}
+	if stat.BalanceCheck != nil {
+		result = append(result, verification{"balance", stat.BalanceCheck.NumCalls, stat.BalanceCheck.NumCalls - stat.BalanceCheck.NumSucceeded})
+	}
+	if stat.LogFuzz != nil {
+		result = append(result, verification{"log_fuzz", stat.LogFuzz.Queries, stat.LogFuzz.Violations + stat.LogFuzz.Missing})
+	}
+	if stat.Scan != nil {
+		result = append(result, verification{"scan", stat.Scan.Checks, stat.Scan.Discrepancies})
+	}
+	if stat.ReceiptsByPivot != nil {
+		result = append(result, verification{"receipts_by_pivot", stat.ReceiptsByPivot.Checks, stat.ReceiptsByPivot.Mismatches})
+	}
+	if stat.LogsBloom != nil {
+		result = append(result, verification{"logs_bloom", stat.LogsBloom.Checks, stat.LogsBloom.Mismatches})
+	}
+	if stat.TxRoot != nil {
+		result = append(result, verification{"transactions_root", stat.TxRoot.Checks, stat.TxRoot.Mismatches})
+	}
+	if stat.GasAccounting != nil {
+		result = append(result, verification{"gas_accounting", stat.GasAccounting.Checks, stat.GasAccounting.Mismatches})
+	}
+	if stat.ReceiptRefs != nil {
+		result = append(result, verification{"receipt_refs", stat.ReceiptRefs.Checks, stat.ReceiptRefs.Mismatches})
+	}
+	if stat.TraceStructure != nil {
+		result = append(result, verification{"trace_structure", stat.TraceStructure.Checks, stat.TraceStructure.Violations})
+	}
+	if stat.Nonce != nil {
+		result = append(result, verification{"nonce", stat.Nonce.NumTxs, stat.Nonce.NumViolations})
+	}
+	if stat.Filter != nil {
+		result = append(result, verification{"filter", len(stat.Filter.epochLogs) + len(stat.Filter.blockHashes),
+			stat.Filter.NumMismatchedEpochs + stat.Filter.NumMissingBlocks})
+	}
+	if stat.CrossSpace != nil {
+		result = append(result, verification{"cross_space", stat.CrossSpace.Calls + stat.CrossSpace.Phantoms,
+			stat.CrossSpace.Missing + stat.CrossSpace.Unmatched + stat.CrossSpace.StatusMismatches})
+	}
+	if stat.Espace != nil {
+		result = append(result, verification{"espace", stat.Espace.Blocks, stat.Espace.BlockMismatches +
+			stat.Espace.ReceiptMismatches + stat.Espace.LogMismatches + stat.Espace.MissingCoreLogs})
+	}
+
+	return result
+}
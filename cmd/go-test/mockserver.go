@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/boqiu/go-test/pkg/mock"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var mockServerFlags struct {
+	Dir    string
+	Addr   string
+	Option mock.Option
+}
+
+func newMockServerCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "mockserver",
+		Short: "Serve canned JSON-RPC responses from capture directory to test this tool without a real fullnode",
+		Long: `Serve canned JSON-RPC responses from capture directory to test this tool without a real fullnode,
+in which each file <method>.json contains an array of responses of the RPC method, e.g.
+
+[
+    {"params": ["0x10", false], "result": {...}},
+    {"params": ["0x11", false], "error": {"code": -32016, "message": "..."}},
+    {"result": null}
+]
+
+Responses are matched by params in order, and the one without params matches any params.
+Then, test against the mock server via --network custom --url http://127.0.0.1:12537.`,
+		Run: mockServe,
+	}
+
+	cmd.Flags().StringVar(&mockServerFlags.Dir, "dir", "", "Capture directory of canned responses")
+	cmd.Flags().StringVar(&mockServerFlags.Addr, "addr", "127.0.0.1:12537", "Address to listen on")
+	cmd.Flags().DurationVar(&mockServerFlags.Option.Latency, "latency", 0, "Fixed latency of each request")
+	cmd.Flags().DurationVar(&mockServerFlags.Option.Jitter, "latency-jitter", 0, "Maximum random latency in addition to --latency")
+	cmd.Flags().Float64Var(&mockServerFlags.Option.ErrorRate, "error-rate", 0, "Probability in [0, 1] to respond an injected error")
+	cmd.MarkFlagRequired("dir")
+
+	return &cmd
+}
+
+func mockServe(*cobra.Command, []string) {
+	handler, err := mock.NewServer(mockServerFlags.Dir, mockServerFlags.Option)
+	if err != nil {
+		fatal(ExitConfig, logrus.WithError(err).WithField("dir", mockServerFlags.Dir), "Failed to load canned responses")
+	}
+
+	server := http.Server{Addr: mockServerFlags.Addr, Handler: handler}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	logrus.WithFields(logrus.Fields{
+		"addr":    mockServerFlags.Addr,
+		"methods": handler.Methods(),
+	}).Info("Mock server started")
+
+	if err = server.ListenAndServe(); err != http.ErrServerClosed {
+		fatal(ExitFailure, logrus.WithError(err), "Failed to serve")
+	}
+
+	printResult(handler.Stat())
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const networkCustom = "custom"
+
+// NetworkPreset is the public endpoints and chain ID of a known network.
+type NetworkPreset struct {
+	Url       string
+	EspaceUrl string
+	ScanUrl   string
+	ChainId   uint64
+}
+
+var networkPresets = map[string]NetworkPreset{
+	"mainnet": {
+		Url:       "https://main.confluxrpc.com",
+		EspaceUrl: "https://evm.confluxrpc.com",
+		ScanUrl:   "https://www.confluxscan.org",
+		ChainId:   1029,
+	},
+	"testnet": {
+		Url:       "https://test.confluxrpc.com",
+		EspaceUrl: "https://evmtestnet.confluxrpc.com",
+		ScanUrl:   "https://testnet.confluxscan.org",
+		ChainId:   1,
+	},
+}
+
+// networkNames returns the supported network names in order.
+func networkNames() string {
+	var names []string
+	for name := range networkPresets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return strings.Join(append(names, networkCustom), "|")
+}
+
+// applyNetworkPreset fills the endpoints and chain ID of the selected network, unless specified explicitly.
+func applyNetworkPreset(cmd *cobra.Command, _ []string) error {
+	if flags.Network == networkCustom {
+		if len(flags.Url) == 0 {
+			return errors.New("--url is required for custom network")
+		}
+
+		return verifyNetworkUrls()
+	}
+
+	preset, ok := networkPresets[flags.Network]
+	if !ok {
+		return errors.Errorf("Unknown network %v, expected %v", flags.Network, networkNames())
+	}
+
+	if !cmd.Flags().Changed("url") {
+		flags.Url = preset.Url
+	}
+
+	if !cmd.Flags().Changed("espace-url") {
+		flags.EspaceUrl = preset.EspaceUrl
+	}
+
+	if !cmd.Flags().Changed("scan-url") {
+		flags.ScanUrl = preset.ScanUrl
+	}
+
+	// chain ID is only verified against the public endpoints by default
+	if !cmd.Flags().Changed("chain-id") && !cmd.Flags().Changed("url") {
+		flags.ChainId = preset.ChainId
+	}
+
+	return verifyNetworkUrls()
+}
+
+// verifyNetworkUrls requires the endpoints of enabled features, which are empty for custom network unless
+// specified explicitly, so as not to fail later with opaque connection errors.
+func verifyNetworkUrls() error {
+	if len(flags.EspaceUrl) == 0 && (flags.Espace || flags.CrossSpace) {
+		return errors.New("--espace-url is required by --espace or --cross-space")
+	}
+
+	if len(flags.ScanUrl) == 0 && flags.ScanSamples != 0 {
+		return errors.New("--scan-url is required by --scan-samples")
+	}
+
+	return nil
+}
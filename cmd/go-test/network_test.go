@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func testApplyNetworkPreset(args ...string) error {
+	saved := flags
+	defer func() { flags = saved }()
+
+	var cmd cobra.Command
+	cmd.Flags().StringVar(&flags.Network, "network", "mainnet", "")
+	cmd.Flags().StringVar(&flags.Url, "url", "", "")
+	cmd.Flags().StringVar(&flags.EspaceUrl, "espace-url", "", "")
+	cmd.Flags().StringVar(&flags.ScanUrl, "scan-url", "", "")
+	cmd.Flags().Uint64Var(&flags.ChainId, "chain-id", 0, "")
+	cmd.Flags().BoolVar(&flags.Espace, "espace", false, "")
+	cmd.Flags().BoolVar(&flags.CrossSpace, "cross-space", false, "")
+	cmd.Flags().IntVar(&flags.ScanSamples, "scan-samples", 0, "")
+
+	if err := cmd.ParseFlags(args); err != nil {
+		return err
+	}
+
+	return applyNetworkPreset(&cmd, nil)
+}
+
+func TestApplyNetworkPreset(t *testing.T) {
+	for _, args := range [][]string{
+		{"--espace", "--cross-space", "--scan-samples", "1"},
+		{"--network", "testnet", "--espace"},
+		{"--network", "custom", "--url", "http://localhost:12537"},
+		{"--network", "custom", "--url", "http://localhost:12537", "--espace", "--espace-url", "http://localhost:8545"},
+		{"--network", "custom", "--url", "http://localhost:12537", "--scan-samples", "-1", "--scan-url", "http://localhost"},
+	} {
+		if err := testApplyNetworkPreset(args...); err != nil {
+			t.Errorf("Failed to apply network preset of %v: %v", args, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--network", "devnet"},
+		{"--network", "custom"},
+		{"--network", "custom", "--url", "http://localhost:12537", "--espace"},
+		{"--network", "custom", "--url", "http://localhost:12537", "--cross-space"},
+		{"--network", "custom", "--url", "http://localhost:12537", "--scan-samples", "1"},
+		{"--espace-url", "", "--espace"},
+	} {
+		if err := testApplyNetworkPreset(args...); err == nil {
+			t.Errorf("Expected error to apply network preset of %v", args)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math/big"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/sirupsen/logrus"
+)
+
+// txStatusSkipped indicates the transaction is not executed, e.g. duplicated in multiple blocks.
+const txStatusSkipped = 2
+
+// NonceChecker validates that nonces of executed transactions are continuous for each sender
+// in the execution order of epochs.
+type NonceChecker struct {
+	nonces map[string]*big.Int // last executed nonce by sender
+
+	NumTxs        int
+	NumViolations int
+}
+
+func NewNonceChecker() *NonceChecker {
+	return &NonceChecker{
+		nonces: make(map[string]*big.Int),
+	}
+}
+
+// NonceTx is the sender and nonce of an executed transaction.
+type NonceTx struct {
+	Hash   types.Hash
+	Sender string
+	Nonce  *big.Int
+}
+
+// executedNonces returns the sender and nonce of executed transactions in blocks in execution order.
+func executedNonces(blocks []*types.Block) []NonceTx {
+	var result []NonceTx
+
+	for _, block := range blocks {
+		for _, tx := range block.Transactions {
+			if tx.Status == nil || *tx.Status == txStatusSkipped || tx.Nonce == nil {
+				continue
+			}
+
+			result = append(result, NonceTx{tx.Hash, tx.From.String(), tx.Nonce.ToInt()})
+		}
+	}
+
+	return result
+}
+
+// Check validates the executed transactions in an epoch, which should be in execution order.
+func (checker *NonceChecker) Check(epoch uint64, txs []NonceTx) {
+	for _, tx := range txs {
+		checker.NumTxs++
+
+		if last, ok := checker.nonces[tx.Sender]; ok && new(big.Int).Sub(tx.Nonce, last).Cmp(big.NewInt(1)) != 0 {
+			logrus.WithFields(logrus.Fields{
+				"epoch":  epoch,
+				"tx":     tx.Hash,
+				"sender": tx.Sender,
+				"last":   last,
+				"nonce":  tx.Nonce,
+			}).Warn("Transaction nonce is not continuous")
+			checker.NumViolations++
+		}
+
+		checker.nonces[tx.Sender] = tx.Nonce
+	}
+}
+
+// Reset clears the tracked nonces, e.g. when failed to query some epoch, so as to avoid false alarms.
+func (checker *NonceChecker) Reset() {
+	checker.nonces = make(map[string]*big.Int)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// manifest of the current run
+var manifest report.Manifest
+
+// setupOutput routes logs to stderr so that only the result is printed to stdout, and suppresses
+// informational logs in quiet mode.
+func setupOutput() error {
+	logrus.SetOutput(os.Stderr)
+
+	level, err := logrus.ParseLevel(flags.LogLevel)
+	if err != nil {
+		return errors.WithMessage(err, "Invalid log level")
+	}
+	logrus.SetLevel(level)
+
+	if flags.Quiet {
+		logrus.SetLevel(min(level, logrus.WarnLevel))
+	}
+
+	return validateColor()
+}
+
+// startManifest records the command, flags and tool version at the beginning of run.
+func startManifest(cmd *cobra.Command) {
+	values := make(map[string]string)
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		values[flag.Name] = flag.Value.String()
+	})
+
+	manifest = report.NewManifest(cmd.CommandPath(), os.Args[1:], values, flags.Url)
+}
+
+// printResult outputs the result in JSON format to the output file if specified, otherwise stdout,
+// and writes the run manifest if required. Results with summary are printed as tables to stdout
+// instead unless --json specified.
+func printResult(result any) {
+	if flags.Manifest != "" {
+		if err := manifest.Write(flags.Manifest, result); err != nil {
+			fatal(ExitFailure, logrus.WithError(err).WithField("file", flags.Manifest), "Failed to write manifest")
+		}
+	}
+
+	summary, ok := result.(summarizer)
+
+	if !ok || flags.Json || flags.Output != "" {
+		if err := report.WriteJSON(flags.Output, result); err != nil {
+			fatal(ExitFailure, logrus.WithError(err).WithField("file", flags.Output), "Failed to write result")
+		}
+	}
+
+	if ok && !flags.Json {
+		summary.Summary(os.Stdout, useColor())
+	}
+}
+
+// printInfo outputs human readable information to stderr unless in quiet mode.
+func printInfo(format string, a ...any) {
+	if !flags.Quiet {
+		fmt.Fprintf(os.Stderr, format+"\n", a...)
+	}
+}
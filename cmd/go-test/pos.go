@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	postypes "github.com/Conflux-Chain/go-conflux-sdk/types/pos"
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var posFlags struct {
+	NumRequests int
+}
+
+func newPosCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "pos",
+		Short: "Benchmark PoS RPC",
+		Run:   testPos,
+	}
+
+	cmd.Flags().IntVar(&posFlags.NumRequests, "count", 100, "Number of requests for each PoS RPC method")
+
+	return &cmd
+}
+
+func testPos(*cobra.Command, []string) {
+	client := mustNewClient()
+	defer client.Close()
+
+	// prepare block numbers and accounts to query
+	status, err := client.Pos().GetStatus()
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get PoS status")
+	}
+
+	committee, err := client.Pos().GetCommittee()
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get PoS committee")
+	}
+
+	start := time.Now()
+	stat := PosStat{
+		client:    client,
+		status:    status,
+		Latencies: make(stats.LatencyStats),
+	}
+	for _, node := range committee.CurrentCommittee.Nodes {
+		stat.accounts = append(stat.accounts, node.Address)
+	}
+
+	if err = parallel.Serial(context.Background(), &stat, posFlags.NumRequests, flags.ParallelOption); err != nil {
+		fatal(ExitFailure, logrus.WithError(err), "Failed to parallel execute PoS RPC statistics")
+	}
+
+	printResult(stat)
+
+	printInfo("Total elapsed: %v", time.Since(start))
+}
+
+type PosStat struct {
+	client   *sdk.Client
+	status   postypes.Status
+	accounts []postypes.Address
+
+	Latencies stats.LatencyStats
+
+	NumErrors int
+}
+
+func (stat *PosStat) ParallelDo(ctx context.Context, routine, task int) (stats.MethodLatency, error) {
+	latency := stats.NewMethodLatency(flags.Jitter)
+	pos := stat.client.Pos()
+
+	if err := latency.Measure("pos_getStatus", func() error {
+		_, err := pos.GetStatus()
+		return err
+	}); err != nil {
+		return latency, errors.WithMessage(err, "Failed to get status")
+	}
+
+	if err := latency.Measure("pos_getCommittee", func() error {
+		_, err := pos.GetCommittee()
+		return err
+	}); err != nil {
+		return latency, errors.WithMessage(err, "Failed to get committee")
+	}
+
+	if len(stat.accounts) > 0 {
+		account := stat.accounts[rand.Intn(len(stat.accounts))]
+		if err := latency.Measure("pos_getAccount", func() error {
+			_, err := pos.GetAccount(account)
+			return err
+		}); err != nil {
+			return latency, errors.WithMessagef(err, "Failed to get account %v", account)
+		}
+	}
+
+	blockNumber := uint64(rand.Int63n(int64(stat.status.LatestCommitted) + 1))
+	if err := latency.Measure("pos_getBlockByNumber", func() error {
+		_, err := pos.GetBlockByNumber(postypes.NewBlockNumber(blockNumber))
+		return err
+	}); err != nil {
+		return latency, errors.WithMessagef(err, "Failed to get block by number %v", blockNumber)
+	}
+
+	return latency, nil
+}
+
+func (stat *PosStat) ParallelCollect(ctx context.Context, result *parallel.Result[stats.MethodLatency]) error {
+	stat.Latencies.Add(result.Value)
+
+	if result.Err != nil {
+		logrus.WithError(result.Err).WithField("task", result.Task).Warn("Failed to query PoS RPC")
+		stat.NumErrors++
+	}
+
+	return nil
+}
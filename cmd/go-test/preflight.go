@@ -0,0 +1,88 @@
+package main
+
+import (
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Capabilities is the data types that fullnode could serve at some epoch.
+type Capabilities struct {
+	ClientVersion string
+	ChainId       uint64
+	NetworkId     uint64
+	Epoch         uint64
+
+	Block    bool
+	Receipts bool
+	Traces   bool
+	State    bool // state at the epoch not pruned
+
+	Errors map[string]string `json:",omitempty"` // errors by data type
+}
+
+// Preflight probes fullnode at the given epoch to check whether the data types are available.
+func Preflight(client *sdk.Client, epochNumber uint64) (result Capabilities, err error) {
+	result.Epoch = epochNumber
+	result.Errors = make(map[string]string)
+
+	status, err := client.GetStatus()
+	if err != nil {
+		return Capabilities{}, errors.WithMessage(err, "Failed to get status")
+	}
+	result.ChainId, result.NetworkId = uint64(status.ChainID), uint64(status.NetworkID)
+
+	if result.ClientVersion, err = client.GetClientVersion(); err != nil {
+		return Capabilities{}, errors.WithMessage(err, "Failed to get client version")
+	}
+
+	epoch := types.NewEpochNumberUint64(epochNumber)
+	available := map[string]*bool{
+		dataTypeBlock:    &result.Block,
+		dataTypeReceipts: &result.Receipts,
+		dataTypeTraces:   &result.Traces,
+		dataTypeState:    &result.State,
+	}
+
+	for _, probe := range newDataProbes(uint32(status.NetworkID)) {
+		if err := probe.available(client, epoch); err != nil {
+			result.Errors[probe.dataType] = err.Error()
+		} else {
+			*available[probe.dataType] = true
+		}
+	}
+
+	return result, nil
+}
+
+// mustPreflight aborts if fullnode cannot serve the data types required for test.
+func mustPreflight(client *sdk.Client, epochNumber uint64) {
+	capabilities, err := Preflight(client, epochNumber)
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to probe fullnode")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"version":   capabilities.ClientVersion,
+		"chainId":   capabilities.ChainId,
+		"networkId": capabilities.NetworkId,
+		"epoch":     capabilities.Epoch,
+		"block":     capabilities.Block,
+		"receipts":  capabilities.Receipts,
+		"traces":    capabilities.Traces,
+		"state":     capabilities.State,
+	}).Info("Fullnode capabilities")
+
+	for dataType, msg := range capabilities.Errors {
+		logrus.WithField("type", dataType).Debug(msg)
+	}
+
+	if !capabilities.Block || !capabilities.Receipts || !capabilities.Traces {
+		fatal(ExitPreflight, logrus.WithField("errors", capabilities.Errors), "Fullnode cannot serve blocks, receipts or traces at the epoch to test from")
+	}
+
+	if !capabilities.State && (flags.AccountSamples > 0 || flags.ContractSamples > 0 || flags.StakingSamples > 0) {
+		logrus.WithField("error", capabilities.Errors["state"]).Warn("State at the epoch to test from is not available, state queries are likely pruned")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newProbeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "probe",
+		Short: "Discover the earliest epoch of which state, traces and receipts are still served by fullnode",
+		Run:   probe,
+	}
+}
+
+const dataTypeState = "state"
+
+// dataProbe checks whether some data type is available at the given epoch, and returns error if not.
+type dataProbe struct {
+	dataType  string
+	available func(client *sdk.Client, epoch *types.Epoch) error
+}
+
+// newDataProbes returns probes of all data types in order.
+func newDataProbes(networkId uint32) []dataProbe {
+	// any account is fine to query state
+	address := cfxaddress.MustNewFromHex(crossSpaceCallAddress, networkId)
+
+	return []dataProbe{
+		{dataTypeBlock, func(client *sdk.Client, epoch *types.Epoch) error {
+			_, err := getPivotBlock(client, epoch)
+			return err
+		}},
+		{dataTypeReceipts, func(client *sdk.Client, epoch *types.Epoch) error {
+			receipts, err := client.GetEpochReceipts(*types.NewEpochOrBlockHashWithEpoch(epoch))
+			if err == nil && receipts == nil {
+				return errors.New("receipts not found")
+			}
+
+			return err
+		}},
+		{dataTypeTraces, func(client *sdk.Client, epoch *types.Epoch) error {
+			pivot, err := getPivotBlock(client, epoch)
+			if err != nil {
+				return err
+			}
+
+			traces, err := client.GetBlockTraces(pivot.Hash)
+			if err == nil && traces == nil {
+				return errors.New("traces not found")
+			}
+
+			return err
+		}},
+		{dataTypeState, func(client *sdk.Client, epoch *types.Epoch) error {
+			_, err := client.GetBalance(address, types.NewEpochOrBlockHashWithEpoch(epoch))
+			return err
+		}},
+	}
+}
+
+// getPivotBlock returns the pivot block of the given epoch, or error if not found.
+func getPivotBlock(client *sdk.Client, epoch *types.Epoch) (*types.BlockSummary, error) {
+	pivot, err := client.GetBlockSummaryByEpoch(epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	if pivot == nil {
+		return nil, errors.New("pivot block not found")
+	}
+
+	return pivot, nil
+}
+
+// PruningBoundary is the earliest epoch of which some data type is served.
+type PruningBoundary struct {
+	Available bool   // whether available at the latest finalized epoch
+	Archive   bool   // whether available since genesis
+	Earliest  uint64 // earliest epoch available
+	NumProbes int
+}
+
+// FindPruningBoundary binary searches the earliest epoch in [0, latest] of which the data type is available,
+// assuming that data is pruned from the genesis epoch continuously.
+func FindPruningBoundary(client *sdk.Client, probe dataProbe, latest uint64) (result PruningBoundary) {
+	available := func(epoch uint64) bool {
+		result.NumProbes++
+
+		err := probe.available(client, types.NewEpochNumberUint64(epoch))
+		if err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"type":  probe.dataType,
+				"epoch": epoch,
+			}).Debug("Data not available")
+		}
+
+		return err == nil
+	}
+
+	if !available(latest) {
+		return result
+	}
+
+	result.Available = true
+
+	if available(0) {
+		result.Archive = true
+		return result
+	}
+
+	// data not available at low, but available at high
+	low, high := uint64(0), latest
+	for low+1 < high {
+		mid := low + (high-low)/2
+		if available(mid) {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+
+	result.Earliest = high
+
+	return result
+}
+
+func probe(*cobra.Command, []string) {
+	client := mustNewClient()
+	defer client.Close()
+
+	version, err := client.GetClientVersion()
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get client version")
+	}
+
+	networkId, err := client.GetNetworkID()
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get network id")
+	}
+
+	latestFinalizedEpoch, err := client.GetEpochNumber(types.EpochLatestFinalized)
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get latest finalized epoch")
+	}
+
+	latest := latestFinalizedEpoch.ToInt().Uint64()
+
+	logrus.WithFields(logrus.Fields{
+		"version":   version,
+		"finalized": latest,
+	}).Info("Probing pruning boundaries")
+
+	result := make(map[string]PruningBoundary)
+	for _, probe := range newDataProbes(networkId) {
+		boundary := FindPruningBoundary(client, probe, latest)
+		result[probe.dataType] = boundary
+
+		logrus.WithFields(logrus.Fields{
+			"type":      probe.dataType,
+			"available": boundary.Available,
+			"earliest":  boundary.Earliest,
+		}).Info("Pruning boundary found")
+	}
+
+	printResult(result)
+}
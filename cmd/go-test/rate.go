@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/sirupsen/logrus"
+)
+
+// rateWindow is the number of recent samples to compute the rolling average rates.
+const rateWindow = 10
+
+// Counters of all RPC requests issued so far.
+var (
+	numRequests      atomic.Uint64
+	numFailed        atomic.Uint64
+	totalLatency     atomic.Int64 // of completed requests in nanoseconds
+	numCompleted     atomic.Uint64
+	windowMaxLatency atomic.Int64 // max latency since last window sample in nanoseconds
+)
+
+// hookRequestCounter counts the RPC requests issued by client, and accumulates their latency and failures.
+func hookRequestCounter(client *sdk.Client) {
+	client.Provider().HookCallContext(func(call providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			numRequests.Add(1)
+
+			start := time.Now()
+			err := call(ctx, result, method, args...)
+			latency := int64(time.Since(start))
+
+			numCompleted.Add(1)
+			totalLatency.Add(latency)
+			if err != nil {
+				numFailed.Add(1)
+			}
+
+			for prev := windowMaxLatency.Load(); latency > prev && !windowMaxLatency.CompareAndSwap(prev, latency); {
+				prev = windowMaxLatency.Load()
+			}
+
+			return err
+		}
+	})
+}
+
+// WindowSample is the throughput, failures and latency of requests completed within a time window.
+type WindowSample struct {
+	Time     time.Time // start of window
+	Requests uint64
+	Errors   uint64
+	Qps      float64
+	Avg      string
+	Max      string
+}
+
+// RateSample is the instantaneous and rolling average rates at some time.
+type RateSample struct {
+	Time   time.Time
+	Rps    float64 // requests per second since last sample
+	Eps    float64 // epochs per second since last sample
+	AvgRps float64 // rolling average of requests per second over recent samples
+	AvgEps float64 // rolling average of epochs per second over recent samples
+}
+
+// RateMeter samples requests and epochs per second periodically during test, and optionally the series of
+// requests, failures and latency by coarser time windows.
+type RateMeter struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	numEpochs atomic.Uint64
+
+	start         time.Time
+	startRequests uint64
+	lastTime      time.Time
+	lastRequests  uint64
+	lastEpochs    uint64
+
+	windowStart     time.Time
+	windowCompleted uint64
+	windowFailed    uint64
+	windowLatency   int64
+
+	PeakRps float64
+	PeakEps float64
+	AvgRps  float64 // average over the whole run
+	AvgEps  float64 // average over the whole run
+	Series  []RateSample
+	Windows []WindowSample `json:",omitempty"`
+}
+
+// AddEpoch counts a completed epoch.
+func (meter *RateMeter) AddEpoch() {
+	meter.numEpochs.Add(1)
+}
+
+// Start samples rates by interval, and windows by window if positive, in a separate goroutine until stopped.
+func (meter *RateMeter) Start(interval, window time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	meter.cancel = cancel
+
+	meter.start = time.Now()
+	meter.lastTime = meter.start
+	meter.startRequests = numRequests.Load()
+	meter.lastRequests = meter.startRequests
+	meter.windowStart = meter.start
+	meter.windowCompleted, meter.windowFailed, meter.windowLatency = numCompleted.Load(), numFailed.Load(), totalLatency.Load()
+	windowMaxLatency.Store(0)
+
+	meter.wg.Add(1)
+	go func() {
+		defer meter.wg.Done()
+
+		// nil channels of disabled tickers never fire
+		var rateC, windowC <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			rateC = ticker.C
+		}
+		if window > 0 {
+			ticker := time.NewTicker(window)
+			defer ticker.Stop()
+			windowC = ticker.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				if window > 0 {
+					meter.sampleWindow(time.Now())
+				}
+				return
+			case now := <-windowC:
+				meter.sampleWindow(now)
+			case now := <-rateC:
+				sample := meter.sample(now)
+
+				logrus.WithFields(logrus.Fields{
+					"rps":    int(sample.Rps),
+					"eps":    int(sample.Eps),
+					"avgRps": int(sample.AvgRps),
+					"avgEps": int(sample.AvgEps),
+				}).Info("Throughput")
+			}
+		}
+	}()
+}
+
+// Stop stops sampling, and computes the average rates over the whole run.
+func (meter *RateMeter) Stop() {
+	if meter.cancel != nil {
+		meter.cancel()
+		meter.wg.Wait()
+	}
+
+	if elapsed := time.Since(meter.start).Seconds(); elapsed > 0 {
+		meter.AvgRps = float64(numRequests.Load()-meter.startRequests) / elapsed
+		meter.AvgEps = float64(meter.numEpochs.Load()) / elapsed
+	}
+}
+
+// sampleWindow appends the window since last window sample, which may be partial when stopped.
+func (meter *RateMeter) sampleWindow(now time.Time) {
+	completed, failed, latency := numCompleted.Load(), numFailed.Load(), totalLatency.Load()
+
+	sample := WindowSample{
+		Time:     meter.windowStart,
+		Requests: completed - meter.windowCompleted,
+		Errors:   failed - meter.windowFailed,
+		Max:      time.Duration(windowMaxLatency.Swap(0)).String(),
+	}
+
+	if elapsed := now.Sub(meter.windowStart).Seconds(); elapsed > 0 {
+		sample.Qps = float64(sample.Requests) / elapsed
+	}
+
+	var avg time.Duration
+	if sample.Requests > 0 {
+		avg = time.Duration((latency - meter.windowLatency) / int64(sample.Requests))
+	}
+	sample.Avg = avg.String()
+
+	meter.windowStart, meter.windowCompleted, meter.windowFailed, meter.windowLatency = now, completed, failed, latency
+	meter.Windows = append(meter.Windows, sample)
+}
+
+func (meter *RateMeter) sample(now time.Time) RateSample {
+	requests, epochs := numRequests.Load(), meter.numEpochs.Load()
+	elapsed := now.Sub(meter.lastTime).Seconds()
+
+	sample := RateSample{
+		Time: now,
+		Rps:  float64(requests-meter.lastRequests) / elapsed,
+		Eps:  float64(epochs-meter.lastEpochs) / elapsed,
+	}
+
+	meter.lastTime, meter.lastRequests, meter.lastEpochs = now, requests, epochs
+	meter.PeakRps = max(meter.PeakRps, sample.Rps)
+	meter.PeakEps = max(meter.PeakEps, sample.Eps)
+	meter.Series = append(meter.Series, sample)
+
+	recent := meter.Series[max(len(meter.Series)-rateWindow, 0):]
+	for _, v := range recent {
+		sample.AvgRps += v.Rps / float64(len(recent))
+		sample.AvgEps += v.Eps / float64(len(recent))
+	}
+	meter.Series[len(meter.Series)-1] = sample
+
+	return sample
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/fetch"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// methodReceiptsByPivot is the latency key of cfx_getEpochReceipts keyed by pivot block hash.
+const methodReceiptsByPivot = "cfx_getEpochReceipts(pivot)"
+
+// ReceiptsVariantResult is the result of comparing epoch receipts queried by pivot block hash against
+// those queried by epoch number.
+type ReceiptsVariantResult struct {
+	Checks     int
+	Mismatches int
+}
+
+func (result *ReceiptsVariantResult) Add(other ReceiptsVariantResult) {
+	result.Checks += other.Checks
+	result.Mismatches += other.Mismatches
+}
+
+// VerifyReceiptsByPivot queries epoch receipts by pivot block hash, and compares them against the receipts
+// queried by epoch number, since gateways may route the two variants differently.
+func VerifyReceiptsByPivot(client *sdk.Client, epochNumber uint64, data *fetch.EpochData) (result ReceiptsVariantResult, err error) {
+	if len(data.Blocks) == 0 {
+		return
+	}
+
+	pivot := data.Blocks[len(data.Blocks)-1].Hash
+
+	var receipts [][]types.TransactionReceipt
+	if err = data.Latency.Measure(methodReceiptsByPivot, func() (err error) {
+		receipts, err = client.GetEpochReceipts(*types.NewEpochOrBlockHashWithBlockHash(pivot, true))
+		return
+	}); err != nil {
+		return result, errors.WithMessagef(err, "Failed to get epoch receipts by pivot block hash %v", pivot)
+	}
+
+	expected, err := json.Marshal(data.Receipts)
+	if err != nil {
+		return result, errors.WithMessage(err, "Failed to marshal receipts by epoch number")
+	}
+
+	actual, err := json.Marshal(receipts)
+	if err != nil {
+		return result, errors.WithMessage(err, "Failed to marshal receipts by pivot block hash")
+	}
+
+	result.Checks++
+
+	if !bytes.Equal(expected, actual) {
+		logrus.WithFields(logrus.Fields{
+			"epoch": epochNumber,
+			"pivot": pivot,
+			"curl":  curl("cfx_getEpochReceipts", map[string]any{"blockHash": pivot, "requirePivot": true}),
+		}).Warn("Epoch receipts by pivot block hash mismatch with those by epoch number")
+		result.Mismatches++
+	}
+
+	return result, nil
+}
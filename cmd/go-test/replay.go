@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ReplayTx is an executed transaction to replay along with its receipt.
+type ReplayTx struct {
+	Tx      types.Transaction
+	Receipt types.TransactionReceipt
+}
+
+// replayTxs returns the executed transactions in blocks that could be replayed.
+func replayTxs(blocks []*types.Block, receipts [][]types.TransactionReceipt) []ReplayTx {
+	txReceipts := make(map[types.Hash]types.TransactionReceipt)
+	for _, blockReceipts := range receipts {
+		for _, receipt := range blockReceipts {
+			txReceipts[receipt.TransactionHash] = receipt
+		}
+	}
+
+	var result []ReplayTx
+	for _, block := range blocks {
+		for _, tx := range block.Transactions {
+			if receipt, ok := txReceipts[tx.Hash]; ok {
+				result = append(result, ReplayTx{tx, receipt})
+			}
+		}
+	}
+
+	return result
+}
+
+// callRequest reconstructs the call request of transaction.
+func (tx *ReplayTx) callRequest() types.CallRequest {
+	data := tx.Tx.Data
+
+	request := types.CallRequest{
+		From:  &tx.Tx.From,
+		To:    tx.Tx.To,
+		Value: tx.Tx.Value,
+		Data:  &data,
+	}
+
+	if tx.Tx.StorageLimit != nil {
+		storageLimit := hexutil.Uint64(tx.Tx.StorageLimit.ToInt().Uint64())
+		request.StorageLimit = &storageLimit
+	}
+
+	return request
+}
+
+// EstimateResult is the comparison of estimated and actual gas used of a replayed transaction.
+type EstimateResult struct {
+	Estimated uint64
+	Actual    uint64
+}
+
+// ReplayEstimates re-runs cfx_estimateGasAndCollateral for sampled transactions against the parent
+// epoch state, and returns the estimation results along with the number of failed estimations.
+func ReplayEstimates(client *sdk.Client, epochNumber uint64, blocks []*types.Block, receipts [][]types.TransactionReceipt, samples int, latency stats.MethodLatency) ([]EstimateResult, int, error) {
+	if epochNumber == 0 {
+		return nil, 0, nil
+	}
+
+	parentEpoch := types.NewEpochNumberUint64(epochNumber - 1)
+
+	var result []EstimateResult
+	var failures int
+
+	for _, tx := range sample(replayTxs(blocks, receipts), samples) {
+		var estimate types.Estimate
+		err := latency.Measure("cfx_estimateGasAndCollateral", func() (err error) {
+			estimate, err = client.EstimateGasAndCollateral(tx.callRequest(), parentEpoch)
+			return
+		})
+
+		if err != nil {
+			if !stats.IsRpcError(err) {
+				return nil, 0, errors.WithMessagef(err, "Failed to estimate gas and collateral for tx %v", tx.Tx.Hash)
+			}
+
+			logrus.WithError(err).WithField("tx", tx.Tx.Hash).Debug("Failed to replay gas estimation")
+			failures++
+			continue
+		}
+
+		if estimate.GasUsed == nil || tx.Receipt.GasUsed == nil {
+			continue
+		}
+
+		result = append(result, EstimateResult{
+			Estimated: estimate.GasUsed.ToInt().Uint64(),
+			Actual:    tx.Receipt.GasUsed.ToInt().Uint64(),
+		})
+	}
+
+	return result, failures, nil
+}
+
+// EstimateStat aggregates the accuracy of gas estimation for replayed transactions.
+type EstimateStat struct {
+	NumEstimates      int
+	NumFailures       int
+	NumUnderestimated int
+
+	totalEstimated uint64
+	totalActual    uint64
+	maxRatio       float64
+}
+
+func (stat *EstimateStat) Add(results []EstimateResult, failures int) {
+	stat.NumFailures += failures
+
+	for _, v := range results {
+		stat.NumEstimates++
+		stat.totalEstimated += v.Estimated
+		stat.totalActual += v.Actual
+
+		if v.Estimated < v.Actual {
+			stat.NumUnderestimated++
+		}
+
+		if v.Actual > 0 {
+			stat.maxRatio = max(stat.maxRatio, float64(v.Estimated)/float64(v.Actual))
+		}
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the estimation accuracy.
+func (stat *EstimateStat) MarshalJSON() ([]byte, error) {
+	type alias EstimateStat
+
+	var avgRatio float64
+	if stat.totalActual > 0 {
+		avgRatio = float64(stat.totalEstimated) / float64(stat.totalActual)
+	}
+
+	return json.Marshal(struct {
+		*alias
+		AvgRatio float64
+		MaxRatio float64
+	}{(*alias)(stat), avgRatio, stat.maxRatio})
+}
+
+// ReplayCalls replays sampled contract calls reconstructed from transactions via cfx_call at
+// their original epoch, and returns the number of calls along with the number of succeeded calls.
+func ReplayCalls(client *sdk.Client, epochNumber uint64, blocks []*types.Block, receipts [][]types.TransactionReceipt, samples int, latency stats.MethodLatency) (calls, succeeded int, err error) {
+	var contractCalls []ReplayTx
+	for _, tx := range replayTxs(blocks, receipts) {
+		if tx.Tx.To != nil && len(tx.Tx.Data) > 2 {
+			contractCalls = append(contractCalls, tx)
+		}
+	}
+
+	epoch := types.NewEpochOrBlockHashWithEpoch(types.NewEpochNumberUint64(epochNumber))
+
+	for _, tx := range sample(contractCalls, samples) {
+		calls++
+
+		if err = latency.Measure("cfx_call", func() error {
+			_, err := client.Call(tx.callRequest(), epoch)
+			return err
+		}); err == nil {
+			succeeded++
+			continue
+		}
+
+		if !stats.IsRpcError(err) {
+			return calls, succeeded, errors.WithMessagef(err, "Failed to call for tx %v", tx.Tx.Hash)
+		}
+
+		logrus.WithError(err).WithField("tx", tx.Tx.Hash).Debug("Failed to replay call")
+	}
+
+	return calls, succeeded, nil
+}
+
+// CallStat aggregates the success rate of RPC calls, e.g. replayed calls.
+type CallStat struct {
+	NumCalls     int
+	NumSucceeded int
+}
+
+func (stat *CallStat) Add(calls, succeeded int) {
+	stat.NumCalls += calls
+	stat.NumSucceeded += succeeded
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the success rate.
+func (stat *CallStat) MarshalJSON() ([]byte, error) {
+	type alias CallStat
+
+	var successRate float64
+	if stat.NumCalls > 0 {
+		successRate = float64(stat.NumSucceeded) / float64(stat.NumCalls)
+	}
+
+	return json.Marshal(struct {
+		*alias
+		SuccessRate float64
+	}{(*alias)(stat), successRate})
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResourceSampler samples the resource usage of this tool periodically, so as to tell whether the
+// measured latency is limited by the client machine.
+type ResourceSampler struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	numSamples     int
+	totalRss       uint64
+	peakRss        uint64
+	totalRoutines  int
+	peakRoutines   int
+	lastNumGC      uint32
+	numGC          int
+	totalGCPause   time.Duration
+	maxGCPause     time.Duration
+	numGCPauseLost int // GC pauses overwritten before sampled
+}
+
+// Start samples resource usage periodically in a separate goroutine until stopped.
+func (sampler *ResourceSampler) Start(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sampler.cancel = cancel
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	sampler.lastNumGC = memStats.NumGC
+
+	sampler.wg.Add(1)
+	go func() {
+		defer sampler.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sampler.sample()
+			}
+		}
+	}()
+}
+
+// Stop stops sampling, and takes the last sample.
+func (sampler *ResourceSampler) Stop() {
+	if sampler.cancel != nil {
+		sampler.cancel()
+		sampler.wg.Wait()
+	}
+
+	sampler.sample()
+}
+
+func (sampler *ResourceSampler) sample() {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	rss, ok := readRss()
+	if !ok {
+		rss = memStats.Sys
+	}
+
+	routines := runtime.NumGoroutine()
+
+	sampler.numSamples++
+	sampler.totalRss += rss
+	sampler.peakRss = max(sampler.peakRss, rss)
+	sampler.totalRoutines += routines
+	sampler.peakRoutines = max(sampler.peakRoutines, routines)
+
+	// GC pauses since last sample, of which only the recent 256 are available
+	for gc := sampler.lastNumGC + 1; gc <= memStats.NumGC; gc++ {
+		sampler.numGC++
+
+		if memStats.NumGC-gc >= uint32(len(memStats.PauseNs)) {
+			sampler.numGCPauseLost++
+			continue
+		}
+
+		pause := time.Duration(memStats.PauseNs[(gc+uint32(len(memStats.PauseNs))-1)%uint32(len(memStats.PauseNs))])
+		sampler.totalGCPause += pause
+		sampler.maxGCPause = max(sampler.maxGCPause, pause)
+	}
+
+	sampler.lastNumGC = memStats.NumGC
+}
+
+// readRss reads the resident set size from procfs, which is only available on Linux.
+func readRss() (uint64, bool) {
+	content, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) < 2 {
+		return 0, false
+	}
+
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return pages * uint64(os.Getpagesize()), true
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the peak and average resource usage.
+func (sampler *ResourceSampler) MarshalJSON() ([]byte, error) {
+	var summary struct {
+		NumSamples     int
+		PeakRssMB      float64
+		AvgRssMB       float64
+		PeakRoutines   int
+		AvgRoutines    float64
+		NumGC          int
+		TotalGCPause   string
+		MaxGCPause     string
+		AvgGCPause     string
+		NumGCPauseLost int `json:",omitempty"`
+	}
+
+	summary.NumSamples = sampler.numSamples
+	summary.PeakRssMB = float64(sampler.peakRss) / 1024 / 1024
+	summary.PeakRoutines = sampler.peakRoutines
+	summary.NumGC = sampler.numGC
+	summary.TotalGCPause = sampler.totalGCPause.String()
+	summary.MaxGCPause = sampler.maxGCPause.String()
+	summary.NumGCPauseLost = sampler.numGCPauseLost
+
+	if sampler.numSamples > 0 {
+		summary.AvgRssMB = float64(sampler.totalRss) / float64(sampler.numSamples) / 1024 / 1024
+		summary.AvgRoutines = float64(sampler.totalRoutines) / float64(sampler.numSamples)
+	}
+
+	var avgGCPause time.Duration
+	if sampled := sampler.numGC - sampler.numGCPauseLost; sampled > 0 {
+		avgGCPause = sampler.totalGCPause / time.Duration(sampled)
+	}
+	summary.AvgGCPause = avgGCPause.String()
+
+	return json.Marshal(summary)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/boqiu/go-test/pkg/cache"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// responseCache of RPC responses on disk, nil if disabled
+var responseCache *cache.ResponseCache
+
+// cacheableMethods are the RPC methods of which responses are deterministic once queried by hash or by explicit
+// epoch or block number, unlike tag queries, status or filters that change over time.
+var cacheableMethods = map[string]bool{
+	"cfx_getBlocksByEpoch":      true,
+	"cfx_getBlockByHash":        true,
+	"cfx_getBlockByEpochNumber": true,
+	"cfx_getBlockByBlockNumber": true,
+	"cfx_getTransactionByHash":  true,
+	"cfx_getTransactionReceipt": true,
+	"cfx_getEpochReceipts":      true,
+	"trace_block":               true,
+	"trace_transaction":         true,
+	"trace_epoch":               true,
+}
+
+// isCacheable returns true if the request is a deterministic lookup, i.e. an allowed method of which params
+// have no epoch tags such as latest_state. Hashes and numbers are hex strings, while tags are not.
+func isCacheable(method string, args []any) bool {
+	if !cacheableMethods[method] {
+		return false
+	}
+
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return false
+	}
+
+	var params any
+	if err = json.Unmarshal(encoded, &params); err != nil {
+		return false
+	}
+
+	return isExplicitParam(params)
+}
+
+// isExplicitParam returns true if all strings in param are hex, e.g. hash or number.
+func isExplicitParam(param any) bool {
+	switch v := param.(type) {
+	case string:
+		return strings.HasPrefix(v, "0x")
+	case []any:
+		for _, elem := range v {
+			if !isExplicitParam(elem) {
+				return false
+			}
+		}
+	case map[string]any:
+		for _, elem := range v {
+			if !isExplicitParam(elem) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// setupResponseCache creates the response cache directory if required.
+func setupResponseCache() error {
+	if len(flags.CacheDir) == 0 {
+		return nil
+	}
+
+	var err error
+	if responseCache, err = cache.NewResponseCache(flags.CacheDir, flags.CacheTTL); err != nil {
+		return errors.WithMessagef(err, "Failed to create response cache %v", flags.CacheDir)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"dir": flags.CacheDir,
+		"ttl": flags.CacheTTL,
+	}).Warn("Response cache enabled, latencies of cached responses are not measured against fullnode")
+
+	return nil
+}
+
+// hookResponseCache serves responses of deterministic lookups from the on-disk cache if any, otherwise caches
+// the successful responses from fullnode. Note, it should be hooked at first as the outermost to bypass other
+// hooks for cached responses, and so decodes responses of both cache hits and misses on behalf of the inner
+// decode hook, e.g. to archive decode errors and detect unknown fields.
+func hookResponseCache(client *sdk.Client, url string) {
+	if responseCache == nil {
+		return
+	}
+
+	client.Provider().HookCallContext(func(call providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if result == nil || !isCacheable(method, args) {
+				return call(ctx, result, method, args...)
+			}
+
+			if raw, ok := responseCache.Get(url, method, args); ok {
+				if err := decodeResponse(url, method, args, raw, result); err == nil {
+					return nil
+				}
+			}
+
+			var raw json.RawMessage
+			if err := call(ctx, &raw, method, args...); err != nil {
+				return err
+			}
+
+			if err := decodeResponse(url, method, args, raw, result); err != nil {
+				return err
+			}
+
+			// data not available yet, e.g. receipts of epoch not executed
+			if string(raw) == "null" {
+				return nil
+			}
+
+			if err := responseCache.Put(url, method, args, raw); err != nil {
+				logrus.WithError(err).WithField("method", method).Debug("Failed to cache response")
+			}
+
+			return nil
+		}
+	})
+}
+
+// reportResponseCache prints the response cache hits and misses if enabled.
+func reportResponseCache() {
+	if responseCache == nil {
+		return
+	}
+
+	hits, misses, numErrors := responseCache.Stat()
+	printInfo("Response cache: hits = %v, misses = %v, errors = %v", hits, misses, numErrors)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/boqiu/go-test/pkg/fetch"
+	"github.com/boqiu/go-test/pkg/stats"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// retryStats of RPC requests by method in the main pass and in retry
+var retryStats stats.RetryStats
+
+// hookRetryStats counts attempts and successes of RPC requests by method, either first tries or retries.
+func hookRetryStats(client *sdk.Client, retry bool) {
+	client.Provider().HookCallContext(func(call providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			err := call(ctx, result, method, args...)
+			retryStats.Add(method, retry, err)
+
+			return err
+		}
+	})
+}
+
+// RetryFailed retries the epochs failed in the main pass once serially with a longer RPC timeout,
+// so that only persistent failures are counted.
+func (stat *RpcStat) RetryFailed(ctx context.Context, timeout time.Duration) error {
+	if stat.scanner.NumFailed() == 0 {
+		return nil
+	}
+
+	// retry against all endpoints, skipping the ones ejected during retry
+	urls := append([]string{flags.Url}, flags.Endpoints...)
+	var clients []*sdk.Client
+	for _, url := range urls {
+		client, err := newClientOf(url, timeout)
+		if err != nil {
+			return errors.WithMessagef(err, "Failed to create client of %v to retry", url)
+		}
+		defer client.Close()
+
+		clients = append(clients, client)
+	}
+
+	pool := NewEndpointPool(clients, urls, flags.BreakerFailures, flags.BreakerCooldown)
+	if stat.raw != nil {
+		stat.raw = fetch.NewRawClient(chaosUrl(flags.Url), timeout, flags.RawOption)
+	}
+
+	logrus.WithField("epochs", stat.scanner.NumFailed()).Info("Retrying failed epochs")
+
+	if err := stat.scanner.RetryFailed(ctx, pool, stat.raw); err != nil {
+		return err
+	}
+
+	// keep per-epoch results in epoch order, while other stats sort epochs on output
+	slices.SortStableFunc(stat.Supplies, func(a, b SupplySample) int { return cmp.Compare(a.Epoch, b.Epoch) })
+	if stat.GasAccounting != nil {
+		slices.Sort(stat.GasAccounting.MismatchedEpochs)
+	}
+	if stat.TraceStructure != nil {
+		slices.Sort(stat.TraceStructure.Epochs)
+	}
+
+	return nil
+}
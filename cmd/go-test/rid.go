@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// lastRequestId is the correlation ID of the last RPC request
+var lastRequestId atomic.Uint64
+
+// hookRequestId assigns each RPC request a short correlation ID, which is logged along with timing and
+// included in error messages, so that a slow or failed request could be matched to its method, params
+// and endpoint in logs. Requests slower than --slow-threshold are logged as warnings immediately.
+func hookRequestId(client *sdk.Client, url string) {
+	client.Provider().HookCallContext(func(call providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			rid := strconv.FormatUint(lastRequestId.Add(1), 36)
+
+			start := time.Now()
+			err := call(ctx, result, method, args...)
+			elapsed := time.Since(start)
+
+			logger := logrus.WithFields(logrus.Fields{
+				"rid":      rid,
+				"method":   method,
+				"params":   args,
+				"endpoint": url,
+				"elapsed":  elapsed,
+			})
+
+			if flags.SlowThreshold > 0 && elapsed >= flags.SlowThreshold {
+				logger.Warn("Slow RPC request")
+			}
+
+			if err != nil {
+				logger.WithError(err).Debug("RPC request failed")
+				return errors.WithMessagef(err, "rid=%v", rid)
+			}
+
+			logger.Trace("RPC request completed")
+
+			return nil
+		}
+	})
+}
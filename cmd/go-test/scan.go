@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Fields of blocks and transactions to cross-verify against ConfluxScan if available on both sides.
+var (
+	scanBlockFields = []string{"hash", "parentHash", "epochNumber", "height", "miner", "timestamp", "gasLimit"}
+	scanTxFields    = []string{"hash", "blockHash", "from", "to", "nonce", "value", "gas", "gasPrice", "status", "contractCreated"}
+)
+
+// ScanResult is the result of cross-verifying sampled blocks and transactions against ConfluxScan.
+type ScanResult struct {
+	Checks        int
+	Discrepancies int
+	Errors        int // failed to query ConfluxScan, e.g. rate limited
+}
+
+func (result *ScanResult) Add(other ScanResult) {
+	result.Checks += other.Checks
+	result.Discrepancies += other.Discrepancies
+	result.Errors += other.Errors
+}
+
+// ScanClient queries blocks and transactions from ConfluxScan as an independent oracle, via paths
+// /v1/block/{hash} and /v1/transaction/{hash} of the scan website. Note, these are the routes that the
+// ConfluxScan web frontend queries from its backend, rather than the documented Open API, so they are not
+// guaranteed to be stable. A failed query is counted as error rather than discrepancy.
+type ScanClient struct {
+	url    string
+	client *http.Client
+}
+
+func NewScanClient(url string, timeout time.Duration) *ScanClient {
+	return &ScanClient{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// get queries the object of path, which is unwrapped from the data or result field if any.
+func (c *ScanClient) get(path string) (map[string]any, error) {
+	resp, err := c.client.Get(c.url + path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to send request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Unexpected status %v of %v", resp.Status, c.url+path)
+	}
+
+	// keep numbers exact, e.g. value and gasPrice in drip beyond the precision of float64
+	var body map[string]any
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err = decoder.Decode(&body); err != nil {
+		return nil, errors.WithMessage(err, "Failed to decode response")
+	}
+
+	for _, key := range []string{"data", "result"} {
+		if v, ok := body[key].(map[string]any); ok {
+			return v, nil
+		}
+	}
+
+	return body, nil
+}
+
+// VerifyScan cross-checks the pivot block and sampled transactions of epoch against ConfluxScan, and reports
+// the fields that differ.
+func VerifyScan(scan *ScanClient, epochNumber uint64, blocks []*types.Block, samples int) (result ScanResult) {
+	if len(blocks) == 0 {
+		return
+	}
+
+	pivot := blocks[len(blocks)-1]
+	result.verify(scan, epochNumber, "block", pivot.Hash.String(), pivot, scanBlockFields)
+
+	var txs []types.Transaction
+	for _, block := range blocks {
+		txs = append(txs, block.Transactions...)
+	}
+
+	if samples < 0 {
+		samples = len(txs)
+	}
+
+	for _, tx := range sample(txs, samples) {
+		result.verify(scan, epochNumber, "transaction", tx.Hash.String(), tx, scanTxFields)
+	}
+
+	return
+}
+
+// verify compares the fields of local object against the one queried from ConfluxScan.
+func (result *ScanResult) verify(scan *ScanClient, epochNumber uint64, kind, hash string, local any, fields []string) {
+	logger := logrus.WithFields(logrus.Fields{
+		"epoch": epochNumber,
+		kind:    hash,
+	})
+
+	remote, err := scan.get(fmt.Sprintf("/v1/%v/%v", kind, hash))
+	if err != nil {
+		logger.WithError(err).Debug("Failed to query ConfluxScan")
+		result.Errors++
+		return
+	}
+
+	encoded, err := json.Marshal(local)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to marshal " + kind)
+		result.Errors++
+		return
+	}
+
+	var expected map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err = decoder.Decode(&expected); err != nil {
+		logger.WithError(err).Warn("Failed to unmarshal " + kind)
+		result.Errors++
+		return
+	}
+
+	result.Checks++
+
+	var mismatched []string
+	for _, field := range fields {
+		a, ok1 := expected[field]
+		b, ok2 := remote[field]
+		if !ok1 || !ok2 || a == nil || b == nil {
+			continue
+		}
+
+		if !scanValueEqual(a, b) {
+			mismatched = append(mismatched, fmt.Sprintf("%v: %v != %v", field, a, b))
+		}
+	}
+
+	if len(mismatched) > 0 {
+		logger.WithField("fields", mismatched).Warn("Discrepancy with ConfluxScan")
+		result.Discrepancies++
+	}
+}
+
+// scanValueEqual compares values numerically if both are numbers in decimal or hex, otherwise case-insensitively.
+func scanValueEqual(a, b any) bool {
+	na, ok1 := scanNumber(a)
+	nb, ok2 := scanNumber(b)
+	if ok1 && ok2 {
+		return na.Cmp(nb) == 0
+	}
+
+	return strings.EqualFold(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func scanNumber(v any) (*big.Int, bool) {
+	switch value := v.(type) {
+	case json.Number:
+		n, ok := new(big.Int).SetString(value.String(), 10)
+		return n, ok
+	case string:
+		// only hex quantities rather than hashes or addresses
+		if strings.HasPrefix(value, "0x") && len(value) != 66 && len(value) != 42 {
+			n, err := hexutil.DecodeBig(value)
+			return n, err == nil
+		}
+
+		n, ok := new(big.Int).SetString(value, 10)
+		return n, ok
+	}
+
+	return nil, false
+}
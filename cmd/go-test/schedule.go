@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/boqiu/go-test/pkg/schedule"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// errScheduleStopped is returned when the scheduler is stopped by signal, so that the command completes
+// without running the test in the scheduler process.
+var errScheduleStopped = errors.New("Scheduler stopped")
+
+// runSchedule stays resident and runs the same command without --schedule periodically in a child process,
+// of which the result is published to the configured sinks, e.g. --output, --manifest and --history.
+// Returns errScheduleStopped when interrupted or terminated.
+func runSchedule() error {
+	cron, err := schedule.ParseCron(flags.Schedule)
+	if err != nil {
+		return errors.WithMessagef(err, "Invalid schedule %v", flags.Schedule)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.WithMessage(err, "Failed to get executable")
+	}
+
+	args := scheduledArgs(os.Args[1:])
+
+	// avoid child process to run in daemon mode via environment variable
+	var env []string
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, envName("schedule")+"=") {
+			env = append(env, v)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for run := 1; ; run++ {
+		next := cron.Next(time.Now())
+		if next.IsZero() {
+			return errors.Errorf("No time matches schedule %v", cron)
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"run":  run,
+			"next": next.Format(time.RFC3339),
+		}).Info("Waiting for next scheduled run")
+
+		select {
+		case <-ctx.Done():
+			logrus.Info("Scheduler stopped")
+			return errScheduleStopped
+		case <-time.After(time.Until(next)):
+		}
+
+		start := time.Now()
+
+		child := exec.CommandContext(ctx, executable, args...)
+		child.Env = env
+		child.Stdout, child.Stderr = os.Stdout, os.Stderr
+
+		// terminate the running child gracefully when stopped, rather than kill it
+		child.Cancel = func() error { return child.Process.Signal(syscall.SIGTERM) }
+		child.WaitDelay = 10 * time.Second
+
+		if err = child.Run(); err != nil {
+			logrus.WithError(err).WithField("run", run).Warn("Scheduled run failed")
+		} else {
+			logrus.WithFields(logrus.Fields{
+				"run":     run,
+				"elapsed": time.Since(start),
+			}).Info("Scheduled run completed")
+		}
+	}
+}
+
+// scheduledArgs removes the --schedule flag from command line arguments.
+func scheduledArgs(args []string) []string {
+	var result []string
+
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--schedule" {
+			i++ // skip value
+			continue
+		}
+
+		if strings.HasPrefix(args[i], "--schedule=") {
+			continue
+		}
+
+		result = append(result, args[i])
+	}
+
+	return result
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	starjson "go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// scriptTaskKey is the key of thread local scriptTask.
+const scriptTaskKey = "task"
+
+// callScript is a Starlark script of call subcommand, which defines the per-task logic in function
+// task(epoch), so as to call RPC methods and check responses with loops, variables and functions.
+type callScript struct {
+	task *starlark.Function
+}
+
+// scriptBuiltins are the builtins predeclared for script.
+var scriptBuiltins = starlark.StringDict{
+	"call":    starlark.NewBuiltin("call", scriptCall),
+	"check":   starlark.NewBuiltin("check", scriptCheck),
+	"latency": starlark.NewBuiltin("latency", scriptLatency),
+	"hex":     starlark.NewBuiltin("hex", scriptHex),
+	"json":    starjson.Module,
+}
+
+// loadCallScript executes the script file to define function task(epoch). Note, globals of script are frozen
+// once loaded, so that tasks could be executed concurrently.
+func loadCallScript(file string) (*callScript, error) {
+	thread := starlark.Thread{Name: "load"}
+
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, &thread, file, nil, scriptBuiltins)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to load script %v", file)
+	}
+
+	task, ok := globals["task"].(*starlark.Function)
+	if !ok {
+		return nil, errors.Errorf("Function task(epoch) not defined in script %v", file)
+	}
+
+	if task.NumParams() != 1 {
+		return nil, errors.Errorf("Function task should have exactly 1 parameter epoch, got %v", task.NumParams())
+	}
+
+	return &callScript{task}, nil
+}
+
+// scriptTask is the state of a task to execute script, which is shared by builtins.
+type scriptTask struct {
+	client  *sdk.Client
+	latency stats.MethodLatency
+
+	// the last call in task
+	method  string
+	params  []any
+	result  json.RawMessage
+	elapsed time.Duration
+
+	failure error // failure of builtin, e.g. RPC error or check failure
+}
+
+// run executes function task(epoch) of script.
+func (script *callScript) run(client *sdk.Client, latency stats.MethodLatency, epoch uint64) error {
+	task := scriptTask{client: client, latency: latency}
+
+	thread := starlark.Thread{Name: "task"}
+	thread.SetLocal(scriptTaskKey, &task)
+
+	if _, err := starlark.Call(&thread, script.task, starlark.Tuple{starlark.MakeUint64(epoch)}, nil); err != nil {
+		if task.failure != nil {
+			return task.failure
+		}
+
+		method := task.method
+		if len(method) == 0 {
+			method = "script"
+		}
+
+		return &callError{method, errors.WithMessage(err, "Failed to execute script")}
+	}
+
+	return nil
+}
+
+// scriptCall calls RPC method with params, e.g. call("cfx_getBlockByHash", hash, False), and returns the
+// result decoded as JSON, in which hex quantities could be parsed by int(value, 16).
+func scriptCall(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) == 0 || len(kwargs) > 0 {
+		return nil, errors.Errorf("%v: expected method and positional params", b.Name())
+	}
+
+	method, ok := starlark.AsString(args[0])
+	if !ok {
+		return nil, errors.Errorf("%v: expected method of string, got %v", b.Name(), args[0].Type())
+	}
+
+	encoded, err := starlark.Call(thread, starjson.Module.Members["encode"], starlark.Tuple{starlark.NewList(args[1:])}, nil)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "%v: invalid params of %v", b.Name(), method)
+	}
+
+	var params []any
+	if err = json.Unmarshal([]byte(encoded.(starlark.String)), &params); err != nil {
+		return nil, errors.WithMessagef(err, "%v: invalid params of %v", b.Name(), method)
+	}
+
+	task := thread.Local(scriptTaskKey).(*scriptTask)
+	task.method, task.params, task.result, task.elapsed = method, params, nil, 0
+
+	if err = task.latency.Measure(method, func() error {
+		start := time.Now()
+		err := task.client.CallRPC(&task.result, method, params...)
+		task.elapsed = time.Since(start)
+		return err
+	}); err != nil {
+		task.failure = &callError{method, errors.WithMessagef(err, "Failed to call %v", method)}
+		return nil, task.failure
+	}
+
+	return starlark.Call(thread, starjson.Module.Members["decode"], starlark.Tuple{starlark.String(task.result)}, nil)
+}
+
+// scriptCheck fails the task unless condition holds, e.g. check(len(receipts) > 0, "receipts not found"),
+// in which case the response of the last call is archived.
+func scriptCheck(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var condition starlark.Value
+	var message string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &condition, &message); err != nil {
+		return nil, err
+	}
+
+	if condition.Truth() {
+		return starlark.None, nil
+	}
+
+	if len(message) == 0 {
+		message = thread.CallFrame(1).Pos.String()
+	}
+
+	task := thread.Local(scriptTaskKey).(*scriptTask)
+
+	archiveResponse(report.ArchiveEntry{
+		Method: task.method,
+		Params: task.params,
+		Reason: "check failed: " + message,
+	}, task.result)
+
+	task.failure = &callError{task.method, &curlError{curl(task.method, task.params...), &checkError{expr: message}}}
+
+	return nil, task.failure
+}
+
+// scriptLatency returns the latency of the last call in milliseconds.
+func scriptLatency(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+
+	task := thread.Local(scriptTaskKey).(*scriptTask)
+
+	return starlark.Float(float64(task.elapsed) / float64(time.Millisecond)), nil
+}
+
+// scriptHex encodes integer as hex quantity, e.g. hex(epoch).
+func scriptHex(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var n starlark.Int
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &n); err != nil {
+		return nil, err
+	}
+
+	if n.Sign() < 0 {
+		return nil, errors.Errorf("%v: negative integer %v", b.Name(), n)
+	}
+
+	return starlark.String(hexutil.EncodeBig(n.BigInt())), nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+)
+
+func testCallScript(t *testing.T, source string) (*callScript, error) {
+	file := filepath.Join(t.TempDir(), "task.star")
+	if err := os.WriteFile(file, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return loadCallScript(file)
+}
+
+func TestLoadCallScript(t *testing.T) {
+	for _, source := range []string{
+		"def task(epoch):\n    pass\n",
+		"def task(epoch):\n    return epoch + 1\n\nLIMIT = 3\n",
+	} {
+		if _, err := testCallScript(t, source); err != nil {
+			t.Errorf("Failed to load script %q: %v", source, err)
+		}
+	}
+
+	for _, source := range []string{
+		"def run(epoch):\n    pass\n",
+		"def task():\n    pass\n",
+		"def task(epoch, client):\n    pass\n",
+		"task = 1\n",
+		"def task(epoch)\n    pass\n",
+		"def task(epoch):\n    undefined()\n",
+	} {
+		if _, err := testCallScript(t, source); err == nil {
+			t.Errorf("Expected error to load script %q", source)
+		}
+	}
+}
+
+func TestCallScriptRun(t *testing.T) {
+	server, requests := testBlockServer(t, map[string]string{
+		"0x01": `{"hash":"0x01","height":"0x1","transactions":["0xa","0xb"]}`,
+		"0x02": `{"hash":"0x02","height":"0x2","transactions":[]}`,
+		"0x1":  `{"hash":"0x1","height":"0x1","transactions":["0xa","0xb"]}`,
+	})
+	defer server.Close()
+
+	client, err := sdk.NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for _, c := range []struct {
+		name     string
+		body     string
+		requests int32
+		check    bool   // check failure expected
+		method   string // method of failure if any
+	}{
+		{"loop and variables", `
+    heights = []
+    for hash in ["0x01", "0x02"]:
+        block = call("cfx_getBlockByHash", hash, False)
+        heights.append(int(block["height"], 16))
+        check(block["hash"] == hash)
+    check(heights == [1, 2] and epoch == 7, "heights")
+    check(latency() >= 0)
+`, 2, false, ""},
+		{"conditional call", `
+    block = call("cfx_getBlockByHash", hex(epoch - 6), False)
+    if len(block["transactions"]) > 1:
+        call("cfx_getBlockByHash", "0x02", False)
+`, 2, false, ""},
+		{"check failure", `
+    block = call("cfx_getBlockByHash", "0x02", False)
+    check(len(block["transactions"]) > 0, "no transactions")
+`, 1, true, "cfx_getBlockByHash"},
+		{"call failure", `
+    call("cfx_getBlockByHash", "0x03")
+`, 1, false, "cfx_getBlockByHash"},
+		{"script failure", `
+    block = call("cfx_getBlockByHash", "0x01")
+    block["missing"]
+`, 1, false, "cfx_getBlockByHash"},
+		{"script failure before call", `
+    fail("aborted")
+`, 0, false, "script"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			script, err := testCallScript(t, "def task(epoch):"+c.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			requests.Store(0)
+			latency := stats.NewMethodLatency(0)
+
+			err = script.run(client, latency, 7)
+			if actual := requests.Load(); actual != c.requests {
+				t.Errorf("Expected %v requests, got %v", c.requests, actual)
+			}
+
+			if len(latency.Samples["cfx_getBlockByHash"]) > int(c.requests) {
+				t.Errorf("Expected at most %v latency samples, got %v", c.requests, latency.Samples)
+			}
+
+			if len(c.method) == 0 {
+				if err != nil {
+					t.Errorf("Failed to run script: %v", err)
+				}
+
+				return
+			}
+
+			var callErr *callError
+			if !errors.As(err, &callErr) || callErr.method != c.method {
+				t.Fatalf("Expected failure of %v, got %v", c.method, err)
+			}
+
+			var checkErr *checkError
+			if errors.As(err, &checkErr) != c.check {
+				t.Errorf("Expected check failure %v, got %v", c.check, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"time"
+
+	"github.com/boqiu/go-test/pkg/report"
+)
+
+// resultTables is the schema of per-epoch results in database, in which rows of each run are distinguished
+// by the run start time.
+var resultTables = []report.SqlTable{
+	{Name: "epochs", Columns: []report.SqlColumn{
+		{Name: "run", Type: "TEXT"},
+		{Name: "endpoint", Type: "TEXT"},
+		{Name: "epoch", Type: "BIGINT"},
+		{Name: "blocks", Type: "BIGINT"},
+		{Name: "txs", Type: "BIGINT"},
+		{Name: "receipts", Type: "BIGINT"},
+		{Name: "logs", Type: "BIGINT"},
+		{Name: "traces", Type: "BIGINT"},
+		{Name: "fetch_ms", Type: "DOUBLE PRECISION"},
+		{Name: "elapsed_ms", Type: "DOUBLE PRECISION"},
+	}},
+	{Name: "latencies", Columns: []report.SqlColumn{
+		{Name: "run", Type: "TEXT"},
+		{Name: "epoch", Type: "BIGINT"},
+		{Name: "method", Type: "TEXT"},
+		{Name: "latency_ms", Type: "DOUBLE PRECISION"},
+	}},
+	{Name: "verifications", Columns: []report.SqlColumn{
+		{Name: "run", Type: "TEXT"},
+		{Name: "epoch", Type: "BIGINT"},
+		{Name: "name", Type: "TEXT"},
+		{Name: "checks", Type: "BIGINT"},
+		{Name: "failures", Type: "BIGINT"},
+	}},
+	{Name: "errors", Columns: []report.SqlColumn{
+		{Name: "run", Type: "TEXT"},
+		{Name: "epoch", Type: "BIGINT"},
+		{Name: "method", Type: "TEXT"},
+		{Name: "error", Type: "TEXT"},
+	}},
+}
+
+// ResultSink inserts per-epoch metrics, errors and verification outcomes into database.
+type ResultSink struct {
+	sink *report.SqlSink
+	run  string
+}
+
+func NewResultSink(sink *report.SqlSink) *ResultSink {
+	return &ResultSink{
+		sink: sink,
+		run:  time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// AddEpoch inserts the metrics and verification outcomes of epoch.
+func (s *ResultSink) AddEpoch(epoch uint64, summary *EpochSummary) error {
+	if err := s.sink.Insert("epochs", s.run, flags.Url, epoch, summary.NumBlocks, summary.NumTxs,
+		summary.NumReceipts, summary.NumLogs, summary.NumTraces, millis(summary.Fetch), millis(summary.Elapsed)); err != nil {
+		return err
+	}
+
+	for method, samples := range summary.Latency.Samples {
+		for _, latency := range samples {
+			if err := s.sink.Insert("latencies", s.run, epoch, method, millis(latency)); err != nil {
+				return err
+			}
+		}
+	}
+
+	verifications := []verification{
+		{"trace", summary.TraceChecks, summary.TraceMismatches},
+		{"estimate", len(summary.Estimates) + summary.EstimateFailures, summary.EstimateFailures},
+		{"call", summary.Calls, summary.Calls - summary.CallsSucceeded},
+		{"balance", summary.BalanceChecks, summary.BalanceChecks - summary.BalanceChecksSucceeded},
+		{"log_fuzz", summary.LogFuzz.Queries, summary.LogFuzz.Violations + summary.LogFuzz.Missing},
+		{"scan", summary.Scan.Checks, summary.Scan.Discrepancies},
+		{"receipts_by_pivot", summary.ReceiptsByPivot.Checks, summary.ReceiptsByPivot.Mismatches},
+		{"logs_bloom", summary.LogsBloom.Checks, summary.LogsBloom.Mismatches},
+		{"transactions_root", summary.TxRoot.Checks, summary.TxRoot.Mismatches},
+		{"gas_accounting", summary.GasAccounting.Checks, summary.GasAccounting.Mismatches},
+		{"receipt_refs", summary.ReceiptRefs.Checks, summary.ReceiptRefs.Mismatches},
+		{"trace_structure", summary.TraceStructure.Checks, summary.TraceStructure.Violations},
+		{"cross_space", summary.CrossSpace.Calls + summary.CrossSpace.Phantoms,
+			summary.CrossSpace.Missing + summary.CrossSpace.Unmatched + summary.CrossSpace.StatusMismatches},
+		{"espace", summary.Espace.Blocks, summary.Espace.BlockMismatches + summary.Espace.ReceiptMismatches +
+			summary.Espace.LogMismatches + summary.Espace.MissingCoreLogs},
+	}
+
+	for _, v := range verifications {
+		if v.checks == 0 {
+			continue
+		}
+
+		if err := s.sink.Insert("verifications", s.run, epoch, v.name, v.checks, v.failures); err != nil {
+			return err
+		}
+	}
+
+	return s.sink.Checkpoint()
+}
+
+// AddError inserts the persistent failure of epoch.
+func (s *ResultSink) AddError(failed FailedEpoch) error {
+	if err := s.sink.Insert("errors", s.run, failed.Epoch, failed.Method, failed.Error); err != nil {
+		return err
+	}
+
+	return s.sink.Checkpoint()
+}
+
+func (s *ResultSink) Close() error {
+	return s.sink.Close()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/boqiu/go-test/pkg/scan"
+	"github.com/boqiu/go-test/pkg/stats"
+)
+
+func TestResultSink(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "results.db")
+
+	sink, err := report.NewSqliteSink(file, resultTables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := NewResultSink(sink)
+
+	latency := stats.NewMethodLatency(0)
+	latency.Samples["cfx_getBlockByHash"] = []time.Duration{time.Millisecond, 2 * time.Millisecond}
+	latency.Samples["cfx_getEpochReceipts"] = []time.Duration{3 * time.Millisecond}
+
+	if err = results.AddEpoch(100, &EpochSummary{
+		Summary: scan.Summary{
+			NumBlocks:   2,
+			NumTxs:      3,
+			NumReceipts: 3,
+			NumLogs:     4,
+			Latency:     latency,
+			Fetch:       10 * time.Millisecond,
+			Elapsed:     20 * time.Millisecond,
+		},
+		TraceChecks:     5,
+		TraceMismatches: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = results.AddError(FailedEpoch{Epoch: 101, Method: "trace_block", Error: "it's timeout"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = results.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, c := range []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT epoch || ',' || blocks || ',' || txs || ',' || receipts || ',' || logs || ',' || fetch_ms || ',' || elapsed_ms FROM epochs`, "100,2,3,3,4,10.0,20.0"},
+		{`SELECT count(*) || ',' || sum(latency_ms) FROM latencies WHERE epoch = 100`, "3,6.0"},
+		{`SELECT group_concat(name || ',' || checks || ',' || failures) FROM verifications WHERE epoch = 100`, "trace,5,1"},
+		{`SELECT epoch || ',' || method || ',' || error FROM errors`, "101,trace_block,it's timeout"},
+		{`SELECT count(DISTINCT run) FROM (SELECT run FROM epochs UNION ALL SELECT run FROM latencies UNION ALL SELECT run FROM errors)`, "1"},
+	} {
+		var actual string
+		if err = db.QueryRow(c.query).Scan(&actual); err != nil {
+			t.Fatalf("Failed to query %v: %v", c.query, err)
+		}
+
+		if actual != c.expected {
+			t.Errorf("Expected %v of %v, got %v", c.expected, c.query, actual)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// QuerySponsorInfos queries sponsor info of all contracts observed in receipts at the given epoch.
+func QuerySponsorInfos(client *sdk.Client, epochNumber uint64, receipts [][]types.TransactionReceipt, latency stats.MethodLatency) (map[string]types.SponsorInfo, error) {
+	epoch := types.NewEpochNumberUint64(epochNumber)
+	result := make(map[string]types.SponsorInfo)
+
+	for _, contract := range receiptContracts(receipts) {
+		var info types.SponsorInfo
+		if err := latency.Measure("cfx_getSponsorInfo", func() (err error) {
+			info, err = client.GetSponsorInfo(contract, epoch)
+			return
+		}); err != nil {
+			return nil, errors.WithMessagef(err, "Failed to get sponsor info of %v", contract)
+		}
+
+		result[contract.String()] = info
+	}
+
+	return result, nil
+}
+
+// SponsorStat aggregates the sponsorship of contracts observed in receipts.
+type SponsorStat struct {
+	infos map[string]types.SponsorInfo // latest sponsor info by contract
+
+	numTxs           int
+	numTxsGasCovered int
+}
+
+func NewSponsorStat() *SponsorStat {
+	return &SponsorStat{
+		infos: make(map[string]types.SponsorInfo),
+	}
+}
+
+func (stat *SponsorStat) Add(infos map[string]types.SponsorInfo, numTxs, numTxsGasCovered int) {
+	for contract, info := range infos {
+		stat.infos[contract] = info
+	}
+
+	stat.numTxs += numTxs
+	stat.numTxsGasCovered += numTxsGasCovered
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the aggregated sponsorship.
+func (stat *SponsorStat) MarshalJSON() ([]byte, error) {
+	var summary struct {
+		NumContracts                int
+		NumGasSponsored             int
+		NumCollateralSponsored      int
+		TotalBalanceForGas          *big.Int
+		TotalBalanceForCollateral   *big.Int
+		NumTxs                      int
+		NumTxsGasCoveredBySponsor   int
+		GasCoveredBySponsorFraction float64
+	}
+
+	summary.NumContracts = len(stat.infos)
+	summary.TotalBalanceForGas = new(big.Int)
+	summary.TotalBalanceForCollateral = new(big.Int)
+
+	for _, info := range stat.infos {
+		if balance := info.SponsorBalanceForGas; balance != nil && balance.ToInt().Sign() > 0 {
+			summary.NumGasSponsored++
+			summary.TotalBalanceForGas.Add(summary.TotalBalanceForGas, balance.ToInt())
+		}
+
+		if balance := info.SponsorBalanceForCollateral; balance != nil && balance.ToInt().Sign() > 0 {
+			summary.NumCollateralSponsored++
+			summary.TotalBalanceForCollateral.Add(summary.TotalBalanceForCollateral, balance.ToInt())
+		}
+	}
+
+	summary.NumTxs = stat.numTxs
+	summary.NumTxsGasCoveredBySponsor = stat.numTxsGasCovered
+	if stat.numTxs > 0 {
+		summary.GasCoveredBySponsorFraction = float64(stat.numTxsGasCovered) / float64(stat.numTxs)
+	}
+
+	return json.Marshal(summary)
+}
+
+// CheckBalances invokes cfx_checkBalanceAgainstTransaction for sampled (sender, contract) pairs of
+// transactions at the given epoch, and returns the number of checks along with the succeeded ones.
+func CheckBalances(client *sdk.Client, epochNumber uint64, blocks []*types.Block, receipts [][]types.TransactionReceipt, samples int, latency stats.MethodLatency) (checks, succeeded int, err error) {
+	var contractCalls []ReplayTx
+	for _, tx := range replayTxs(blocks, receipts) {
+		if tx.Tx.To != nil && tx.Tx.To.GetAddressType() == cfxaddress.AddressTypeContract {
+			contractCalls = append(contractCalls, tx)
+		}
+	}
+
+	epoch := types.NewEpochNumberUint64(epochNumber)
+
+	for _, tx := range sample(contractCalls, samples) {
+		checks++
+
+		gasPrice := tx.Tx.GasPrice
+		if gasPrice == nil {
+			gasPrice = tx.Tx.MaxFeePerGas
+		}
+
+		if err = latency.Measure("cfx_checkBalanceAgainstTransaction", func() error {
+			_, err := client.CheckBalanceAgainstTransaction(tx.Tx.From, *tx.Tx.To, tx.Tx.Gas, gasPrice, tx.Tx.StorageLimit, epoch)
+			return err
+		}); err == nil {
+			succeeded++
+			continue
+		}
+
+		if !stats.IsRpcError(err) {
+			return checks, succeeded, errors.WithMessagef(err, "Failed to check balance for tx %v", tx.Tx.Hash)
+		}
+
+		logrus.WithError(err).WithField("tx", tx.Tx.Hash).Warn("Failed to check balance against transaction")
+	}
+
+	return checks, succeeded, nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"math/rand"
+	"strings"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SupplySample is the token supply and interest rate at a sampled epoch.
+type SupplySample struct {
+	Epoch                  uint64
+	Supply                 types.TokenSupplyInfo
+	InterestRate           *hexutil.Big
+	AccumulateInterestRate *hexutil.Big
+}
+
+// QuerySupplyInfo queries the token supply and interest rate at the given epoch.
+func QuerySupplyInfo(client *sdk.Client, epochNumber uint64, latency stats.MethodLatency) (result SupplySample, err error) {
+	result.Epoch = epochNumber
+	epoch := types.NewEpochNumberUint64(epochNumber)
+
+	if err = latency.Measure("cfx_getSupplyInfo", func() (err error) {
+		result.Supply, err = client.GetSupplyInfo(epoch)
+		return
+	}); err != nil {
+		return SupplySample{}, errors.WithMessage(err, "Failed to get supply info")
+	}
+
+	if err = latency.Measure("cfx_getInterestRate", func() (err error) {
+		result.InterestRate, err = client.GetInterestRate(epoch)
+		return
+	}); err != nil {
+		return SupplySample{}, errors.WithMessage(err, "Failed to get interest rate")
+	}
+
+	if err = latency.Measure("cfx_getAccumulateInterestRate", func() (err error) {
+		result.AccumulateInterestRate, err = client.GetAccumulateInterestRate(epoch)
+		return
+	}); err != nil {
+		return SupplySample{}, errors.WithMessage(err, "Failed to get accumulate interest rate")
+	}
+
+	return result, nil
+}
+
+// StateResult is the result of state queries at a sampled epoch.
+type StateResult struct {
+	Reads        int
+	Pruned       int
+	DecodeErrors int
+}
+
+func (result *StateResult) Add(other StateResult) {
+	result.Reads += other.Reads
+	result.Pruned += other.Pruned
+	result.DecodeErrors += other.DecodeErrors
+}
+
+// read invokes a state RPC and counts the result, ignoring errors of pruned state and response decoding.
+func (result *StateResult) read(latency stats.MethodLatency, method string, rpc func() error) error {
+	result.Reads++
+
+	err := latency.Measure(method, rpc)
+	if err == nil {
+		return nil
+	}
+
+	if isStatePrunedError(err) {
+		result.Pruned++
+		return nil
+	}
+
+	if isDecodeError(err) {
+		logrus.WithError(err).WithField("method", method).Warn("Failed to decode RPC response")
+		result.DecodeErrors++
+		return nil
+	}
+
+	return err
+}
+
+// isStatePrunedError returns true if the state of requested epoch is no longer available on fullnode.
+func isStatePrunedError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "State for epoch") && strings.Contains(msg, "does not exist")
+}
+
+// isDecodeError returns true if failed to unmarshal the RPC response.
+func isDecodeError(err error) bool {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+
+	return errors.As(err, &typeErr) || errors.As(err, &syntaxErr)
+}
+
+// QueryAccountStates queries balance and nonce of sampled transaction senders at the given epoch.
+func QueryAccountStates(client *sdk.Client, epochNumber uint64, blocks []*types.Block, samples int, latency stats.MethodLatency) (result StateResult, err error) {
+	epoch := types.NewEpochOrBlockHashWithEpoch(types.NewEpochNumberUint64(epochNumber))
+
+	for _, account := range sample(blockSenders(blocks), samples) {
+		if err = result.read(latency, "cfx_getBalance", func() error {
+			_, err := client.GetBalance(account, epoch)
+			return err
+		}); err != nil {
+			return result, errors.WithMessagef(err, "Failed to get balance of %v", account)
+		}
+
+		if err = result.read(latency, "cfx_getNextNonce", func() error {
+			_, err := client.GetNextNonce(account, epoch)
+			return err
+		}); err != nil {
+			return result, errors.WithMessagef(err, "Failed to get nonce of %v", account)
+		}
+	}
+
+	return result, nil
+}
+
+// QueryStakingStates queries deposit list and vote list of sampled transaction senders at the given epoch.
+func QueryStakingStates(client *sdk.Client, epochNumber uint64, blocks []*types.Block, samples int, latency stats.MethodLatency) (result StateResult, err error) {
+	epoch := types.NewEpochNumberUint64(epochNumber)
+
+	for _, account := range sample(blockSenders(blocks), samples) {
+		if err = result.read(latency, "cfx_getDepositList", func() error {
+			_, err := client.GetDepositList(account, epoch)
+			return err
+		}); err != nil {
+			return result, errors.WithMessagef(err, "Failed to get deposit list of %v", account)
+		}
+
+		if err = result.read(latency, "cfx_getVoteList", func() error {
+			_, err := client.GetVoteList(account, epoch)
+			return err
+		}); err != nil {
+			return result, errors.WithMessagef(err, "Failed to get vote list of %v", account)
+		}
+	}
+
+	return result, nil
+}
+
+// blockSenders returns the distinct transaction senders in blocks.
+func blockSenders(blocks []*types.Block) []types.Address {
+	senders := make(map[string]types.Address)
+	for _, block := range blocks {
+		for _, tx := range block.Transactions {
+			senders[tx.From.String()] = tx.From
+		}
+	}
+
+	var result []types.Address
+	for _, v := range senders {
+		result = append(result, v)
+	}
+
+	return result
+}
+
+// QueryContractStates queries code and storage of sampled contracts in receipts at the given epoch.
+func QueryContractStates(client *sdk.Client, epochNumber uint64, receipts [][]types.TransactionReceipt, samples int, latency stats.MethodLatency) (result StateResult, err error) {
+	epoch := types.NewEpochOrBlockHashWithEpoch(types.NewEpochNumberUint64(epochNumber))
+	position := (*hexutil.Big)(big.NewInt(0))
+
+	for _, contract := range sample(receiptContracts(receipts), samples) {
+		if err = result.read(latency, "cfx_getCode", func() error {
+			_, err := client.GetCode(contract, epoch)
+			return err
+		}); err != nil {
+			return result, errors.WithMessagef(err, "Failed to get code of %v", contract)
+		}
+
+		if err = result.read(latency, "cfx_getStorageAt", func() error {
+			_, err := client.GetStorageAt(contract, position, epoch)
+			return err
+		}); err != nil {
+			return result, errors.WithMessagef(err, "Failed to get storage of %v", contract)
+		}
+	}
+
+	return result, nil
+}
+
+// receiptContracts returns the distinct core space contracts that emitted logs or created in receipts.
+func receiptContracts(receipts [][]types.TransactionReceipt) []types.Address {
+	contracts := make(map[string]types.Address)
+
+	for _, blockReceipts := range receipts {
+		for _, receipt := range blockReceipts {
+			if receipt.ContractCreated != nil {
+				contracts[receipt.ContractCreated.String()] = *receipt.ContractCreated
+			}
+
+			for _, log := range receipt.Logs {
+				if log.Space == nil || *log.Space == types.SPACE_NATIVE {
+					contracts[log.Address.String()] = log.Address
+				}
+			}
+		}
+	}
+
+	var result []types.Address
+	for _, v := range contracts {
+		result = append(result, v)
+	}
+
+	return result
+}
+
+// sample randomly picks up to n items.
+func sample[T any](items []T, n int) []T {
+	if n >= len(items) {
+		return items
+	}
+
+	rand.Shuffle(len(items), func(i, j int) {
+		items[i], items[j] = items[j], items[i]
+	})
+
+	return items[:n]
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// UnknownFields counts the fields responded from fullnode but silently dropped by SDK, by method and path,
+// e.g. cfx_getBlockByHash: {"transactions[].newField": 3}.
+type UnknownFields struct {
+	mu     sync.Mutex
+	fields map[string]map[string]int
+}
+
+// unknownFields of all RPC responses, nil if strict mode disabled
+var unknownFields *UnknownFields
+
+// setupStrict enables unknown field detection in strict mode.
+func setupStrict() {
+	if flags.Strict {
+		unknownFields = &UnknownFields{fields: make(map[string]map[string]int)}
+	}
+}
+
+func (u *UnknownFields) add(method string, paths []string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	fields, ok := u.fields[method]
+	if !ok {
+		fields = make(map[string]int)
+		u.fields[method] = fields
+	}
+
+	for _, path := range paths {
+		if fields[path] == 0 {
+			logrus.WithFields(logrus.Fields{
+				"method": method,
+				"field":  path,
+			}).Warn("Unknown field in response dropped by SDK")
+		}
+
+		fields[path]++
+	}
+}
+
+func (u *UnknownFields) MarshalJSON() ([]byte, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return json.Marshal(u.fields)
+}
+
+// diffFields returns the paths of non-null fields in raw JSON that are absent after re-encoding the decoded value.
+func diffFields(raw json.RawMessage, decoded any) ([]string, error) {
+	var expected any
+	if err := json.Unmarshal(raw, &expected); err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var actual any
+	if err = json.Unmarshal(encoded, &actual); err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]bool)
+	collectMissing(expected, actual, "", paths)
+
+	var result []string
+	for path := range paths {
+		result = append(result, path)
+	}
+
+	return result, nil
+}
+
+// collectMissing collects the paths of fields in expected but not in actual, in which array elements are
+// compared by index and denoted as [] in path.
+func collectMissing(expected, actual any, prefix string, paths map[string]bool) {
+	switch e := expected.(type) {
+	case map[string]any:
+		a, _ := actual.(map[string]any)
+		for key, value := range e {
+			if value == nil {
+				continue
+			}
+
+			path := key
+			if len(prefix) > 0 {
+				path = prefix + "." + key
+			}
+
+			if v, ok := a[key]; ok {
+				collectMissing(value, v, path, paths)
+			} else {
+				paths[path] = true
+			}
+		}
+	case []any:
+		a, _ := actual.([]any)
+		for i, value := range e {
+			if i < len(a) {
+				collectMissing(value, a[i], prefix+"[]", paths)
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/pkg/errors"
+)
+
+// summarizer is implemented by results that could be output as human readable tables.
+type summarizer interface {
+	Summary(w io.Writer, color bool)
+}
+
+// validateColor validates the --color flag.
+func validateColor() error {
+	switch flags.Color {
+	case "auto", "always", "never":
+		return nil
+	default:
+		return errors.Errorf("Invalid color %v, expected auto, always or never", flags.Color)
+	}
+}
+
+// useColor returns whether to color the summary table on stdout.
+func useColor() bool {
+	switch flags.Color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	info, err := os.Stdout.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// roundLatency rounds the latency for display.
+func roundLatency(d time.Duration) string {
+	return d.Round(10 * time.Microsecond).String()
+}
+
+// countStyle returns red if any failure, otherwise no style.
+func countStyle(failures int) report.Style {
+	if failures > 0 {
+		return report.StyleRed
+	}
+
+	return report.StyleNone
+}
+
+// Summary outputs counts, latency percentiles, error classes and verification results in tables.
+func (stat *RpcStat) Summary(w io.Writer, color bool) {
+	overview := report.NewTable("Summary")
+	overview.AddRow(report.Text("Epochs"), report.Text("%v", flags.NumEpochs))
+	overview.AddRow(report.Text("Blocks"), report.Text("%v", stat.NumBlocks))
+	if blocks := stat.BlocksPerEpoch.Summary(); blocks.Count > 0 {
+		overview.AddRow(report.Text("Blocks per epoch"), report.Text("min %v, median %v, p95 %v, max %v", blocks.Min, blocks.Median, blocks.P95, blocks.Max))
+	}
+	overview.AddRow(report.Text("Empty epochs"), report.Text("%v", stat.NumEmptyEpochs))
+	overview.AddRow(report.Text("Empty blocks"), report.Text("%v", stat.NumEmptyBlocks))
+	overview.AddRow(report.Text("Transactions"), report.Text("%v", stat.NumTxs))
+	overview.AddRow(report.Text("Logs"), report.Text("%v", stat.NumLogs))
+	overview.AddRow(report.Text("Traces"), report.Text("%v", stat.NumTraces))
+	overview.AddRow(report.Text("Failed epochs"), report.Styled(countStyle(stat.NumErrors), "%v", stat.NumErrors))
+	if stat.NumRetried > 0 {
+		style := report.StyleGreen
+		if stat.NumRecovered < stat.NumRetried {
+			style = report.StyleYellow
+		}
+		overview.AddRow(report.Text("Recovered epochs"), report.Styled(style, "%v / %v", stat.NumRecovered, stat.NumRetried))
+	}
+	overview.Write(w, color)
+
+	var methods []string
+	for method := range stat.Latencies {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	latencies := report.NewTable("Latency", "Method", "Count", "Min", "Avg", "P50", "P90", "P99", "Max")
+	for _, method := range methods {
+		summary := stat.Latencies[method].Summary()
+		latencies.AddRow(
+			report.Text("%v", method),
+			report.Text("%v", summary.Count),
+			report.Text("%v", roundLatency(summary.Min)),
+			report.Text("%v", roundLatency(summary.Avg)),
+			report.Text("%v", roundLatency(summary.P50)),
+			report.Text("%v", roundLatency(summary.P90)),
+			report.Text("%v", roundLatency(summary.P99)),
+			report.Text("%v", roundLatency(summary.Max)),
+		)
+	}
+	if latencies.Len() > 0 {
+		latencies.Write(w, color)
+	}
+
+	if stat.Retries != nil && stat.NumRetried > 0 {
+		summaries := stat.Retries.Summaries()
+
+		var retryMethods []string
+		for method := range summaries {
+			retryMethods = append(retryMethods, method)
+		}
+		sort.Strings(retryMethods)
+
+		retries := report.NewTable("Retries", "Method", "Attempts", "First try success", "Retries", "Retry success")
+		for _, method := range retryMethods {
+			summary := summaries[method]
+			retries.AddRow(
+				report.Text("%v", method),
+				report.Text("%v", summary.Attempts),
+				report.Text("%.2f%%", summary.FirstTrySuccess*100),
+				report.Text("%v", summary.Retries),
+				report.Text("%.2f%%", summary.RetrySuccess*100),
+			)
+		}
+		retries.Write(w, color)
+	}
+
+	errorClasses := report.NewTable("Errors", "Message", "Code", "Count")
+	for _, group := range stat.ErrorCodes.Groups() {
+		code := "-"
+		if group.Code != 0 {
+			code = strconv.Itoa(group.Code)
+		}
+		errorClasses.AddRow(report.Text("%v", group.Message), report.Text("%v", code), report.Styled(report.StyleRed, "%v", group.Count))
+	}
+	if errorClasses.Len() > 0 {
+		errorClasses.Write(w, color)
+	}
+
+	verifications := report.NewTable("Verifications", "Name", "Checks", "Failures", "Result")
+	for _, v := range verificationsOf(stat) {
+		verifications.AddRow(report.Text("%v", v.name), report.Text("%v", v.checks), report.Styled(countStyle(v.failures), "%v", v.failures), resultCell(v.failures))
+	}
+	if stat.Assert != nil {
+		verifications.AddRow(report.Text("assertions"), report.Text("%v", stat.Assert.NumChecks),
+			report.Styled(countStyle(stat.Assert.NumFailed), "%v", stat.Assert.NumFailed), resultCell(stat.Assert.NumFailed))
+	}
+	if verifications.Len() > 0 {
+		verifications.Write(w, color)
+	}
+}
+
+// resultCell returns a colored PASS or FAIL cell by the number of failures.
+func resultCell(failures int) report.Cell {
+	if failures > 0 {
+		return report.Styled(report.StyleRed, "FAIL")
+	}
+
+	return report.Styled(report.StyleGreen, "PASS")
+}
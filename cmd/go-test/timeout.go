@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/boqiu/go-test/pkg/stats"
+	providers "github.com/openweb3/go-rpc-provider/provider_wrapper"
+	"github.com/pkg/errors"
+)
+
+// Methods of data types to override timeout via dedicated flags.
+var (
+	blockMethods   = []string{"cfx_getBlocksByEpoch", "cfx_getBlockByHash", "cfx_getBlockByEpochNumber", "cfx_getBlockByBlockNumber"}
+	receiptMethods = []string{"cfx_getEpochReceipts", "cfx_getTransactionReceipt"}
+	traceMethods   = []string{"trace_block", "trace_transaction", "trace_filter", "trace_epoch"}
+)
+
+// methodTimeouts returns the RPC timeout overrides by method.
+func methodTimeouts() (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+
+	for _, v := range []struct {
+		timeout time.Duration
+		methods []string
+	}{
+		{flags.TimeoutBlocks, blockMethods},
+		{flags.TimeoutReceipts, receiptMethods},
+		{flags.TimeoutTraces, traceMethods},
+	} {
+		if v.timeout > 0 {
+			for _, method := range v.methods {
+				timeouts[method] = v.timeout
+			}
+		}
+	}
+
+	// timeouts of specific methods take precedence
+	for method, value := range flags.MethodTimeouts {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Invalid timeout of method %v", method)
+		}
+
+		timeouts[method] = timeout
+	}
+
+	return timeouts, nil
+}
+
+// timeoutHistogram of all RPC requests against the timeout budget
+var timeoutHistogram stats.TimeoutHistogram
+
+// hookTimeoutHistogram records where in the timeout window each RPC request completed, in which the default
+// timeout applies to methods without specific timeout.
+func hookTimeoutHistogram(client *sdk.Client, timeouts map[string]time.Duration, defaultTimeout time.Duration) {
+	client.Provider().HookCallContext(func(call providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			budget, ok := timeouts[method]
+			if !ok {
+				budget = defaultTimeout
+			}
+
+			start := time.Now()
+			err := call(ctx, result, method, args...)
+			timeoutHistogram.Add(time.Since(start), budget, err)
+
+			return err
+		}
+	})
+}
+
+// hookMethodTimeouts overrides the global RPC timeout of client for the given methods. Note, the global
+// timeout is only applied if there is no deadline in the request context.
+func hookMethodTimeouts(client *sdk.Client, timeouts map[string]time.Duration) {
+	if len(timeouts) == 0 {
+		return
+	}
+
+	client.Provider().HookCallContext(func(call providers.CallContextFunc) providers.CallContextFunc {
+		return func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+			if timeout, ok := timeouts[method]; ok {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			return call(ctx, result, method, args...)
+		}
+	})
+}
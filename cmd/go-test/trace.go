@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// VerifyTransactionTraces queries traces of sampled transactions via trace_transaction, and
+// compares them against the corresponding transaction traces in the block traces.
+func VerifyTransactionTraces(client *sdk.Client, epochNumber uint64, blockTraces []*types.LocalizedBlockTrace, samples int) (checked, mismatched int, err error) {
+	var txTraces []types.LocalizedTransactionTrace
+	for _, blockTrace := range blockTraces {
+		if blockTrace != nil {
+			txTraces = append(txTraces, blockTrace.TransactionTraces...)
+		}
+	}
+
+	for _, expected := range sample(txTraces, samples) {
+		actual, err := client.GetTransactionTraces(expected.TransactionHash)
+		if err != nil {
+			return checked, mismatched, errors.WithMessagef(err, "Failed to get transaction traces by hash %v", expected.TransactionHash)
+		}
+
+		checked++
+
+		if !tracesEqual(expected.Traces, actual) {
+			logrus.WithFields(logrus.Fields{
+				"epoch":    epochNumber,
+				"tx":       expected.TransactionHash,
+				"expected": len(expected.Traces),
+				"actual":   len(actual),
+				"curl":     curl("trace_transaction", expected.TransactionHash),
+			}).Warn("Transaction traces mismatch with block traces")
+			archiveMismatch(client, epochNumber, "transaction traces mismatch with block traces", "trace_transaction", expected.TransactionHash)
+			mismatched++
+		}
+	}
+
+	return checked, mismatched, nil
+}
+
+// tracesEqual compares traces by type, validity and action, ignoring the optional location
+// fields which are only populated by trace_transaction.
+func tracesEqual(expected, actual []types.LocalizedTrace) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	for i := range expected {
+		if expected[i].Type != actual[i].Type || expected[i].Valid != actual[i].Valid {
+			return false
+		}
+
+		expectedAction, err1 := json.Marshal(expected[i].Action)
+		actualAction, err2 := json.Marshal(actual[i].Action)
+		if err1 != nil || err2 != nil || !bytes.Equal(expectedAction, actualAction) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"math/big"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/sirupsen/logrus"
+)
+
+// TraceStructureResult is the result of validating structure of transaction traces.
+type TraceStructureResult struct {
+	Checks     int            // transactions checked
+	Violations int            // transactions with any structural violation
+	ByKind     map[string]int `json:",omitempty"` // violations by kind
+	Epochs     []uint64       `json:",omitempty"` // epochs with any violation
+}
+
+func (result *TraceStructureResult) Add(other TraceStructureResult) {
+	result.Checks += other.Checks
+	result.Violations += other.Violations
+	result.Epochs = append(result.Epochs, other.Epochs...)
+
+	for kind, count := range other.ByKind {
+		if result.ByKind == nil {
+			result.ByKind = make(map[string]int)
+		}
+
+		result.ByKind[kind] += count
+	}
+}
+
+// traceFrame is an open call or create action waiting for its result.
+type traceFrame struct {
+	resultType types.TraceType
+	gas        *big.Int
+}
+
+// txStatusSuccess indicates the transaction is executed successfully.
+const txStatusSuccess = 0
+
+// traceViolation returns the first structural violation of transaction traces, or empty if valid. Traces
+// are a flattened tree, in which each call or create action is closed by a matching result later, along
+// with internal transfers, e.g. gas payment. Besides, traces are validated against the receipt of transaction
+// if any, i.e. a skipped transaction has no traces, while the root action of an executed one is the call or
+// create of the transaction itself. Note, a failed transaction may have no action at all, e.g. not enough cash.
+func traceViolation(traces []types.LocalizedTrace, receipt *types.TransactionReceipt) string {
+	if receipt != nil {
+		switch {
+		case receipt.OutcomeStatus == txStatusSkipped && len(traces) > 0:
+			return "traces of skipped transaction"
+		case receipt.OutcomeStatus == txStatusSuccess && len(traces) == 0:
+			return "no traces of executed transaction"
+		}
+
+		if violation := rootActionViolation(traces, receipt); violation != "" {
+			return violation
+		}
+	}
+
+	var frames []traceFrame
+	var roots int
+
+	// closes the innermost open action with result
+	closeFrame := func(resultType types.TraceType, gasLeft *big.Int) string {
+		if len(frames) == 0 {
+			return "result without action"
+		}
+
+		frame := frames[len(frames)-1]
+		frames = frames[:len(frames)-1]
+
+		if frame.resultType != resultType {
+			return "result type mismatch with action"
+		}
+
+		if gasLeft.Cmp(frame.gas) > 0 {
+			return "gas left exceeds gas of action"
+		}
+
+		return ""
+	}
+
+	for _, trace := range traces {
+		var violation string
+
+		switch action := trace.Action.(type) {
+		case types.Call:
+			frames = append(frames, traceFrame{types.TRACE_CALL_RESULT, action.Gas.ToInt()})
+		case types.Create:
+			frames = append(frames, traceFrame{types.TRACE_CREATE_RESULT, action.Gas.ToInt()})
+		case types.CallResult:
+			violation = closeFrame(types.TRACE_CALL_RESULT, action.GasLeft.ToInt())
+		case types.CreateResult:
+			violation = closeFrame(types.TRACE_CREATE_RESULT, action.GasLeft.ToInt())
+		}
+
+		// a transaction has a single root action, apart from internal transfers
+		if len(frames) == 1 && (trace.Type == types.TRACE_CALL || trace.Type == types.TRACE_CREATE) {
+			if roots++; roots > 1 {
+				violation = "multiple root actions"
+			}
+		}
+
+		if violation != "" {
+			return violation
+		}
+	}
+
+	if len(frames) > 0 {
+		return "action without result"
+	}
+
+	return ""
+}
+
+// rootActionViolation validates the first call or create action against the sender and receiver of transaction.
+func rootActionViolation(traces []types.LocalizedTrace, receipt *types.TransactionReceipt) string {
+	var root any
+	for _, trace := range traces {
+		if trace.Type == types.TRACE_CALL || trace.Type == types.TRACE_CREATE {
+			root = trace.Action
+			break
+		}
+	}
+
+	switch action := root.(type) {
+	case types.Call:
+		if receipt.To == nil {
+			return "call action of contract creation"
+		}
+
+		if action.From.String() != receipt.From.String() || action.To.String() != receipt.To.String() {
+			return "root call mismatch with transaction"
+		}
+	case types.Create:
+		if receipt.To != nil {
+			return "create action of contract call"
+		}
+
+		if action.From.String() != receipt.From.String() {
+			return "root create mismatch with transaction"
+		}
+	default:
+		if receipt.OutcomeStatus == txStatusSuccess {
+			return "no action of executed transaction"
+		}
+	}
+
+	return ""
+}
+
+// VerifyTraceStructure validates the trace trees of all transactions in epoch structurally, i.e. every call
+// or create action has a matching result, and gas left in results never exceeds gas in actions. Transaction
+// traces of each block are matched against the receipts of the block, so that every executed transaction has
+// traces rooted at its own action, and skipped ones have none.
+func VerifyTraceStructure(epochNumber uint64, blockTraces []*types.LocalizedBlockTrace, receipts [][]types.TransactionReceipt) (result TraceStructureResult) {
+	for i, blockTrace := range blockTraces {
+		if blockTrace == nil {
+			continue
+		}
+
+		blockReceipts := make(map[types.Hash]*types.TransactionReceipt)
+		if i < len(receipts) {
+			for j := range receipts[i] {
+				blockReceipts[receipts[i][j].TransactionHash] = &receipts[i][j]
+			}
+		}
+
+		for _, txTrace := range blockTrace.TransactionTraces {
+			receipt := blockReceipts[txTrace.TransactionHash]
+			delete(blockReceipts, txTrace.TransactionHash)
+
+			violation := traceViolation(txTrace.Traces, receipt)
+			result.check(epochNumber, blockTrace.BlockHash, txTrace.TransactionHash, len(txTrace.Traces), violation)
+		}
+
+		// executed transactions missing in block traces
+		for hash, receipt := range blockReceipts {
+			if receipt.OutcomeStatus == txStatusSuccess {
+				result.check(epochNumber, blockTrace.BlockHash, hash, 0, "no traces of executed transaction")
+			}
+		}
+	}
+
+	return result
+}
+
+// check counts a transaction checked, and reports the violation if any.
+func (result *TraceStructureResult) check(epochNumber uint64, blockHash, txHash types.Hash, numTraces int, violation string) {
+	result.Checks++
+
+	if violation == "" {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"epoch":     epochNumber,
+		"block":     blockHash,
+		"tx":        txHash,
+		"traces":    numTraces,
+		"violation": violation,
+		"curl":      curl("trace_block", blockHash),
+	}).Warn("Transaction traces structurally invalid")
+
+	if result.Violations == 0 {
+		result.Epochs = append(result.Epochs, epochNumber)
+	}
+
+	if result.ByKind == nil {
+		result.ByKind = make(map[string]int)
+	}
+
+	result.Violations++
+	result.ByKind[violation]++
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/Conflux-Chain/go-conflux-sdk/types/cfxaddress"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	testSender   = cfxaddress.MustNewFromHex("0x1000000000000000000000000000000000000001", 1029)
+	testContract = cfxaddress.MustNewFromHex("0x8000000000000000000000000000000000000002", 1029)
+)
+
+func testGas(v int64) hexutil.Big {
+	return hexutil.Big(*big.NewInt(v))
+}
+
+func testCall(from, to types.Address, gas int64) types.LocalizedTrace {
+	return types.LocalizedTrace{Type: types.TRACE_CALL, Action: types.Call{From: from, To: to, Gas: testGas(gas)}}
+}
+
+func testCreate(from types.Address, gas int64) types.LocalizedTrace {
+	return types.LocalizedTrace{Type: types.TRACE_CREATE, Action: types.Create{From: from, Gas: testGas(gas)}}
+}
+
+func testCallResult(gasLeft int64) types.LocalizedTrace {
+	return types.LocalizedTrace{Type: types.TRACE_CALL_RESULT, Action: types.CallResult{GasLeft: testGas(gasLeft)}}
+}
+
+func testCreateResult(gasLeft int64) types.LocalizedTrace {
+	return types.LocalizedTrace{Type: types.TRACE_CREATE_RESULT, Action: types.CreateResult{GasLeft: testGas(gasLeft)}}
+}
+
+func testTransfer() types.LocalizedTrace {
+	return types.LocalizedTrace{Type: types.TRACE_INTERNAL_TRANSFER_ACTIION, Action: types.InternalTransferAction{}}
+}
+
+func testReceipt(status uint64, to *types.Address) *types.TransactionReceipt {
+	return &types.TransactionReceipt{From: testSender, To: to, OutcomeStatus: hexutil.Uint64(status)}
+}
+
+func TestTraceViolation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		traces   []types.LocalizedTrace
+		receipt  *types.TransactionReceipt
+		expected string
+	}{
+		{"call", []types.LocalizedTrace{testTransfer(), testCall(testSender, testContract, 100), testCall(testContract, testSender, 50), testCallResult(10), testCallResult(60), testTransfer()}, testReceipt(0, &testContract), ""},
+		{"create", []types.LocalizedTrace{testCreate(testSender, 100), testCreateResult(0)}, testReceipt(0, nil), ""},
+		{"skipped", nil, testReceipt(txStatusSkipped, &testContract), ""},
+		{"failed without action", []types.LocalizedTrace{testTransfer()}, testReceipt(1, &testContract), ""},
+		{"no receipt", nil, nil, ""},
+
+		{"traces of skipped", []types.LocalizedTrace{testCall(testSender, testContract, 100), testCallResult(0)}, testReceipt(txStatusSkipped, &testContract), "traces of skipped transaction"},
+		{"no traces", nil, testReceipt(0, &testContract), "no traces of executed transaction"},
+		{"no action", []types.LocalizedTrace{testTransfer()}, testReceipt(0, &testContract), "no action of executed transaction"},
+		{"wrong sender", []types.LocalizedTrace{testCall(testContract, testContract, 100), testCallResult(0)}, testReceipt(0, &testContract), "root call mismatch with transaction"},
+		{"wrong receiver", []types.LocalizedTrace{testCall(testSender, testSender, 100), testCallResult(0)}, testReceipt(0, &testContract), "root call mismatch with transaction"},
+		{"call of creation", []types.LocalizedTrace{testCall(testSender, testContract, 100), testCallResult(0)}, testReceipt(0, nil), "call action of contract creation"},
+		{"create of call", []types.LocalizedTrace{testCreate(testSender, 100), testCreateResult(0)}, testReceipt(0, &testContract), "create action of contract call"},
+		{"multiple roots", []types.LocalizedTrace{testCall(testSender, testContract, 100), testCallResult(0), testCall(testSender, testContract, 100), testCallResult(0)}, nil, "multiple root actions"},
+		{"result without action", []types.LocalizedTrace{testCallResult(0)}, nil, "result without action"},
+		{"action without result", []types.LocalizedTrace{testCall(testSender, testContract, 100)}, nil, "action without result"},
+		{"result type mismatch", []types.LocalizedTrace{testCall(testSender, testContract, 100), testCreateResult(0)}, nil, "result type mismatch with action"},
+		{"gas left exceeds", []types.LocalizedTrace{testCall(testSender, testContract, 100), testCallResult(101)}, nil, "gas left exceeds gas of action"},
+	} {
+		if actual := traceViolation(tc.traces, tc.receipt); actual != tc.expected {
+			t.Errorf("%v: expected violation %q, but got %q", tc.name, tc.expected, actual)
+		}
+	}
+}
+
+func TestVerifyTraceStructure(t *testing.T) {
+	executed, skipped, missing := types.Hash("0x01"), types.Hash("0x02"), types.Hash("0x03")
+
+	traces := []*types.LocalizedBlockTrace{
+		{
+			BlockHash: "0xb1",
+			TransactionTraces: []types.LocalizedTransactionTrace{
+				{TransactionHash: executed, Traces: []types.LocalizedTrace{testCall(testSender, testContract, 100), testCallResult(0)}},
+				{TransactionHash: skipped},
+			},
+		},
+		nil, // skipped block without transactions
+	}
+
+	receipts := [][]types.TransactionReceipt{
+		{
+			*testReceipt(0, &testContract),
+			*testReceipt(txStatusSkipped, &testContract),
+			*testReceipt(0, &testContract),
+		},
+		nil,
+	}
+	receipts[0][0].TransactionHash = executed
+	receipts[0][1].TransactionHash = skipped
+	receipts[0][2].TransactionHash = missing
+
+	result := VerifyTraceStructure(1, traces, receipts)
+	if result.Checks != 3 || result.Violations != 1 || result.ByKind["no traces of executed transaction"] != 1 {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+)
+
+// TraceBreakdown is the number of traces by type and call type.
+type TraceBreakdown struct {
+	Epoch      uint64         `json:",omitempty"`
+	ByType     map[string]int // e.g. call, create and internal_transfer_action
+	ByCallType map[string]int `json:",omitempty"` // e.g. call, delegatecall and staticcall
+}
+
+func newTraceBreakdown(epoch uint64) TraceBreakdown {
+	return TraceBreakdown{
+		Epoch:      epoch,
+		ByType:     make(map[string]int),
+		ByCallType: make(map[string]int),
+	}
+}
+
+func (breakdown *TraceBreakdown) add(other TraceBreakdown) {
+	for k, v := range other.ByType {
+		breakdown.ByType[k] += v
+	}
+
+	for k, v := range other.ByCallType {
+		breakdown.ByCallType[k] += v
+	}
+}
+
+// traceBreakdownOf counts traces of epoch by type and call type.
+func traceBreakdownOf(epochNumber uint64, blockTraces []*types.LocalizedBlockTrace) TraceBreakdown {
+	breakdown := newTraceBreakdown(epochNumber)
+
+	for _, blockTrace := range blockTraces {
+		if blockTrace == nil {
+			continue
+		}
+
+		for _, txTrace := range blockTrace.TransactionTraces {
+			for _, trace := range txTrace.Traces {
+				breakdown.ByType[string(trace.Type)]++
+
+				if call, ok := trace.Action.(types.Call); ok {
+					breakdown.ByCallType[string(call.CallType)]++
+				}
+			}
+		}
+	}
+
+	return breakdown
+}
+
+// TraceBreakdownStat aggregates the trace breakdown per epoch and overall.
+type TraceBreakdownStat struct {
+	Overall TraceBreakdown
+	Epochs  []TraceBreakdown
+}
+
+func NewTraceBreakdownStat() *TraceBreakdownStat {
+	return &TraceBreakdownStat{Overall: newTraceBreakdown(0)}
+}
+
+func (stat *TraceBreakdownStat) Add(breakdown TraceBreakdown) {
+	if len(breakdown.ByType) == 0 {
+		return
+	}
+
+	stat.Overall.add(breakdown)
+	stat.Epochs = append(stat.Epochs, breakdown)
+}
+
+// MarshalJSON implements the json.Marshaler interface to output epochs in epoch order, regardless of the
+// collection order, e.g. epochs retried at the end.
+func (stat *TraceBreakdownStat) MarshalJSON() ([]byte, error) {
+	type plain TraceBreakdownStat
+	sorted := plain(*stat)
+	sorted.Epochs = slices.Clone(stat.Epochs)
+	slices.SortStableFunc(sorted.Epochs, func(a, b TraceBreakdown) int { return cmp.Compare(a.Epoch, b.Epoch) })
+
+	return json.Marshal(sorted)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+)
+
+func TestTraceBreakdownStat(t *testing.T) {
+	traces := func(epoch uint64, calls int) TraceBreakdown {
+		var txTrace types.LocalizedTransactionTrace
+		for i := 0; i < calls; i++ {
+			txTrace.Traces = append(txTrace.Traces, testCall(testSender, testContract, 100), testCallResult(0))
+		}
+
+		return traceBreakdownOf(epoch, []*types.LocalizedBlockTrace{{TransactionTraces: []types.LocalizedTransactionTrace{txTrace}}, nil})
+	}
+
+	stat := NewTraceBreakdownStat()
+	stat.Add(traces(3, 1))
+	stat.Add(traces(1, 2))
+	stat.Add(traces(4, 0)) // skipped without traces
+	stat.Add(traces(2, 1))
+
+	encoded, err := json.Marshal(stat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded TraceBreakdownStat
+	if err = json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Overall.ByType[string(types.TRACE_CALL)] != 4 || decoded.Overall.ByType[string(types.TRACE_CALL_RESULT)] != 4 {
+		t.Fatalf("Unexpected overall breakdown %s", encoded)
+	}
+
+	if len(decoded.Epochs) != 3 || decoded.Epochs[0].Epoch != 1 || decoded.Epochs[1].Epoch != 2 || decoded.Epochs[2].Epoch != 3 {
+		t.Fatalf("Expected epochs in order, but got %s", encoded)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"time"
+
+	"github.com/boqiu/go-test/pkg/report"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// newHistoryRecord summarizes the test run for history.
+func newHistoryRecord(stat *RpcStat) report.HistoryRecord {
+	record := report.HistoryRecord{
+		Time:      time.Now(),
+		Endpoint:  flags.Url,
+		ChainId:   manifest.ChainId,
+		NumEpochs: flags.NumEpochs,
+		NumErrors: stat.NumErrors,
+		Latencies: make(map[string]stats.LatencySummary),
+	}
+
+	for method, latency := range stat.Latencies {
+		record.Latencies[method] = latency.Summary()
+	}
+
+	return record
+}
+
+var trendFlags struct {
+	History string
+	Last    int
+}
+
+func newTrendCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "trend",
+		Short: "Print latency and error trends of the endpoint over the recent test runs in history",
+		Run:   trend,
+	}
+
+	cmd.Flags().StringVar(&trendFlags.History, "history", "history.ndjson", "History file appended by test runs via --history")
+	cmd.Flags().IntVar(&trendFlags.Last, "last", 10, "Number of recent runs to analyze")
+
+	return &cmd
+}
+
+func trend(*cobra.Command, []string) {
+	runs, err := report.ReadHistory(trendFlags.History, flags.Url)
+	if err != nil {
+		fatal(ExitConfig, logrus.WithError(err).WithField("file", trendFlags.History), "Failed to read history")
+	}
+
+	if len(runs) == 0 {
+		fatal(ExitConfig, logrus.WithField("endpoint", flags.Url), "No history of the endpoint")
+	}
+
+	if trendFlags.Last > 0 && len(runs) > trendFlags.Last {
+		runs = runs[len(runs)-trendFlags.Last:]
+	}
+
+	printResult(report.AnalyzeTrend(flags.Url, runs))
+}
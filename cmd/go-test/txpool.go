@@ -0,0 +1,171 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var txpoolFlags struct {
+	Interval      time.Duration
+	Duration      time.Duration
+	Senders       []string
+	NumHotSenders int
+}
+
+func newTxpoolCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "txpool",
+		Short: "Inspect transaction pool over time",
+		Run:   inspectTxpool,
+	}
+
+	cmd.Flags().DurationVar(&txpoolFlags.Interval, "interval", time.Second, "Interval to poll transaction pool")
+	cmd.Flags().DurationVar(&txpoolFlags.Duration, "duration", time.Minute, "Duration to inspect transaction pool")
+	cmd.Flags().StringSliceVar(&txpoolFlags.Senders, "senders", nil, "Senders to query pending transactions, by default the hot senders in the best block")
+	cmd.Flags().IntVar(&txpoolFlags.NumHotSenders, "hot-senders", 10, "Number of hot senders in the best block to query pending transactions")
+
+	return &cmd
+}
+
+func inspectTxpool(*cobra.Command, []string) {
+	client := mustNewClient()
+	defer client.Close()
+
+	senders, err := txpoolSenders(client)
+	if err != nil {
+		fatal(ExitPreflight, logrus.WithError(err), "Failed to get senders to inspect")
+	}
+
+	stat := TxpoolStat{
+		Senders:   make([]string, 0, len(senders)),
+		Latencies: make(stats.LatencyStats),
+	}
+	for _, v := range senders {
+		stat.Senders = append(stat.Senders, v.String())
+	}
+
+	ticker := time.NewTicker(txpoolFlags.Interval)
+	defer ticker.Stop()
+
+	for start := time.Now(); time.Since(start) < txpoolFlags.Duration; <-ticker.C {
+		latency := stats.NewMethodLatency(flags.Jitter)
+
+		sample, err := QueryTxpool(client, senders, latency)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to query transaction pool")
+			stat.NumErrors++
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"ready":      sample.Status.Ready,
+			"deferred":   sample.Status.Deferred,
+			"unexecuted": sample.Status.Unexecuted,
+		}).Info("Transaction pool status")
+
+		stat.Samples = append(stat.Samples, sample)
+		stat.Latencies.Add(latency)
+	}
+
+	printResult(stat)
+}
+
+// txpoolSenders returns the senders to inspect, which are either specified or the hot ones in the best block.
+func txpoolSenders(client *sdk.Client) ([]types.Address, error) {
+	var result []types.Address
+
+	if len(txpoolFlags.Senders) > 0 {
+		for _, v := range txpoolFlags.Senders {
+			address, err := client.NewAddress(v)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "Invalid sender %v", v)
+			}
+
+			result = append(result, address)
+		}
+
+		return result, nil
+	}
+
+	bestHash, err := client.GetBestBlockHash()
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to get best block hash")
+	}
+
+	block, err := client.GetBlockByHash(bestHash)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "Failed to get block by hash %v", bestHash)
+	}
+
+	counts := make(map[string]int)
+	for _, tx := range block.Transactions {
+		if counts[tx.From.String()] == 0 {
+			result = append(result, tx.From)
+		}
+
+		counts[tx.From.String()]++
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return counts[result[i].String()] > counts[result[j].String()]
+	})
+
+	if len(result) > txpoolFlags.NumHotSenders {
+		result = result[:txpoolFlags.NumHotSenders]
+	}
+
+	return result, nil
+}
+
+// TxpoolSample is the transaction pool status polled at some time.
+type TxpoolSample struct {
+	Time    time.Time
+	Status  types.TxPoolStatus
+	Pending map[string]uint64 `json:",omitempty"` // number of pending transactions by sender
+}
+
+// QueryTxpool queries the transaction pool status and pending transactions of given senders.
+func QueryTxpool(client *sdk.Client, senders []types.Address, latency stats.MethodLatency) (result TxpoolSample, err error) {
+	result.Time = time.Now()
+
+	if err = latency.Measure("txpool_status", func() (err error) {
+		result.Status, err = client.TxPool().Status()
+		return
+	}); err != nil {
+		return TxpoolSample{}, errors.WithMessage(err, "Failed to get txpool status")
+	}
+
+	if len(senders) > 0 {
+		result.Pending = make(map[string]uint64)
+	}
+
+	for _, sender := range senders {
+		var pending types.AccountPendingTransactions
+		if err = latency.Measure("cfx_getAccountPendingTransactions", func() (err error) {
+			pending, err = client.GetAccountPendingTransactions(sender, nil, nil)
+			return
+		}); err != nil {
+			return TxpoolSample{}, errors.WithMessagef(err, "Failed to get pending transactions of %v", sender)
+		}
+
+		result.Pending[sender.String()] = uint64(pending.PendingCount)
+	}
+
+	return result, nil
+}
+
+type TxpoolStat struct {
+	Senders []string
+	Samples []TxpoolSample
+
+	Latencies stats.LatencyStats
+
+	NumErrors int
+}
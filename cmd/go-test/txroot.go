@@ -0,0 +1,56 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/light"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/sirupsen/logrus"
+)
+
+// TxRootResult is the result of verifying transactions root of sampled blocks.
+type TxRootResult struct {
+	Checks     int
+	Mismatches int
+}
+
+func (result *TxRootResult) Add(other TxRootResult) {
+	result.Checks += other.Checks
+	result.Mismatches += other.Mismatches
+}
+
+// transactionsRoot recomputes the transactions root of block from its transaction list.
+func transactionsRoot(block *types.Block) types.Hash {
+	txs := make([]types.WrapTransaction, len(block.Transactions))
+	for i := range block.Transactions {
+		txs[i].NativeTransaction = &block.Transactions[i]
+	}
+
+	return types.Hash(light.CreateTransactionsMPT(txs).Hash().Hex())
+}
+
+// VerifyTransactionsRoot recomputes the transactions root of sampled blocks, and compares it against the
+// block header, so as to detect truncated or reordered transaction lists in RPC responses.
+func VerifyTransactionsRoot(epochNumber uint64, blocks []*types.Block, samples int) (result TxRootResult) {
+	if samples < 0 {
+		samples = len(blocks)
+	}
+
+	for _, block := range sample(slices.Clone(blocks), samples) {
+		result.Checks++
+
+		if actual := transactionsRoot(block); actual != block.TransactionsRoot {
+			logrus.WithFields(logrus.Fields{
+				"epoch":    epochNumber,
+				"block":    block.Hash,
+				"txs":      len(block.Transactions),
+				"expected": block.TransactionsRoot,
+				"actual":   actual,
+				"curl":     curl("cfx_getBlockByHash", block.Hash, true),
+			}).Warn("Transactions root mismatch with transaction list")
+			result.Mismatches++
+		}
+	}
+
+	return result
+}
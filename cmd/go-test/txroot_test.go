@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+)
+
+// testTxRootBlock returns the mainnet block 0x26f15d... with transaction hashes only, which are all that
+// transactions root depends on.
+func testTxRootBlock() *types.Block {
+	block := types.Block{
+		BlockHeader: types.BlockHeader{
+			Hash:             "0x26f15dc6f353485cdfb1b370becc4abfdacbd36e39c3f9f42be724fe4073cfeb",
+			TransactionsRoot: "0xbf9add52641cdeb9fec7fc8bbacfaf71592c37df34b1f36220003af8797dfb41",
+		},
+	}
+
+	for _, hash := range []types.Hash{
+		"0x740b71de5591fe87bf661d5c4a39cf2a1fbf8cf21b68928033a7382154c78d19",
+		"0x536cb069dc9024625c3ae27fef0a32df6733bec0a159f1b4871741b73b0419cb",
+		"0x18e0f546df2f56e8149bb9424d70201feb41f8e2ea7c1a850a03b8a2f508a8f7",
+	} {
+		block.Transactions = append(block.Transactions, types.Transaction{Hash: hash})
+	}
+
+	return &block
+}
+
+func TestTransactionsRoot(t *testing.T) {
+	if root := transactionsRoot(testTxRootBlock()); root != testTxRootBlock().TransactionsRoot {
+		t.Errorf("Expected transactions root %v, but got %v", testTxRootBlock().TransactionsRoot, root)
+	}
+
+	// root of empty mainnet blocks
+	if root := transactionsRoot(&types.Block{}); root != "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470" {
+		t.Errorf("Unexpected transactions root %v of empty block", root)
+	}
+}
+
+func TestVerifyTransactionsRoot(t *testing.T) {
+	truncated := testTxRootBlock()
+	truncated.Transactions = truncated.Transactions[:2]
+
+	reordered := testTxRootBlock()
+	reordered.Transactions[0], reordered.Transactions[1] = reordered.Transactions[1], reordered.Transactions[0]
+
+	blocks := []*types.Block{testTxRootBlock(), truncated, reordered}
+
+	if result := VerifyTransactionsRoot(1, blocks, -1); result != (TxRootResult{Checks: 3, Mismatches: 2}) {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+
+	if result := VerifyTransactionsRoot(1, blocks, 1); result.Checks != 1 {
+		t.Fatalf("Expected 1 sampled block, but got %+v", result)
+	}
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ResponseCache caches raw RPC responses on disk keyed by endpoint, method and params, so that identical
+// queries are not sent to fullnode again within TTL, e.g. during iterative development of verifications.
+type ResponseCache struct {
+	dir string
+	ttl time.Duration
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errors atomic.Uint64 // failed to read or write cache files
+}
+
+// NewResponseCache creates the cache directory if not exists.
+func NewResponseCache(dir string, ttl time.Duration) (*ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.WithMessage(err, "Failed to create directory")
+	}
+
+	return &ResponseCache{dir: dir, ttl: ttl}, nil
+}
+
+// file returns the cache file of request, named by hash of endpoint, method and params, so that file names
+// never depend on the raw method string.
+func (cache *ResponseCache) file(url, method string, params []any) (string, error) {
+	// params are marshaled with sorted map keys, which is canonical enough for the same caller
+	key, err := json.Marshal([]any{url, method, params})
+	if err != nil {
+		return "", errors.WithMessage(err, "Failed to marshal params")
+	}
+
+	hash := sha256.Sum256(key)
+
+	return filepath.Join(cache.dir, hex.EncodeToString(hash[:16])+".json"), nil
+}
+
+// Get returns the cached raw response if exists and not expired.
+func (cache *ResponseCache) Get(url, method string, params []any) (json.RawMessage, bool) {
+	file, err := cache.file(url, method, params)
+	if err != nil {
+		cache.errors.Add(1)
+		return nil, false
+	}
+
+	info, err := os.Stat(file)
+	if err != nil || (cache.ttl > 0 && time.Since(info.ModTime()) > cache.ttl) {
+		cache.misses.Add(1)
+		return nil, false
+	}
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		cache.errors.Add(1)
+		cache.misses.Add(1)
+		return nil, false
+	}
+
+	cache.hits.Add(1)
+
+	return raw, true
+}
+
+// Put caches the raw response, which is written to a temp file and renamed to avoid partial reads by
+// concurrent threads.
+func (cache *ResponseCache) Put(url, method string, params []any, raw json.RawMessage) error {
+	file, err := cache.file(url, method, params)
+	if err != nil {
+		cache.errors.Add(1)
+		return err
+	}
+
+	temp, err := os.CreateTemp(cache.dir, "tmp-*")
+	if err != nil {
+		cache.errors.Add(1)
+		return errors.WithMessage(err, "Failed to create temp file")
+	}
+
+	_, err = temp.Write(raw)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), file)
+	}
+
+	if err != nil {
+		os.Remove(temp.Name())
+		cache.errors.Add(1)
+		return errors.WithMessage(err, "Failed to write cache file")
+	}
+
+	return nil
+}
+
+// Stat returns the number of cache hits, misses and file errors.
+func (cache *ResponseCache) Stat() (hits, misses, numErrors uint64) {
+	return cache.hits.Load(), cache.misses.Load(), cache.errors.Load()
+}
@@ -0,0 +1,211 @@
+package chaos
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Option is the rates of faults injected by proxy.
+type Option struct {
+	DropRate      float64       // probability to close connection without response
+	DelayRate     float64       // probability to delay request
+	Delay         time.Duration // delay of request
+	MalformedRate float64       // probability to respond a malformed JSON
+}
+
+// Enabled returns whether any fault would be injected.
+func (option Option) Enabled() bool {
+	return option.DropRate > 0 || (option.DelayRate > 0 && option.Delay > 0) || option.MalformedRate > 0
+}
+
+// Stat is the number of requests proxied and faults injected.
+type Stat struct {
+	Target       string
+	NumRequests  uint64
+	NumDropped   uint64
+	NumDelayed   uint64
+	NumMalformed uint64
+	NumErrors    uint64 // failed to forward request to target
+}
+
+// Proxy forwards HTTP requests to the target endpoint and injects faults at configurable rates.
+type Proxy struct {
+	target string
+	option Option
+	client http.Client
+
+	numRequests  atomic.Uint64
+	numDropped   atomic.Uint64
+	numDelayed   atomic.Uint64
+	numMalformed atomic.Uint64
+	numErrors    atomic.Uint64
+}
+
+// NewProxy creates a proxy to forward requests to the target URL.
+func NewProxy(target string, option Option) *Proxy {
+	return &Proxy{
+		target: target,
+		option: option,
+	}
+}
+
+// Start serves the proxy on a random local port in background, and returns the URL of proxy.
+func (proxy *Proxy) Start() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", errors.WithMessage(err, "Failed to listen")
+	}
+
+	go http.Serve(listener, proxy)
+
+	return "http://" + listener.Addr().String(), nil
+}
+
+// Stat returns the number of requests proxied and faults injected so far.
+func (proxy *Proxy) Stat() Stat {
+	return Stat{
+		Target:       proxy.target,
+		NumRequests:  proxy.numRequests.Load(),
+		NumDropped:   proxy.numDropped.Load(),
+		NumDelayed:   proxy.numDelayed.Load(),
+		NumMalformed: proxy.numMalformed.Load(),
+		NumErrors:    proxy.numErrors.Load(),
+	}
+}
+
+func (proxy *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	proxy.numRequests.Add(1)
+
+	if chance(proxy.option.DropRate) {
+		proxy.numDropped.Add(1)
+		proxy.drop(w)
+		return
+	}
+
+	if proxy.option.Delay > 0 && chance(proxy.option.DelayRate) {
+		proxy.numDelayed.Add(1)
+
+		select {
+		case <-time.After(proxy.option.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	resp, body, err := proxy.forward(r)
+	if err != nil {
+		proxy.numErrors.Add(1)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	// response headers are forwarded, e.g. Content-Encoding if client accepts compression
+	header := w.Header()
+	for key, values := range resp.Header {
+		if !hopHeaders[http.CanonicalHeaderKey(key)] {
+			header[key] = values
+		}
+	}
+
+	if chance(proxy.option.MalformedRate) {
+		proxy.numMalformed.Add(1)
+
+		// malform the decompressed JSON rather than compressed bytes
+		if resp.Header.Get("Content-Encoding") == "gzip" {
+			if decompressed, err := gunzip(body); err == nil {
+				body = decompressed
+				header.Del("Content-Encoding")
+			}
+		}
+
+		body = malform(body)
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	w.Write(body)
+}
+
+// hopHeaders are the headers of a single connection that are not forwarded, along with Content-Length
+// which changes if response malformed.
+var hopHeaders = map[string]bool{
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+	"Content-Length":      true,
+}
+
+// forward forwards the request to target as is, e.g. Accept-Encoding, and returns the response along with
+// the body read as is without decompression.
+func (proxy *Proxy) forward(r *http.Request) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, proxy.target, r.Body)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "Failed to create request")
+	}
+
+	req.Header = r.Header.Clone()
+
+	resp, err := proxy.client.Do(req)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "Failed to forward request")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "Failed to read response")
+	}
+
+	return resp, body, nil
+}
+
+func gunzip(body []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// drop closes the underlying connection without any response.
+func (proxy *Proxy) drop(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+
+	conn.Close()
+}
+
+// malform truncates the JSON response, or returns a non-JSON response if too short.
+func malform(body []byte) []byte {
+	body = bytes.TrimSpace(body)
+	if len(body) < 2 {
+		return []byte("<html>malformed</html>")
+	}
+
+	return body[:1+rand.Intn(len(body)-1)]
+}
+
+func chance(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
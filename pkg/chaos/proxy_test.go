@@ -0,0 +1,102 @@
+package chaos
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boqiu/go-test/pkg/fetch"
+)
+
+const testResponse = `{"jsonrpc":"2.0","id":1,"result":"0x1234"}`
+
+// newTestUpstream serves a JSON-RPC response, which is gzipped if client accepts.
+func newTestUpstream() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			io.WriteString(w, testResponse)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		io.WriteString(writer, testResponse)
+		writer.Close()
+	}))
+}
+
+func startTestProxy(t *testing.T, option Option) string {
+	upstream := newTestUpstream()
+	t.Cleanup(upstream.Close)
+
+	url, err := NewProxy(upstream.URL, option).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return url
+}
+
+func TestProxyCompression(t *testing.T) {
+	url := startTestProxy(t, Option{})
+
+	for _, compression := range []string{"gzip", "none"} {
+		client := fetch.NewRawClient(url, time.Second, fetch.RawOption{Compression: compression})
+
+		result, err := client.Call("cfx_epochNumber")
+		if err != nil {
+			t.Fatalf("Failed to call via proxy with compression %v: %v", compression, err)
+		}
+
+		if string(result) != `"0x1234"` {
+			t.Fatalf("Unexpected result %s with compression %v", result, compression)
+		}
+
+		compressed, decompressed := client.Bytes()
+		if decompressed != uint64(len(testResponse)) || (compression == "gzip") == (compressed == decompressed) {
+			t.Fatalf("Unexpected bytes %v on the wire and %v decompressed with compression %v", compressed, decompressed, compression)
+		}
+	}
+
+	// transparent decompression of net/http, e.g. SDK client
+	resp, err := http.Post(url, "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !resp.Uncompressed || string(body) != testResponse || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Unexpected response %s of transparent decompression", body)
+	}
+}
+
+func TestProxyMalformedCompression(t *testing.T) {
+	url := startTestProxy(t, Option{MalformedRate: 1})
+
+	client := fetch.NewRawClient(url, time.Second, fetch.RawOption{Compression: "gzip"})
+
+	// malformed JSON rather than corrupted gzip stream
+	_, err := client.Call("cfx_epochNumber")
+
+	var syntaxErr *json.SyntaxError
+	if !errors.Is(err, io.ErrUnexpectedEOF) && !errors.As(err, &syntaxErr) {
+		t.Fatalf("Expected malformed JSON error, but got %v", err)
+	}
+
+	if compressed, decompressed := client.Bytes(); compressed != decompressed {
+		t.Fatalf("Expected malformed response uncompressed, but got %v bytes on the wire and %v decompressed", compressed, decompressed)
+	}
+}
@@ -0,0 +1,87 @@
+package collect
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boqiu/go-test/pkg/fetch"
+	"github.com/pkg/errors"
+)
+
+// Timing is the timing information of an epoch fetched.
+type Timing struct {
+	Epoch uint64
+	Fetch time.Duration // time to fetch blocks, receipts and traces
+}
+
+// Collector aggregates custom metrics of epochs during scan in two phases, so that epochs failed and
+// retried later are never aggregated twice. OnEpoch is invoked by concurrent workers out of epoch order to
+// extract the metrics of an epoch, and Commit is invoked sequentially to aggregate the extracted metrics only
+// after all the queries and checks of the epoch succeeded.
+type Collector interface {
+	// OnEpoch extracts metrics of the epoch data, which should not be retained as a whole to save memory.
+	OnEpoch(data *fetch.EpochData, timing Timing) (any, error)
+
+	// Commit aggregates the metrics extracted by OnEpoch of a succeeded epoch.
+	Commit(metrics any)
+
+	// Finalize returns the aggregated result to report in JSON format.
+	Finalize() any
+}
+
+var (
+	mu        sync.Mutex
+	factories = make(map[string]func() Collector)
+)
+
+// Register registers a collector factory by name, which is usually called in init function. Panics if
+// the name is already registered.
+func Register(name string, factory func() Collector) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := factories[name]; ok {
+		panic("collector already registered: " + name)
+	}
+
+	factories[name] = factory
+}
+
+// Names returns the names of all registered collectors in order.
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return namesLocked()
+}
+
+// New creates collectors of the given names in order.
+func New(names []string) ([]Collector, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var collectors []Collector
+	for _, name := range names {
+		factory, ok := factories[name]
+		if !ok {
+			return nil, errors.Errorf("Unknown collector %v, expected one of %v", name, strings.Join(namesLocked(), ", "))
+		}
+
+		collectors = append(collectors, factory())
+	}
+
+	return collectors, nil
+}
+
+func namesLocked() []string {
+	var names []string
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
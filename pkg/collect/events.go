@@ -0,0 +1,81 @@
+package collect
+
+import (
+	"sort"
+
+	"github.com/boqiu/go-test/pkg/fetch"
+)
+
+func init() {
+	Register("events", func() Collector {
+		return &EventCollector{counts: make(map[string]int), top: 20}
+	})
+}
+
+// EventCollector counts logs by event signature, i.e. the first topic.
+type EventCollector struct {
+	counts map[string]int
+	total  int
+	top    int
+}
+
+// eventCounts is the number of logs by event signature of an epoch, along with the total number of logs.
+type eventCounts struct {
+	counts map[string]int
+	total  int
+}
+
+func (c *EventCollector) OnEpoch(data *fetch.EpochData, _ Timing) (any, error) {
+	staged := eventCounts{counts: make(map[string]int)}
+
+	for _, blockReceipts := range data.Receipts {
+		for _, receipt := range blockReceipts {
+			for _, log := range receipt.Logs {
+				if len(log.Topics) > 0 {
+					staged.counts[log.Topics[0].String()]++
+				}
+
+				staged.total++
+			}
+		}
+	}
+
+	return staged, nil
+}
+
+func (c *EventCollector) Commit(metrics any) {
+	staged := metrics.(eventCounts)
+
+	for signature, count := range staged.counts {
+		c.counts[signature] += count
+	}
+
+	c.total += staged.total
+}
+
+// EventCount is the number of logs of an event signature.
+type EventCount struct {
+	Signature string
+	Count     int
+}
+
+func (c *EventCollector) Finalize() any {
+	var events []EventCount
+	for signature, count := range c.counts {
+		events = append(events, EventCount{signature, count})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Count != events[j].Count {
+			return events[i].Count > events[j].Count
+		}
+
+		return events[i].Signature < events[j].Signature
+	})
+
+	return struct {
+		NumLogs   int
+		NumEvents int
+		Top       []EventCount
+	}{c.total, len(events), events[:min(len(events), c.top)]}
+}
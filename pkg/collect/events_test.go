@@ -0,0 +1,69 @@
+package collect
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/fetch"
+)
+
+func testEpochData(topics ...string) *fetch.EpochData {
+	var logs []types.Log
+	for _, topic := range topics {
+		var log types.Log
+		if len(topic) > 0 {
+			log.Topics = []types.Hash{types.Hash(topic)}
+		}
+
+		logs = append(logs, log)
+	}
+
+	return &fetch.EpochData{
+		Receipts: [][]types.TransactionReceipt{{{Logs: logs}}},
+	}
+}
+
+func TestEventCollector(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		epochs    [][]string
+		committed []bool
+		numLogs   int
+		top       []EventCount
+	}{
+		{"empty", nil, nil, 0, nil},
+		{"committed", [][]string{{"0xa", "0xb", ""}, {"0xb"}}, []bool{true, true}, 4, []EventCount{{"0xb", 2}, {"0xa", 1}}},
+		{"failed and retried", [][]string{{"0xa"}, {"0xa"}, {"0xb"}}, []bool{false, true, true}, 2, []EventCount{{"0xa", 1}, {"0xb", 1}}},
+		{"never committed", [][]string{{"0xa"}}, []bool{false}, 0, nil},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			collectors, err := New([]string{"events"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			collector := collectors[0]
+
+			for i, topics := range c.epochs {
+				metrics, err := collector.OnEpoch(testEpochData(topics...), Timing{Epoch: uint64(i)})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if c.committed[i] {
+					collector.Commit(metrics)
+				}
+			}
+
+			result := reflect.ValueOf(collector.Finalize())
+			if numLogs := result.FieldByName("NumLogs").Interface(); numLogs != c.numLogs {
+				t.Errorf("Expected %v logs, got %v", c.numLogs, numLogs)
+			}
+
+			if top := result.FieldByName("Top").Interface().([]EventCount); len(top) != len(c.top) || (len(top) > 0 && !reflect.DeepEqual(top, c.top)) {
+				t.Errorf("Expected top events %v, got %v", c.top, top)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Difference is a meaningful difference between two JSON values at the path.
+type Difference struct {
+	Path string
+	A    string // compact JSON of value A, empty if absent
+	B    string // compact JSON of value B, empty if absent
+}
+
+// Normalize decodes the raw JSON and normalizes it to eliminate formatting noise, so that only
+// meaningful differences are reported:
+//   - hex strings are lowercased.
+//   - null, empty array and empty object are equivalent, and object fields of such values are removed.
+//
+// Object keys are compared regardless of order.
+func Normalize(raw json.RawMessage) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, errors.WithMessage(err, "Failed to decode JSON")
+	}
+
+	return normalize(v), nil
+}
+
+func normalize(v any) any {
+	switch value := v.(type) {
+	case string:
+		if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+			return strings.ToLower(value)
+		}
+	case []any:
+		if len(value) == 0 {
+			return nil
+		}
+
+		for i := range value {
+			value[i] = normalize(value[i])
+		}
+	case map[string]any:
+		for key, field := range value {
+			if value[key] = normalize(field); value[key] == nil {
+				delete(value, key)
+			}
+		}
+
+		if len(value) == 0 {
+			return nil
+		}
+	}
+
+	return v
+}
+
+// Compare normalizes both raw JSON values and returns the meaningful differences in path order.
+func Compare(a, b json.RawMessage) ([]Difference, error) {
+	va, err := Normalize(a)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to normalize A")
+	}
+
+	vb, err := Normalize(b)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to normalize B")
+	}
+
+	var diffs []Difference
+	compare(va, vb, "", &diffs)
+
+	return diffs, nil
+}
+
+func compare(a, b any, path string, diffs *[]Difference) {
+	switch va := a.(type) {
+	case map[string]any:
+		if vb, ok := b.(map[string]any); ok {
+			keys := make(map[string]bool)
+			for key := range va {
+				keys[key] = true
+			}
+			for key := range vb {
+				keys[key] = true
+			}
+
+			sorted := make([]string, 0, len(keys))
+			for key := range keys {
+				sorted = append(sorted, key)
+			}
+			sort.Strings(sorted)
+
+			for _, key := range sorted {
+				compare(va[key], vb[key], join(path, key), diffs)
+			}
+
+			return
+		}
+	case []any:
+		if vb, ok := b.([]any); ok && len(va) == len(vb) {
+			for i := range va {
+				compare(va[i], vb[i], fmt.Sprintf("%v[%v]", path, i), diffs)
+			}
+
+			return
+		}
+	}
+
+	ja, jb := encode(a), encode(b)
+	if ja != jb {
+		*diffs = append(*diffs, Difference{path, ja, jb})
+	}
+}
+
+func join(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// encode returns the compact JSON of value, or empty if absent.
+func encode(v any) string {
+	if v == nil {
+		return ""
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
+
+// Canonicalize re-serializes the raw JSON in a stable way, i.e. compact with sorted object keys, while
+// keeping values byte-for-byte, e.g. hex case and number formats.
+func Canonicalize(raw json.RawMessage) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, errors.WithMessage(err, "Failed to decode JSON")
+	}
+
+	return json.Marshal(v)
+}
+
+// CompareBytes compares the stably re-serialized raw JSON values byte for byte, and returns the difference
+// around the first mismatched byte if any.
+func CompareBytes(a, b json.RawMessage) ([]Difference, error) {
+	ca, err := Canonicalize(a)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to canonicalize A")
+	}
+
+	cb, err := Canonicalize(b)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to canonicalize B")
+	}
+
+	if bytes.Equal(ca, cb) {
+		return nil, nil
+	}
+
+	offset := 0
+	for offset < len(ca) && offset < len(cb) && ca[offset] == cb[offset] {
+		offset++
+	}
+
+	return []Difference{{
+		Path: fmt.Sprintf("byte %v", offset),
+		A:    excerpt(ca, offset),
+		B:    excerpt(cb, offset),
+	}}, nil
+}
+
+// excerpt returns the bytes around offset for context.
+func excerpt(data []byte, offset int) string {
+	const context = 32
+
+	from := max(offset-context, 0)
+	to := min(offset+context, len(data))
+
+	return string(data[from:to])
+}
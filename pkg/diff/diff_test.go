@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		raw      string
+		expected string
+	}{
+		// hex strings are lowercased, but not other strings
+		{`"0xABCdef"`, `"0xabcdef"`},
+		{`"0XAB"`, `"0xab"`},
+		{`"ABC"`, `"ABC"`},
+		{`"cfx:AAM"`, `"cfx:AAM"`},
+
+		// null, empty array and empty object are equivalent
+		{`null`, `null`},
+		{`[]`, `null`},
+		{`{}`, `null`},
+		{`{"a":null,"b":[],"c":{},"d":{"e":[]}}`, `null`},
+		{`{"a":null,"b":1}`, `{"b":1}`},
+		{`[null,[],{}]`, `[null,null,null]`},
+
+		// numbers are kept exact beyond float64 precision
+		{`12345678901234567891`, `12345678901234567891`},
+		{`1.50`, `1.50`},
+
+		// nested
+		{`{"b":[{"hash":"0xAB","logs":[]}],"a":true}`, `{"a":true,"b":[{"hash":"0xab"}]}`},
+	} {
+		v, err := Normalize(json.RawMessage(tc.raw))
+		if err != nil {
+			t.Errorf("Failed to normalize %v: %v", tc.raw, err)
+			continue
+		}
+
+		if actual, _ := json.Marshal(v); string(actual) != tc.expected {
+			t.Errorf("Expected %v normalized to %v, but got %s", tc.raw, tc.expected, actual)
+		}
+	}
+
+	if _, err := Normalize(json.RawMessage(`{"a":`)); err == nil {
+		t.Error("Expected error to normalize malformed JSON")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected []Difference
+	}{
+		// formatting noise only
+		{`{"a":1,"b":"0xAB"}`, `{"b":"0xab","a":1}`, nil},
+		{`{"a":null,"b":[1]}`, `{"b":[1],"c":[]}`, nil},
+		{`null`, `{}`, nil},
+
+		// meaningful differences in path order
+		{`{"b":2,"a":1}`, `{"a":3,"b":2}`, []Difference{{"a", "1", "3"}}},
+		{`{"a":{"b":[1,2]}}`, `{"a":{"b":[1,3]}}`, []Difference{{"a.b[1]", "2", "3"}}},
+		{`{"a":[1,2]}`, `{"a":[1]}`, []Difference{{"a", "[1,2]", "[1]"}}},
+		{`{"a":1}`, `{"b":1}`, []Difference{{"a", "1", ""}, {"b", "", "1"}}},
+		{`{"a":"0x1"}`, `{"a":1}`, []Difference{{"a", `"0x1"`, "1"}}},
+		{`12345678901234567891`, `12345678901234567890`, []Difference{{"", "12345678901234567891", "12345678901234567890"}}},
+		{`1.0`, `1`, []Difference{{"", "1.0", "1"}}},
+	} {
+		diffs, err := Compare(json.RawMessage(tc.a), json.RawMessage(tc.b))
+		if err != nil {
+			t.Errorf("Failed to compare %v with %v: %v", tc.a, tc.b, err)
+		} else if !reflect.DeepEqual(diffs, tc.expected) {
+			t.Errorf("Expected differences %v of %v and %v, but got %v", tc.expected, tc.a, tc.b, diffs)
+		}
+	}
+}
+
+func TestCompareBytes(t *testing.T) {
+	diffs, err := CompareBytes(json.RawMessage(`{"b":"0xAB", "a":1}`), json.RawMessage(`{"a":1,"b":"0xAB"}`))
+	if err != nil || diffs != nil {
+		t.Fatalf("Expected no difference regardless of key order and whitespace, but got %v, %v", diffs, err)
+	}
+
+	diffs, err = CompareBytes(json.RawMessage(`{"a":"0xAB"}`), json.RawMessage(`{"a":"0xab"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diffs) != 1 || diffs[0].Path != "byte 8" || diffs[0].A != `{"a":"0xAB"}` || diffs[0].B != `{"a":"0xab"}` {
+		t.Fatalf("Unexpected differences %v of hex case", diffs)
+	}
+}
@@ -0,0 +1,235 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	pqgzip "github.com/parquet-go/parquet-go/compress/gzip"
+	pqzstd "github.com/parquet-go/parquet-go/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// ColumnType is the type of Parquet column.
+type ColumnType int
+
+const (
+	Bool   ColumnType = iota
+	Int64             // INT64
+	String            // BYTE_ARRAY annotated as STRING
+)
+
+// Column is a flat and nullable Parquet column.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// parquetRowGroupSize is the number of rows buffered in memory before written as a row group.
+const parquetRowGroupSize = 65536
+
+// ParquetWriter writes rows of flat schema to Parquet files of <prefix>-<seq>.parquet in directory, and rotates
+// to a new file once the current one exceeds the maximum file size after a row group is written. All columns
+// are optional, and optionally compressed by gzip or zstd.
+type ParquetWriter struct {
+	dir     string
+	prefix  string
+	columns []Column
+	schema  *parquet.Schema
+	codec   compress.Codec
+
+	option Option
+
+	file    *os.File
+	counter *countingWriter // bytes written to the current file
+	writer  *parquet.Writer
+	rows    []parquet.Row // buffered rows of the current row group
+
+	Files   []string
+	NumRows int64
+	Bytes   int64 // bytes written to files
+}
+
+// parquetSchemaOf returns the schema of columns in order, since the fields of parquet.Group are sorted by name.
+func parquetSchemaOf(columns []Column) (*parquet.Schema, error) {
+	var fields []reflect.StructField
+
+	for i, column := range columns {
+		var typ reflect.Type
+		switch column.Type {
+		case Bool:
+			typ = reflect.TypeOf(false)
+		case Int64:
+			typ = reflect.TypeOf(int64(0))
+		case String:
+			typ = reflect.TypeOf("")
+		default:
+			return nil, errors.Errorf("Invalid type %v of column %v", column.Type, column.Name)
+		}
+
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("Column%v", i),
+			Type: typ,
+			Tag:  reflect.StructTag(fmt.Sprintf(`parquet:"%v,optional"`, column.Name)),
+		})
+	}
+
+	return parquet.SchemaOf(reflect.New(reflect.StructOf(fields)).Elem().Interface()), nil
+}
+
+// parquetCodecOf returns the Parquet compression codec by option, or nil if compression disabled.
+func parquetCodecOf(option Option) compress.Codec {
+	switch option.Compression {
+	case "gzip":
+		return &pqgzip.Codec{Level: option.Level}
+	case "zstd":
+		return &pqzstd.Codec{Level: zstd.EncoderLevelFromZstd(option.Level)}
+	default:
+		return nil
+	}
+}
+
+// NewParquetWriter creates the directory if not exists, and validates the compression option.
+func NewParquetWriter(dir, prefix string, columns []Column, option Option) (*ParquetWriter, error) {
+	if err := setup(dir, option); err != nil {
+		return nil, err
+	}
+
+	schema, err := parquetSchemaOf(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParquetWriter{
+		dir:     dir,
+		prefix:  prefix,
+		columns: columns,
+		schema:  schema,
+		codec:   parquetCodecOf(option),
+		option:  option,
+	}, nil
+}
+
+// WriteRow writes a row of column values, in which value is int64, string, bool or nil for null.
+func (w *ParquetWriter) WriteRow(values ...any) error {
+	if len(values) != len(w.columns) {
+		return errors.Errorf("Expected %v values but got %v", len(w.columns), len(values))
+	}
+
+	row := make(parquet.Row, len(values))
+
+	for i, v := range values {
+		if v == nil {
+			row[i] = parquet.NullValue().Level(0, 0, i)
+			continue
+		}
+
+		var ok bool
+		switch w.columns[i].Type {
+		case Bool:
+			_, ok = v.(bool)
+		case Int64:
+			_, ok = v.(int64)
+		case String:
+			_, ok = v.(string)
+		}
+
+		if !ok {
+			return errors.Errorf("Invalid value type %T of column %v", v, w.columns[i].Name)
+		}
+
+		row[i] = parquet.ValueOf(v).Level(0, 1, i)
+	}
+
+	w.rows = append(w.rows, row)
+
+	if len(w.rows) >= parquetRowGroupSize {
+		return w.flushRowGroup()
+	}
+
+	return nil
+}
+
+// open opens the next file to write.
+func (w *ParquetWriter) open() error {
+	file, err := os.Create(filepath.Join(w.dir, fmt.Sprintf("%v-%06d.parquet", w.prefix, len(w.Files)+1)))
+	if err != nil {
+		return errors.WithMessage(err, "Failed to create file")
+	}
+
+	options := []parquet.WriterOption{w.schema, parquet.CreatedBy("go-test", "", "")}
+	if w.codec != nil {
+		options = append(options, parquet.Compression(w.codec))
+	}
+
+	w.file = file
+	w.counter = &countingWriter{Writer: file}
+	w.writer = parquet.NewWriter(w.counter, options...)
+	w.Files = append(w.Files, file.Name())
+
+	return nil
+}
+
+// flushRowGroup writes the buffered rows as a row group, and rotates file if exceeds the maximum file size.
+func (w *ParquetWriter) flushRowGroup() error {
+	if len(w.rows) == 0 {
+		return nil
+	}
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.writer.WriteRows(w.rows); err != nil {
+		return errors.WithMessage(err, "Failed to write rows")
+	}
+
+	if err := w.writer.Flush(); err != nil {
+		return errors.WithMessage(err, "Failed to write row group")
+	}
+
+	w.NumRows += int64(len(w.rows))
+	w.rows = w.rows[:0]
+
+	if w.option.MaxFileSize > 0 && w.counter.n >= w.option.MaxFileSize {
+		return w.closeFile()
+	}
+
+	return nil
+}
+
+// closeFile writes the file metadata and closes the current file if any.
+func (w *ParquetWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.writer.Close()
+	if err != nil {
+		err = errors.WithMessage(err, "Failed to write file metadata")
+	}
+
+	if closeErr := w.file.Close(); err == nil && closeErr != nil {
+		err = errors.WithMessage(closeErr, "Failed to close file")
+	}
+
+	w.Bytes += w.counter.n
+	w.file = nil
+
+	return err
+}
+
+// Close writes the buffered rows and closes the current file.
+func (w *ParquetWriter) Close() error {
+	if err := w.flushRowGroup(); err != nil {
+		return err
+	}
+
+	return w.closeFile()
+}
@@ -0,0 +1,233 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// parquetCodecs are the codecs in file metadata by compression option.
+var parquetCodecs = map[string]format.CompressionCodec{
+	"none": format.Uncompressed,
+	"gzip": format.Gzip,
+	"zstd": format.Zstd,
+}
+
+func isStringType(logical format.LogicalTypeValue) bool {
+	_, ok := logical.(*format.StringType)
+	return ok
+}
+
+// readParquet reads rows back from file by a full Parquet implementation, and checks the schema and codec.
+func readParquet(t *testing.T, file string, columns []Column, compression string) [][]any {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pf, err := parquet.OpenFile(f, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// columns in order of definition
+	fields := pf.Schema().Fields()
+	if len(fields) != len(columns) {
+		t.Fatalf("Expected %v columns, got %v", len(columns), len(fields))
+	}
+
+	for i, field := range fields {
+		if field.Name() != columns[i].Name || !field.Optional() {
+			t.Fatalf("Expected optional column %v at %v, got %v", columns[i].Name, i, field.Name())
+		}
+
+		if logical := field.Type().LogicalType(); columns[i].Type == String && (logical == nil || !isStringType(logical.Value)) {
+			t.Fatalf("Expected column %v annotated as string", field.Name())
+		}
+	}
+
+	for _, group := range pf.Metadata().RowGroups {
+		for _, chunk := range group.Columns {
+			if chunk.MetaData.Codec != parquetCodecs[compression] {
+				t.Fatalf("Expected codec %v, got %v", parquetCodecs[compression], chunk.MetaData.Codec)
+			}
+		}
+	}
+
+	reader := parquet.NewReader(pf)
+	defer reader.Close()
+
+	var rows [][]any
+	buf := make([]parquet.Row, 100)
+
+	for {
+		n, err := reader.ReadRows(buf)
+
+		for _, row := range buf[:n] {
+			values := make([]any, len(columns))
+			for _, v := range row {
+				switch {
+				case v.IsNull():
+				case columns[v.Column()].Type == Bool:
+					values[v.Column()] = v.Boolean()
+				case columns[v.Column()].Type == Int64:
+					values[v.Column()] = v.Int64()
+				default:
+					values[v.Column()] = string(v.ByteArray())
+				}
+			}
+
+			rows = append(rows, values)
+		}
+
+		if err == io.EOF {
+			return rows
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+var testColumns = []Column{
+	{"epoch", Int64},
+	{"hash", String},
+	{"value", Int64},
+	{"success", Bool},
+}
+
+// testRows generates rows with nulls, negative and extreme values, empty and unicode strings.
+func testRows(n int) [][]any {
+	rows := make([][]any, n)
+
+	for i := range rows {
+		var value any
+		switch i % 4 {
+		case 0:
+			value = int64(-i)
+		case 1:
+			value = int64(math.MaxInt64 - i)
+		case 2:
+			value = int64(math.MinInt64 + i)
+		}
+
+		var hash any = fmt.Sprintf("0x%064x", i)
+		switch i % 5 {
+		case 1:
+			hash = nil
+		case 2:
+			hash = ""
+		case 3:
+			hash = "交易"
+		}
+
+		var success any = i%3 == 0
+		if i%7 == 0 {
+			success = nil
+		}
+
+		rows[i] = []any{int64(i), hash, value, success}
+	}
+
+	return rows
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	for _, compression := range []string{"none", "gzip", "zstd"} {
+		for _, numRows := range []int{1, 9, 1000, parquetRowGroupSize + 100} {
+			t.Run(fmt.Sprintf("%v-%v", compression, numRows), func(t *testing.T) {
+				writer, err := NewParquetWriter(t.TempDir(), "txs", testColumns, Option{Compression: compression, Level: 6})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				expected := testRows(numRows)
+				for _, row := range expected {
+					if err = writer.WriteRow(row...); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				if err = writer.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				if writer.NumRows != int64(numRows) || len(writer.Files) != 1 {
+					t.Fatalf("Expected %v rows in 1 file, but got %v rows in %v files", numRows, writer.NumRows, len(writer.Files))
+				}
+
+				rows := readParquet(t, writer.Files[0], testColumns, compression)
+				if len(rows) != len(expected) {
+					t.Fatalf("Expected %v rows, but got %v", len(expected), len(rows))
+				}
+
+				for i := range expected {
+					if !reflect.DeepEqual(rows[i], expected[i]) {
+						t.Fatalf("Row %v mismatch, expected %v, got %v", i, expected[i], rows[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestParquetRotation(t *testing.T) {
+	writer, err := NewParquetWriter(t.TempDir(), "txs", testColumns, Option{Compression: "none", MaxFileSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := testRows(parquetRowGroupSize * 2)
+	for _, row := range expected {
+		if err = writer.WriteRow(row...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(writer.Files) != 2 {
+		t.Fatalf("Expected a file per row group, but got %v files", len(writer.Files))
+	}
+
+	var rows [][]any
+	for _, file := range writer.Files {
+		rows = append(rows, readParquet(t, file, testColumns, "none")...)
+	}
+
+	if !reflect.DeepEqual(rows, expected) {
+		t.Fatal("Rows mismatch across rotated files")
+	}
+}
+
+func TestParquetInvalidRow(t *testing.T) {
+	writer, err := NewParquetWriter(t.TempDir(), "txs", testColumns, Option{Compression: "none"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, row := range [][]any{
+		{int64(1), "0x", int64(1)},               // missing column
+		{1, "0x", int64(1), true},                // int instead of int64
+		{int64(1), []byte("0x"), int64(1), true}, // bytes instead of string
+	} {
+		if err = writer.WriteRow(row...); err == nil {
+			t.Errorf("Expected error of row %v", row)
+		}
+	}
+}
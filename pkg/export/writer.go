@@ -0,0 +1,188 @@
+package export
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// Option is the compression and rotation option of exported files.
+type Option struct {
+	Compression string // "gzip", "zstd" or "none"
+	Level       int    // compression level, from 1 for best speed to 9 for gzip or 22 for zstd for best compression
+	MaxFileSize int64  // approximate size in bytes on disk to rotate files, 0 for no rotation
+}
+
+// Writer writes records to files of <prefix>-<seq>.jsonl[.gz|.zst] in directory, and rotates to a new file once
+// the current one exceeds the maximum file size. Note, records are never split across files.
+type Writer struct {
+	dir    string
+	prefix string
+	option Option
+
+	file       *os.File
+	compressed *countingWriter // bytes written to file
+	compressor io.WriteCloser  // nil if compression disabled
+	writer     io.Writer
+
+	Files            []string
+	Bytes            int64 // bytes before compression
+	CompressedBytes  int64 // bytes written to files
+	compressedClosed int64 // bytes of closed files
+}
+
+type countingWriter struct {
+	io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// setup validates the compression option and creates the directory if not exists.
+func setup(dir string, option Option) error {
+	switch option.Compression {
+	case "gzip":
+		if option.Level < gzip.HuffmanOnly || option.Level > gzip.BestCompression {
+			return errors.Errorf("Invalid gzip compression level %v", option.Level)
+		}
+	case "zstd":
+		if option.Level < 1 || option.Level > 22 {
+			return errors.Errorf("Invalid zstd compression level %v", option.Level)
+		}
+	case "none":
+	default:
+		return errors.Errorf("Invalid compression %v, expected gzip, zstd or none", option.Compression)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithMessage(err, "Failed to create directory")
+	}
+
+	return nil
+}
+
+// newCompressor returns the writer to compress data into w by option, or nil if compression disabled. Note,
+// the option should be validated in advance.
+func newCompressor(w io.Writer, option Option) (io.WriteCloser, error) {
+	switch option.Compression {
+	case "gzip":
+		return gzip.NewWriterLevel(w, option.Level)
+	case "zstd":
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(option.Level)))
+	default:
+		return nil, nil
+	}
+}
+
+// fileExt returns the file extension of compression.
+func fileExt(compression string) string {
+	switch compression {
+	case "gzip":
+		return ".gz"
+	case "zstd":
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// NewWriter creates the directory if not exists, and validates the compression option.
+func NewWriter(dir, prefix string, option Option) (*Writer, error) {
+	if err := setup(dir, option); err != nil {
+		return nil, err
+	}
+
+	return &Writer{dir: dir, prefix: prefix, option: option}, nil
+}
+
+// open opens the next file to write.
+func (w *Writer) open() error {
+	name := fmt.Sprintf("%v-%06d.jsonl%v", w.prefix, len(w.Files)+1, fileExt(w.option.Compression))
+
+	file, err := os.Create(filepath.Join(w.dir, name))
+	if err != nil {
+		return errors.WithMessage(err, "Failed to create file")
+	}
+
+	w.file = file
+	w.compressed = &countingWriter{Writer: file}
+	w.writer = w.compressed
+	w.Files = append(w.Files, file.Name())
+
+	compressor, err := newCompressor(w.compressed, w.option)
+	if err != nil {
+		file.Close()
+		return errors.WithMessage(err, "Failed to create compressor")
+	}
+
+	if compressor != nil {
+		w.compressor = compressor
+		w.writer = compressor
+	}
+
+	return nil
+}
+
+// closeFile flushes and closes the current file if any.
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+
+	var err error
+	if w.compressor != nil {
+		err = w.compressor.Close()
+		w.compressor = nil
+	}
+
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	w.compressedClosed += w.compressed.n
+	w.CompressedBytes = w.compressedClosed
+	w.file = nil
+
+	if err != nil {
+		return errors.WithMessage(err, "Failed to close file")
+	}
+
+	return nil
+}
+
+// WriteRecord writes a record followed by newline, and rotates file if exceeds the maximum file size.
+// Since compressors buffer data internally, the file size is checked approximately.
+func (w *Writer) WriteRecord(record []byte) error {
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.writer.Write(append(record, '\n')); err != nil {
+		return errors.WithMessage(err, "Failed to write record")
+	}
+
+	w.Bytes += int64(len(record)) + 1
+	w.CompressedBytes = w.compressedClosed + w.compressed.n
+
+	if w.option.MaxFileSize > 0 && w.compressed.n >= w.option.MaxFileSize {
+		return w.closeFile()
+	}
+
+	return nil
+}
+
+// Close closes the current file.
+func (w *Writer) Close() error {
+	return w.closeFile()
+}
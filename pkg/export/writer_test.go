@@ -0,0 +1,108 @@
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// readRecords reads records from files written by Writer.
+func readRecords(t *testing.T, files []string, compression string) []string {
+	var records []string
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		var reader io.Reader = f
+		switch compression {
+		case "gzip":
+			if reader, err = gzip.NewReader(f); err != nil {
+				t.Fatal(err)
+			}
+		case "zstd":
+			decoder, err := zstd.NewReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer decoder.Close()
+			reader = decoder
+		}
+
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			records = append(records, scanner.Text())
+		}
+
+		if err = scanner.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return records
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	for _, compression := range []string{"none", "gzip", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			writer, err := NewWriter(t.TempDir(), "epochs", Option{Compression: compression, Level: 6, MaxFileSize: 64 << 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// incompressible enough to rotate files, since compressors buffer data internally
+			random := rand.New(rand.NewPCG(1, 2))
+
+			var expected []string
+			for i := 0; i < 50000; i++ {
+				record := fmt.Sprintf(`{"epoch":%v,"hash":"%x"}`, i, random.Uint64())
+				expected = append(expected, record)
+
+				if err = writer.WriteRecord([]byte(record)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err = writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(writer.Files) < 2 {
+				t.Fatalf("Expected files rotated, but got %v files", len(writer.Files))
+			}
+
+			records := readRecords(t, writer.Files, compression)
+			if len(records) != len(expected) {
+				t.Fatalf("Expected %v records, but got %v", len(expected), len(records))
+			}
+
+			for i := range expected {
+				if records[i] != expected[i] {
+					t.Fatalf("Record %v mismatch, expected %v, got %v", i, expected[i], records[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriterInvalidOption(t *testing.T) {
+	for _, option := range []Option{
+		{Compression: "lz4", Level: 1},
+		{Compression: "gzip", Level: 10},
+		{Compression: "zstd", Level: 0},
+		{Compression: "zstd", Level: 23},
+	} {
+		if _, err := NewWriter(t.TempDir(), "epochs", option); err == nil {
+			t.Errorf("Expected error of option %+v", option)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+// Package expr implements a small expression language to evaluate assertions against numeric variables,
+// e.g. "epoch.numBlocks >= 1 && epoch.latency < 2s", in which durations are evaluated in nanoseconds.
+package expr
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Vars resolves variables by name, and returns false if not found.
+type Vars func(name string) (float64, bool)
+
+// Expr is a compiled expression.
+type Expr struct {
+	source string
+	vars   []string                     // variables referenced
+	eval   func(vars Vars) (any, error) // evaluates to float64 or bool
+}
+
+// String returns the source of expression.
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Vars returns the names of variables referenced in expression.
+func (e *Expr) Vars() []string {
+	return e.vars
+}
+
+// Eval evaluates the expression to a boolean value.
+func (e *Expr) Eval(vars Vars) (bool, error) {
+	value, err := e.eval(vars)
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return false, errors.Errorf("Expression evaluated to number %v rather than boolean", value)
+	}
+
+	return result, nil
+}
+
+// Parse compiles the expression.
+func Parse(source string) (*Expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+
+	eval, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, errors.Errorf("Unexpected token %v", p.tokens[p.pos].text)
+	}
+
+	return &Expr{source, p.vars, eval}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value float64
+}
+
+// operators ordered by length so that the longest one is matched first
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "(", ")"}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c):
+			// number, or duration with unit suffix, e.g. 1.5s or 1m30s
+			j := i
+			for j < len(source) && (unicode.IsDigit(rune(source[j])) || source[j] == '.') {
+				j++
+			}
+
+			if j < len(source) && (unicode.IsLetter(rune(source[j])) || strings.HasPrefix(source[j:], "µ")) {
+				for j < len(source) && (unicode.IsLetter(rune(source[j])) || unicode.IsDigit(rune(source[j])) || source[j] == '.' || source[j] >= 0x80) {
+					j++
+				}
+
+				d, err := time.ParseDuration(source[i:j])
+				if err != nil {
+					return nil, errors.WithMessagef(err, "Invalid duration %v", source[i:j])
+				}
+
+				tokens = append(tokens, token{tokenNumber, source[i:j], float64(d)})
+			} else {
+				v, err := strconv.ParseFloat(source[i:j], 64)
+				if err != nil {
+					return nil, errors.WithMessagef(err, "Invalid number %v", source[i:j])
+				}
+
+				tokens = append(tokens, token{tokenNumber, source[i:j], v})
+			}
+
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(source) && (unicode.IsLetter(rune(source[j])) || unicode.IsDigit(rune(source[j])) || source[j] == '_' || source[j] == '.') {
+				j++
+			}
+
+			tokens = append(tokens, token{kind: tokenIdent, text: source[i:j]})
+			i = j
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+
+			if !matched {
+				return nil, errors.Errorf("Unexpected character %q at %v", c, i)
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+type evalFunc = func(vars Vars) (any, error)
+
+type parser struct {
+	tokens []token
+	pos    int
+	vars   []string
+}
+
+// accept consumes the next token if it is one of the given operators.
+func (p *parser) accept(ops ...string) (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOp {
+		return "", false
+	}
+
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			p.pos++
+			return op, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *parser) parseOr() (evalFunc, error) {
+	return p.parseLogical("||", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (evalFunc, error) {
+	return p.parseLogical("&&", p.parseComparison)
+}
+
+// parseLogical parses left associative logical operators with short circuit.
+func (p *parser) parseLogical(op string, next func() (evalFunc, error)) (evalFunc, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept(op); !ok {
+			return left, nil
+		}
+
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(vars Vars) (any, error) {
+			lv, err := evalBool(l, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			// short circuit
+			if (op == "&&" && !lv) || (op == "||" && lv) {
+				return lv, nil
+			}
+
+			return evalBool(right, vars)
+		}
+	}
+}
+
+func (p *parser) parseComparison() (evalFunc, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return left, nil
+	}
+
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(vars Vars) (any, error) {
+		lv, rv, err := evalNumbers(left, right, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case "==":
+			return lv == rv, nil
+		case "!=":
+			return lv != rv, nil
+		case "<=":
+			return lv <= rv, nil
+		case ">=":
+			return lv >= rv, nil
+		case "<":
+			return lv < rv, nil
+		default:
+			return lv > rv, nil
+		}
+	}, nil
+}
+
+func (p *parser) parseAdditive() (evalFunc, error) {
+	return p.parseArithmetic([]string{"+", "-"}, p.parseMultiplicative)
+}
+
+func (p *parser) parseMultiplicative() (evalFunc, error) {
+	return p.parseArithmetic([]string{"*", "/"}, p.parseUnary)
+}
+
+// parseArithmetic parses left associative arithmetic operators.
+func (p *parser) parseArithmetic(ops []string, next func() (evalFunc, error)) (evalFunc, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(vars Vars) (any, error) {
+			lv, rv, err := evalNumbers(l, right, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			switch op {
+			case "+":
+				return lv + rv, nil
+			case "-":
+				return lv - rv, nil
+			case "*":
+				return lv * rv, nil
+			default:
+				if rv == 0 {
+					return nil, errors.New("Division by zero")
+				}
+
+				return lv / rv, nil
+			}
+		}
+	}
+}
+
+func (p *parser) parseUnary() (evalFunc, error) {
+	op, ok := p.accept("!", "-")
+	if !ok {
+		return p.parsePrimary()
+	}
+
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	if op == "!" {
+		return func(vars Vars) (any, error) {
+			v, err := evalBool(operand, vars)
+			return !v, err
+		}, nil
+	}
+
+	return func(vars Vars) (any, error) {
+		v, err := evalNumber(operand, vars)
+		return -v, err
+	}, nil
+}
+
+func (p *parser) parsePrimary() (evalFunc, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("Unexpected end of expression")
+	}
+
+	if _, ok := p.accept("("); ok {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok = p.accept(")"); !ok {
+			return nil, errors.New("Missing closing parenthesis")
+		}
+
+		return inner, nil
+	}
+
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch t.kind {
+	case tokenNumber:
+		return func(Vars) (any, error) { return t.value, nil }, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return func(Vars) (any, error) { return true, nil }, nil
+		case "false":
+			return func(Vars) (any, error) { return false, nil }, nil
+		}
+
+		p.vars = append(p.vars, t.text)
+
+		return func(vars Vars) (any, error) {
+			v, ok := vars(t.text)
+			if !ok {
+				return nil, errors.Errorf("Unknown variable %v", t.text)
+			}
+
+			return v, nil
+		}, nil
+	default:
+		return nil, errors.Errorf("Unexpected token %v", t.text)
+	}
+}
+
+func evalBool(eval evalFunc, vars Vars) (bool, error) {
+	v, err := eval(vars)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		return false, errors.Errorf("Expected boolean rather than number %v", v)
+	}
+
+	return b, nil
+}
+
+func evalNumber(eval evalFunc, vars Vars) (float64, error) {
+	v, err := eval(vars)
+	if err != nil {
+		return 0, err
+	}
+
+	f, ok := v.(float64)
+	if !ok {
+		return 0, errors.Errorf("Expected number rather than boolean %v", v)
+	}
+
+	return f, nil
+}
+
+func evalNumbers(left, right evalFunc, vars Vars) (float64, float64, error) {
+	lv, err := evalNumber(left, vars)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rv, err := evalNumber(right, vars)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return lv, rv, nil
+}
@@ -0,0 +1,127 @@
+package expr
+
+import (
+	"testing"
+	"time"
+)
+
+var testVars = Vars(func(name string) (float64, bool) {
+	switch name {
+	case "a":
+		return 2, true
+	case "b":
+		return 3, true
+	case "epoch.latency":
+		return float64(1500 * time.Millisecond), true
+	}
+
+	return 0, false
+})
+
+func TestEval(t *testing.T) {
+	for _, tc := range []struct {
+		source   string
+		expected bool
+	}{
+		// precedence
+		{"1 + 2 * 3 == 7", true},
+		{"(1 + 2) * 3 == 9", true},
+		{"10 - 4 - 3 == 3", true},
+		{"12 / 3 / 2 == 2", true},
+		{"-a * b == -6", true},
+		{"--a == 2", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"!false && !(1 > 2)", true},
+		{"!true || true", true},
+		{"a + 1 >= b", true},
+		{"a * b != 6", false},
+		{"a <= 2 && b > 2.5", true},
+
+		// units
+		{"epoch.latency > 1s", true},
+		{"epoch.latency < 2s", true},
+		{"epoch.latency == 1500ms", true},
+		{"epoch.latency == 1s500ms", true},
+		{"epoch.latency == 1.5s", true},
+		{"1m30s == 90s", true},
+		{"1µs == 1000ns", true},
+		{"1us == 1000", true},
+		{"2h == 120m", true},
+
+		// short circuit skips errors of the right operand
+		{"false && unknown > 0", false},
+		{"true || 1 / 0 > 0", true},
+	} {
+		e, err := Parse(tc.source)
+		if err != nil {
+			t.Errorf("Failed to parse %v: %v", tc.source, err)
+			continue
+		}
+
+		actual, err := e.Eval(testVars)
+		if err != nil {
+			t.Errorf("Failed to evaluate %v: %v", tc.source, err)
+		} else if actual != tc.expected {
+			t.Errorf("Expected %v of %v, but got %v", tc.expected, tc.source, actual)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, source := range []string{
+		"",
+		"a <",
+		"(a > 1",
+		"a > 1)",
+		"a > 1 b",
+		"a == == 1",
+		"a # 1",
+		"1xs > 0",
+		"1..2 > 0",
+		"a < b == true", // comparisons are not chained
+	} {
+		if _, err := Parse(source); err == nil {
+			t.Errorf("Expected error to parse %q", source)
+		}
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	for _, source := range []string{
+		"a + 1",        // number rather than boolean
+		"unknown > 0",  // unknown variable
+		"a / 0 > 1",    // division by zero
+		"a && true",    // number in logical operator
+		"!a",           // number in negation
+		"true + 1 > 0", // boolean in arithmetic
+		"-true",        // boolean in negation
+		"true == true", // boolean in comparison
+	} {
+		e, err := Parse(source)
+		if err != nil {
+			t.Errorf("Failed to parse %v: %v", source, err)
+			continue
+		}
+
+		if _, err = e.Eval(testVars); err == nil {
+			t.Errorf("Expected error to evaluate %v", source)
+		}
+	}
+}
+
+func TestVars(t *testing.T) {
+	e, err := Parse("a > 1 && (epoch.latency < 2s || b_2 == 0) && true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars := e.Vars()
+	if len(vars) != 3 || vars[0] != "a" || vars[1] != "epoch.latency" || vars[2] != "b_2" {
+		t.Fatalf("Unexpected variables %v", vars)
+	}
+
+	if e.String() != "a > 1 && (epoch.latency < 2s || b_2 == 0) && true" {
+		t.Fatalf("Unexpected source %v", e.String())
+	}
+}
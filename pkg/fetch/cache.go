@@ -0,0 +1,113 @@
+package fetch
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+)
+
+// BlockCache is a LRU cache of blocks by hash, so that the same block is never fetched twice, e.g. in retry
+// or overlapping epochs.
+type BlockCache struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[types.Hash]*list.Element
+	order    *list.List // front is the most recently used
+
+	hits   int
+	misses int
+}
+
+type blockCacheEntry struct {
+	hash  types.Hash
+	block *types.Block
+}
+
+func NewBlockCache(capacity int) *BlockCache {
+	return &BlockCache{
+		capacity: capacity,
+		items:    make(map[types.Hash]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached block of hash if any.
+func (cache *BlockCache) Get(hash types.Hash) (*types.Block, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	elem, ok := cache.items[hash]
+	if !ok {
+		cache.misses++
+		return nil, false
+	}
+
+	cache.hits++
+	cache.order.MoveToFront(elem)
+
+	return elem.Value.(*blockCacheEntry).block, true
+}
+
+// Add adds block into cache, and evicts the least recently used one if full.
+func (cache *BlockCache) Add(hash types.Hash, block *types.Block) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if elem, ok := cache.items[hash]; ok {
+		cache.order.MoveToFront(elem)
+		return
+	}
+
+	cache.items[hash] = cache.order.PushFront(&blockCacheEntry{hash, block})
+
+	if cache.order.Len() > cache.capacity {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.items, oldest.Value.(*blockCacheEntry).hash)
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface to output the cache hit rate.
+func (cache *BlockCache) MarshalJSON() ([]byte, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	var hitRate float64
+	if total := cache.hits + cache.misses; total > 0 {
+		hitRate = float64(cache.hits) / float64(total)
+	}
+
+	return json.Marshal(struct {
+		Capacity, Size, Hits, Misses int
+		HitRate                      float64
+	}{cache.capacity, cache.order.Len(), cache.hits, cache.misses, hitRate})
+}
+
+// getBlock returns the block from cache if any, otherwise fetches it from fullnode and measures latency.
+// Cache could be nil if disabled.
+func (cache *BlockCache) getBlock(client *sdk.Client, hash types.Hash, latency stats.MethodLatency) (*types.Block, error) {
+	if cache != nil {
+		if block, ok := cache.Get(hash); ok {
+			return block, nil
+		}
+	}
+
+	var block *types.Block
+	if err := latency.Measure("cfx_getBlockByHash", func() (err error) {
+		block, err = client.GetBlockByHash(hash)
+		return
+	}); err != nil {
+		return nil, errors.WithMessagef(err, "Failed to get block by hash %v", hash)
+	}
+
+	if cache != nil && block != nil {
+		cache.Add(hash, block)
+	}
+
+	return block, nil
+}
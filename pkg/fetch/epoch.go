@@ -0,0 +1,93 @@
+package fetch
+
+import (
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+)
+
+// Option is the option to query epoch data.
+type Option struct {
+	// SkipEmpty skips querying traces of blocks without transactions, and receipts of epochs without
+	// transactions, which are provably empty, so as to speed up scans of quiet chain periods.
+	SkipEmpty bool
+
+	// Blocks is the cache of blocks already fetched by hash, nil if disabled.
+	Blocks *BlockCache
+}
+
+// EpochData is the blocks, receipts and traces of an epoch.
+type EpochData struct {
+	Blocks   []*types.Block
+	Receipts [][]types.TransactionReceipt
+	Traces   []*types.LocalizedBlockTrace
+	Latency  stats.MethodLatency
+
+	Counts *EpochCounts // available in streaming mode, where receipts and traces are not retained
+}
+
+// QueryEpochData queries blocks, receipts and traces of an epoch, and measures latency by method.
+func QueryEpochData(client *sdk.Client, epochNumber uint64, latency stats.MethodLatency, option Option) (EpochData, error) {
+	result := EpochData{Latency: latency}
+
+	// blocks
+	epoch := types.NewEpochNumberUint64(epochNumber)
+	var blocks []types.Hash
+	if err := latency.Measure("cfx_getBlocksByEpoch", func() (err error) {
+		blocks, err = client.GetBlocksByEpoch(epoch)
+		return
+	}); err != nil {
+		return EpochData{}, errors.WithMessage(err, "Failed to get blocks by epoch")
+	}
+
+	for _, blockHash := range blocks {
+		// block detail
+		block, err := option.Blocks.getBlock(client, blockHash, latency)
+		if err != nil {
+			return EpochData{}, err
+		}
+		result.Blocks = append(result.Blocks, block)
+
+		if option.SkipEmpty && len(block.Transactions) == 0 {
+			result.Traces = append(result.Traces, nil)
+			continue
+		}
+
+		// traces
+		var blockTrace *types.LocalizedBlockTrace
+		if err := latency.Measure("trace_block", func() (err error) {
+			blockTrace, err = client.GetBlockTraces(blockHash)
+			return
+		}); err != nil {
+			return EpochData{}, errors.WithMessagef(err, "Failed to get block traces by block hash %v", blockHash)
+		}
+		result.Traces = append(result.Traces, blockTrace)
+	}
+
+	if option.SkipEmpty && isEmptyEpoch(result.Blocks) {
+		result.Receipts = make([][]types.TransactionReceipt, len(result.Blocks))
+		return result, nil
+	}
+
+	// receipts
+	if err := latency.Measure("cfx_getEpochReceipts", func() (err error) {
+		result.Receipts, err = client.GetEpochReceipts(*types.NewEpochOrBlockHashWithEpoch(epoch))
+		return
+	}); err != nil {
+		return EpochData{}, errors.WithMessage(err, "Failed to get epoch receipts")
+	}
+
+	return result, nil
+}
+
+// isEmptyEpoch returns true if there is no transaction in any block of epoch.
+func isEmptyEpoch(blocks []*types.Block) bool {
+	for _, block := range blocks {
+		if len(block.Transactions) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
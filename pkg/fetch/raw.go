@@ -0,0 +1,245 @@
+package fetch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+// RawOption is the option of raw client.
+type RawOption struct {
+	// HttpVersion is the HTTP protocol, "1.1" to force HTTP/1.1, "2" to enable HTTP/2 over TLS, or empty for
+	// the default negotiation.
+	HttpVersion string
+
+	// Compression is the Accept-Encoding, "gzip" or "none".
+	Compression string
+}
+
+// RawClient is a minimal JSON-RPC client that returns raw responses without decoding, so as to
+// measure the overhead of SDK client.
+type RawClient struct {
+	url         string
+	client      *http.Client
+	compression string
+	id          atomic.Uint64
+
+	mu        sync.Mutex
+	protocols map[string]int // number of responses by negotiated protocol
+
+	compressedBytes   atomic.Uint64 // response bytes received on the wire
+	decompressedBytes atomic.Uint64 // response bytes after decompression
+}
+
+func NewRawClient(url string, timeout time.Duration, option RawOption) *RawClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = true // decompress manually to count bytes
+
+	switch option.HttpVersion {
+	case "1.1":
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	case "2":
+		transport.ForceAttemptHTTP2 = true
+	}
+
+	return &RawClient{
+		url:         url,
+		client:      &http.Client{Timeout: timeout, Transport: transport},
+		compression: option.Compression,
+		protocols:   make(map[string]int),
+	}
+}
+
+// Protocols returns the number of responses by negotiated protocol, e.g. HTTP/1.1 or HTTP/2.0.
+func (c *RawClient) Protocols() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return maps.Clone(c.protocols)
+}
+
+// rawRpcError is the JSON-RPC error responded from fullnode.
+type rawRpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rawRpcError) Error() string {
+	return fmt.Sprintf("%v (code %v)", e.Message, e.Code)
+}
+
+func (e *rawRpcError) ErrorCode() int {
+	return e.Code
+}
+
+// Bytes returns the number of response bytes received on the wire and after decompression.
+func (c *RawClient) Bytes() (compressed, decompressed uint64) {
+	return c.compressedBytes.Load(), c.decompressedBytes.Load()
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	io.ReadCloser
+	counter *atomic.Uint64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.counter.Add(uint64(n))
+	return n, err
+}
+
+// gzipReadCloser closes both the gzip reader and underlying body.
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+func (r *gzipReadCloser) Close() error {
+	r.Reader.Close()
+	return r.body.Close()
+}
+
+// post sends the JSON-RPC request and returns the HTTP response.
+func (c *RawClient) post(method string, params []any) (*http.Response, error) {
+	if params == nil {
+		params = []any{}
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      c.id.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to marshal request")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to create request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if c.compression == "gzip" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to send request")
+	}
+
+	c.mu.Lock()
+	c.protocols[resp.Proto]++
+	c.mu.Unlock()
+
+	compressed := &countingReader{ReadCloser: resp.Body, counter: &c.compressedBytes}
+	resp.Body = compressed
+
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		reader, err := gzip.NewReader(compressed)
+		if err != nil {
+			compressed.Close()
+			return nil, errors.WithMessage(err, "Failed to decompress response")
+		}
+
+		resp.Body = &gzipReadCloser{Reader: reader, body: compressed}
+	}
+
+	resp.Body = &countingReader{ReadCloser: resp.Body, counter: &c.decompressedBytes}
+
+	return resp, nil
+}
+
+// Call invokes the RPC method and returns the raw result.
+func (c *RawClient) Call(method string, params ...any) (json.RawMessage, error) {
+	resp, err := c.post(method, params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rawRpcError    `json:"error"`
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, errors.WithMessagef(err, "Failed to decode response, status = %v", resp.Status)
+	}
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return result.Result, nil
+}
+
+// QueryEpochDataRaw issues the same requests as QueryEpochData via raw client, and measures the latency
+// along with the time to decode raw responses into SDK types.
+func QueryEpochDataRaw(client *RawClient, epochNumber uint64, latency, decode stats.MethodLatency) error {
+	epoch := hexutil.EncodeUint64(epochNumber)
+
+	call := func(result any, method string, params ...any) error {
+		var raw json.RawMessage
+		if err := latency.Measure(method, func() (err error) {
+			raw, err = client.Call(method, params...)
+			return
+		}); err != nil {
+			return err
+		}
+
+		// measure without jitter
+		start := time.Now()
+		if err := json.Unmarshal(raw, result); err != nil {
+			return err
+		}
+		decode.Record(method, time.Since(start))
+
+		return nil
+	}
+
+	// blocks
+	var blocks []types.Hash
+	if err := call(&blocks, "cfx_getBlocksByEpoch", epoch); err != nil {
+		return errors.WithMessage(err, "Failed to get blocks by epoch")
+	}
+
+	for _, blockHash := range blocks {
+		// block detail
+		var block types.Block
+		if err := call(&block, "cfx_getBlockByHash", blockHash, true); err != nil {
+			return errors.WithMessagef(err, "Failed to get block by hash %v", blockHash)
+		}
+
+		// traces
+		var blockTrace types.LocalizedBlockTrace
+		if err := call(&blockTrace, "trace_block", blockHash); err != nil {
+			return errors.WithMessagef(err, "Failed to get block traces by block hash %v", blockHash)
+		}
+	}
+
+	// receipts
+	var receipts [][]types.TransactionReceipt
+	if err := call(&receipts, "cfx_getEpochReceipts", epoch); err != nil {
+		return errors.WithMessage(err, "Failed to get epoch receipts")
+	}
+
+	return nil
+}
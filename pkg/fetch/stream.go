@@ -0,0 +1,227 @@
+package fetch
+
+import (
+	"encoding/json"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-sdk/types"
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/pkg/errors"
+)
+
+// CallStream invokes the RPC method and decodes the result in streaming via the given function, so that
+// huge responses are never buffered as a whole.
+func (c *RawClient) CallStream(method string, decodeResult func(dec *json.Decoder) error, params ...any) error {
+	resp, err := c.post(method, params)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+
+	if err = expectDelim(dec, '{'); err != nil {
+		return errors.WithMessagef(err, "Invalid response, status = %v", resp.Status)
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return errors.WithMessage(err, "Failed to decode response field")
+		}
+
+		switch token {
+		case "result":
+			if err = decodeResult(dec); err != nil {
+				return errors.WithMessage(err, "Failed to decode result")
+			}
+		case "error":
+			var rpcErr rawRpcError
+			if err = dec.Decode(&rpcErr); err != nil {
+				return errors.WithMessage(err, "Failed to decode error")
+			}
+			return &rpcErr
+		default:
+			var ignored json.RawMessage
+			if err = dec.Decode(&ignored); err != nil {
+				return errors.WithMessagef(err, "Failed to decode field %v", token)
+			}
+		}
+	}
+
+	return nil
+}
+
+// expectDelim consumes the next token and ensures it is the given delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if token != delim {
+		return errors.Errorf("Expected %v but got %v", delim, token)
+	}
+
+	return nil
+}
+
+// decodeArray decodes a JSON array element by element, and null is regarded as an empty array.
+func decodeArray(dec *json.Decoder, decodeElem func(dec *json.Decoder) error) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if token == nil {
+		return nil
+	}
+
+	if token != json.Delim('[') {
+		return errors.Errorf("Expected array but got %v", token)
+	}
+
+	for dec.More() {
+		if err = decodeElem(dec); err != nil {
+			return err
+		}
+	}
+
+	return expectDelim(dec, ']')
+}
+
+// EpochCounts is the number of objects decoded in streaming without materializing receipts and traces.
+type EpochCounts struct {
+	Receipts int
+	Logs     int
+	Traces   int // number of transaction traces
+	Bytes    int // size of receipts and traces in JSON
+}
+
+// QueryEpochDataStream queries blocks of epoch as QueryEpochData, but decodes epoch receipts and block traces
+// in streaming to only count the objects, so as to avoid memory spike for epochs with huge number of logs.
+func QueryEpochDataStream(client *sdk.Client, raw *RawClient, epochNumber uint64, latency stats.MethodLatency, option Option) (EpochData, error) {
+	result := EpochData{
+		Latency: latency,
+		Counts:  &EpochCounts{},
+	}
+
+	// blocks
+	epoch := types.NewEpochNumberUint64(epochNumber)
+	var blocks []types.Hash
+	if err := latency.Measure("cfx_getBlocksByEpoch", func() (err error) {
+		blocks, err = client.GetBlocksByEpoch(epoch)
+		return
+	}); err != nil {
+		return EpochData{}, errors.WithMessage(err, "Failed to get blocks by epoch")
+	}
+
+	for _, blockHash := range blocks {
+		// block detail
+		block, err := option.Blocks.getBlock(client, blockHash, latency)
+		if err != nil {
+			return EpochData{}, err
+		}
+		result.Blocks = append(result.Blocks, block)
+
+		if option.SkipEmpty && len(block.Transactions) == 0 {
+			continue
+		}
+
+		// traces, decoded per transaction
+		if err := latency.Measure("trace_block", func() error {
+			return raw.CallStream("trace_block", func(dec *json.Decoder) error {
+				defer countBytes(dec, &result.Counts.Bytes)()
+
+				return decodeObjectField(dec, "transactionTraces", func(dec *json.Decoder) error {
+					return decodeArray(dec, func(dec *json.Decoder) error {
+						var ignored struct{}
+						if err := dec.Decode(&ignored); err != nil {
+							return err
+						}
+						result.Counts.Traces++
+						return nil
+					})
+				})
+			}, blockHash)
+		}); err != nil {
+			return EpochData{}, errors.WithMessagef(err, "Failed to get block traces by block hash %v", blockHash)
+		}
+	}
+
+	if option.SkipEmpty && isEmptyEpoch(result.Blocks) {
+		return result, nil
+	}
+
+	// receipts, decoded per receipt
+	if err := latency.Measure("cfx_getEpochReceipts", func() error {
+		return raw.CallStream("cfx_getEpochReceipts", func(dec *json.Decoder) error {
+			defer countBytes(dec, &result.Counts.Bytes)()
+
+			return decodeArray(dec, func(dec *json.Decoder) error {
+				return decodeArray(dec, func(dec *json.Decoder) error {
+					var receipt struct {
+						Logs []struct{} `json:"logs"`
+					}
+					if err := dec.Decode(&receipt); err != nil {
+						return err
+					}
+					result.Counts.Receipts++
+					result.Counts.Logs += len(receipt.Logs)
+					return nil
+				})
+			})
+		}, hexutil.EncodeUint64(epochNumber))
+	}); err != nil {
+		return EpochData{}, errors.WithMessage(err, "Failed to get epoch receipts")
+	}
+
+	return result, nil
+}
+
+// countBytes returns a function to add the number of bytes decoded since called to counter.
+func countBytes(dec *json.Decoder, counter *int) func() {
+	start := dec.InputOffset()
+
+	return func() {
+		*counter += int(dec.InputOffset() - start)
+	}
+}
+
+// decodeObjectField decodes a JSON object, of which the given field is decoded via function and others are
+// skipped, and null is regarded as an empty object.
+func decodeObjectField(dec *json.Decoder, field string, decodeField func(dec *json.Decoder) error) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if token == nil {
+		return nil
+	}
+
+	if token != json.Delim('{') {
+		return errors.Errorf("Expected object but got %v", token)
+	}
+
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if key == field {
+			err = decodeField(dec)
+		} else {
+			var ignored json.RawMessage
+			err = dec.Decode(&ignored)
+		}
+
+		if err != nil {
+			return errors.WithMessagef(err, "Failed to decode field %v", key)
+		}
+	}
+
+	return expectDelim(dec, '}')
+}
@@ -0,0 +1,267 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// JSON-RPC error codes responded by mock server.
+const (
+	CodeParseError     = -32700
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInjected       = -32000
+)
+
+// Response is a canned response of RPC method. Params is optional to match any params.
+type Response struct {
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// Error is the JSON-RPC error object.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type request struct {
+	Version string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Version string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Option is the latency and error injection of mock server.
+type Option struct {
+	Latency   time.Duration // fixed latency of each request
+	Jitter    time.Duration // maximum random latency in addition to the fixed latency
+	ErrorRate float64       // probability of responding an injected error
+}
+
+// Stat is the number of requests served by mock server.
+type Stat struct {
+	NumRequests uint64
+	NumMissed   uint64 // no canned response matched
+	NumInjected uint64
+}
+
+// Server serves canned JSON-RPC responses over HTTP.
+type Server struct {
+	option    Option
+	responses map[string][]Response // method -> responses
+
+	numRequests atomic.Uint64
+	numMissed   atomic.Uint64
+	numInjected atomic.Uint64
+}
+
+// NewServer loads canned responses from the capture directory, in which each file <method>.json
+// contains an array of responses of the RPC method, e.g.
+//
+//	[
+//	    {"params": ["0x10", false], "result": {...}},
+//	    {"result": null}
+//	]
+//
+// Responses are matched by params in order, and the one without params matches any params.
+func NewServer(dir string, option Option) (*Server, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to list capture files")
+	}
+
+	server := Server{
+		option:    option,
+		responses: make(map[string][]Response),
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Failed to read file %v", file)
+		}
+
+		var responses []Response
+		if err = json.Unmarshal(content, &responses); err != nil {
+			return nil, errors.WithMessagef(err, "Failed to unmarshal responses in file %v", file)
+		}
+
+		for i := range responses {
+			if len(responses[i].Params) == 0 {
+				continue
+			}
+
+			if responses[i].Params, err = canonicalize(responses[i].Params); err != nil {
+				return nil, errors.WithMessagef(err, "Invalid params of response %v in file %v", i, file)
+			}
+		}
+
+		method := strings.TrimSuffix(filepath.Base(file), ".json")
+		server.responses[method] = responses
+	}
+
+	return &server, nil
+}
+
+// Methods returns the number of canned responses by method.
+func (server *Server) Methods() map[string]int {
+	methods := make(map[string]int)
+	for method, responses := range server.responses {
+		methods[method] = len(responses)
+	}
+
+	return methods
+}
+
+// Stat returns the number of requests served so far.
+func (server *Server) Stat() Stat {
+	return Stat{
+		NumRequests: server.numRequests.Load(),
+		NumMissed:   server.numMissed.Load(),
+		NumInjected: server.numInjected.Load(),
+	}
+}
+
+// ServeHTTP serves both single and batch JSON-RPC requests.
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		server.write(w, response{Version: "2.0", Id: json.RawMessage("null"), Error: &Error{
+			Code: CodeParseError, Message: err.Error(),
+		}})
+		return
+	}
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var requests []request
+		if err := json.Unmarshal(body, &requests); err != nil {
+			server.write(w, response{Version: "2.0", Id: json.RawMessage("null"), Error: &Error{
+				Code: CodeParseError, Message: err.Error(),
+			}})
+			return
+		}
+
+		server.delay()
+
+		responses := make([]response, 0, len(requests))
+		for _, v := range requests {
+			responses = append(responses, server.serve(v))
+		}
+
+		server.write(w, responses)
+		return
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		server.write(w, response{Version: "2.0", Id: json.RawMessage("null"), Error: &Error{
+			Code: CodeParseError, Message: err.Error(),
+		}})
+		return
+	}
+
+	server.delay()
+	server.write(w, server.serve(req))
+}
+
+// delay sleeps for the configured latency.
+func (server *Server) delay() {
+	latency := server.option.Latency
+	if server.option.Jitter > 0 {
+		latency += time.Duration(rand.Int63n(int64(server.option.Jitter)))
+	}
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+}
+
+// serve responds the canned response of request, or an injected error by chance.
+func (server *Server) serve(req request) response {
+	server.numRequests.Add(1)
+
+	resp := response{Version: "2.0", Id: req.Id}
+
+	if server.option.ErrorRate > 0 && rand.Float64() < server.option.ErrorRate {
+		server.numInjected.Add(1)
+		resp.Error = &Error{Code: CodeInjected, Message: "Injected error by mock server"}
+		return resp
+	}
+
+	responses, ok := server.responses[req.Method]
+	if !ok {
+		server.numMissed.Add(1)
+		resp.Error = &Error{Code: CodeMethodNotFound, Message: "Method not found: " + req.Method}
+		return resp
+	}
+
+	params, err := canonicalize(req.Params)
+	if err != nil {
+		resp.Error = &Error{Code: CodeInvalidParams, Message: err.Error()}
+		return resp
+	}
+
+	for _, v := range responses {
+		if len(v.Params) > 0 && !bytes.Equal(v.Params, params) {
+			continue
+		}
+
+		if v.Error != nil {
+			resp.Error = v.Error
+		} else if len(v.Result) > 0 {
+			resp.Result = v.Result
+		} else {
+			resp.Result = json.RawMessage("null")
+		}
+
+		return resp
+	}
+
+	server.numMissed.Add(1)
+	resp.Error = &Error{Code: CodeInvalidParams, Message: "No canned response for params " + string(params)}
+
+	return resp
+}
+
+func (server *Server) write(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// canonicalize re-encodes params so that params could be compared regardless of formatting,
+// in which case empty params is regarded as an empty array.
+func canonicalize(params json.RawMessage) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(params)) == 0 {
+		return json.RawMessage("[]"), nil
+	}
+
+	var v any
+	if err := json.Unmarshal(params, &v); err != nil {
+		return nil, errors.WithMessage(err, "Failed to unmarshal params")
+	}
+
+	if v == nil {
+		v = []any{}
+	}
+
+	return json.Marshal(v)
+}
@@ -0,0 +1,63 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ArchiveEntry is the metadata of an unexpected RPC response.
+type ArchiveEntry struct {
+	Time     time.Time
+	Endpoint string
+	Method   string
+	Params   []any
+	Epoch    uint64 `json:",omitempty"`
+	Reason   string
+}
+
+// Archive saves unexpected RPC responses to a directory, in which each response is saved as a pair of files,
+// <seq>-<method>.raw of the exact bytes received and <seq>-<method>.json of the metadata.
+type Archive struct {
+	dir string
+	seq atomic.Uint64
+}
+
+// NewArchive creates the archive directory if not exists.
+func NewArchive(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.WithMessage(err, "Failed to create directory")
+	}
+
+	return &Archive{dir: dir}, nil
+}
+
+// Save saves the raw response along with metadata, and returns the path of raw response file.
+func (archive *Archive) Save(entry ArchiveEntry, raw []byte) (string, error) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	name := fmt.Sprintf("%v-%v-%v", entry.Time.Format("20060102T150405"), archive.seq.Add(1), entry.Method)
+	rawFile := filepath.Join(archive.dir, name+".raw")
+
+	if err := os.WriteFile(rawFile, raw, 0644); err != nil {
+		return "", errors.WithMessage(err, "Failed to write raw response")
+	}
+
+	metadata, err := json.MarshalIndent(entry, "", "    ")
+	if err != nil {
+		return "", errors.WithMessage(err, "Failed to marshal metadata")
+	}
+
+	if err = os.WriteFile(filepath.Join(archive.dir, name+".json"), append(metadata, '\n'), 0644); err != nil {
+		return "", errors.WithMessage(err, "Failed to write metadata")
+	}
+
+	return rawFile, nil
+}
@@ -0,0 +1,31 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Curl returns a ready-to-run curl command to reproduce the JSON-RPC request against the endpoint.
+func Curl(url, method string, params ...any) string {
+	if params == nil {
+		params = []any{}
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		body = []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":%q,"params":%q}`, method, fmt.Sprint(params)))
+	}
+
+	return fmt.Sprintf("curl -s -X POST -H 'Content-Type: application/json' --data %v %v", shellQuote(string(body)), shellQuote(url))
+}
+
+// shellQuote quotes the string in single quotes for POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
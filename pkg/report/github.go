@@ -0,0 +1,17 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	githubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// GithubAnnotation returns the workflow command of GitHub Actions to annotate with level of error, warning
+// or notice, e.g. ::error title=Verification failed::3 of 100 trace checks failed.
+func GithubAnnotation(level, title, message string) string {
+	return fmt.Sprintf("::%v title=%v::%v", level, githubPropertyEscaper.Replace(title), githubDataEscaper.Replace(message))
+}
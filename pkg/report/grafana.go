@@ -0,0 +1,84 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// grafanaDatasource refers to the Prometheus datasource chosen on import.
+var grafanaDatasource = map[string]any{"type": "prometheus", "uid": "${DS_PROMETHEUS}"}
+
+// GrafanaDashboard returns the Grafana dashboard model of exported metrics with Prometheus datasource, which
+// could be imported in Grafana directly.
+func GrafanaDashboard(title string) map[string]any {
+	var panels []any
+
+	// time since last run
+	panels = append(panels, grafanaPanel("stat", "Time since last run",
+		fmt.Sprintf(`time() - %v{endpoint=~"$endpoint"}`, MetricLastRun), "{{endpoint}}", "s", 0, 0, 24))
+
+	for i, metric := range Metrics {
+		if metric.Name == MetricLastRun {
+			continue
+		}
+
+		legend := []string{"{{endpoint}}"}
+		for _, label := range metric.Labels {
+			legend = append(legend, label+"={{"+label+"}}")
+		}
+
+		expr := fmt.Sprintf(`%v{endpoint=~"$endpoint"}`, metric.Name)
+		panels = append(panels, grafanaPanel("timeseries", metric.Help, expr, strings.Join(legend, " "), metric.Unit,
+			(i%2)*12, 4+(i/2)*8, 12))
+	}
+
+	return map[string]any{
+		"__inputs": []any{map[string]any{
+			"name":     "DS_PROMETHEUS",
+			"label":    "Prometheus",
+			"type":     "datasource",
+			"pluginId": "prometheus",
+		}},
+		"title":         title,
+		"uid":           "go-test",
+		"editable":      true,
+		"schemaVersion": 39,
+		"refresh":       "1m",
+		"time":          map[string]any{"from": "now-7d", "to": "now"},
+		"templating": map[string]any{"list": []any{map[string]any{
+			"name":       "endpoint",
+			"label":      "Endpoint",
+			"type":       "query",
+			"datasource": grafanaDatasource,
+			"query":      fmt.Sprintf("label_values(%v, endpoint)", MetricEpochs),
+			"refresh":    2,
+			"multi":      true,
+			"includeAll": true,
+		}}},
+		"panels": panels,
+	}
+}
+
+func grafanaPanel(typ, title, expr, legend, unit string, x, y, width int) map[string]any {
+	height := 8
+	if typ == "stat" {
+		height = 4
+	}
+
+	return map[string]any{
+		"type":       typ,
+		"title":      title,
+		"datasource": grafanaDatasource,
+		"gridPos":    map[string]any{"x": x, "y": y, "w": width, "h": height},
+		"fieldConfig": map[string]any{
+			"defaults":  map[string]any{"unit": unit},
+			"overrides": []any{},
+		},
+		"targets": []any{map[string]any{
+			"datasource":   grafanaDatasource,
+			"expr":         expr,
+			"legendFormat": legend,
+			"refId":        "A",
+		}},
+	}
+}
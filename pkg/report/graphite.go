@@ -0,0 +1,52 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// invalidGraphiteChars are the chars not allowed in a node of Graphite metric path.
+var invalidGraphiteChars = regexp.MustCompile(`[^A-Za-z0-9_\-]+`)
+
+// GraphitePath returns the hierarchical path of metric in Graphite, e.g.
+// gotest.rpc_latency_seconds.http_localhost_12537.cfx_getBlockByHash.0_99 with prefix gotest.
+func GraphitePath(prefix string, metric Metric) string {
+	nodes := []string{strings.TrimPrefix(metric.Name, "gotest_")}
+	if len(prefix) > 0 {
+		nodes = append([]string{prefix}, nodes...)
+	}
+
+	for _, label := range metric.Labels {
+		nodes = append(nodes, strings.Trim(invalidGraphiteChars.ReplaceAllString(label.Value, "_"), "_"))
+	}
+
+	return strings.Join(nodes, ".")
+}
+
+// PushGraphite pushes metrics to Carbon in Graphite plaintext protocol over TCP.
+func PushGraphite(addr, prefix string, metrics []Metric, timestamp time.Time) error {
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to connect")
+	}
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+
+	writer := bufio.NewWriter(conn)
+	for _, metric := range metrics {
+		fmt.Fprintf(writer, "%v %v %v\n", GraphitePath(prefix, metric), metric.Value, timestamp.Unix())
+	}
+
+	if err = writer.Flush(); err != nil {
+		return errors.WithMessage(err, "Failed to write metrics")
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/boqiu/go-test/pkg/stats"
+	"github.com/pkg/errors"
+)
+
+// HistoryRecord is the summary of a test run appended to the history file line by line.
+type HistoryRecord struct {
+	Time      time.Time
+	Endpoint  string
+	ChainId   uint64 `json:",omitempty"`
+	NumEpochs uint64
+	NumErrors int
+
+	Latencies map[string]stats.LatencySummary
+}
+
+// AppendHistory appends the record to history file in NDJSON format.
+func AppendHistory(file string, record HistoryRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to marshal record")
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to open file")
+	}
+	defer f.Close()
+
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		return errors.WithMessage(err, "Failed to write file")
+	}
+
+	return nil
+}
+
+// ReadHistory reads records of the given endpoint from history file in order.
+func ReadHistory(file, endpoint string) ([]HistoryRecord, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to open file")
+	}
+	defer f.Close()
+
+	var records []HistoryRecord
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record HistoryRecord
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, errors.WithMessagef(err, "Failed to decode record at line %v", line)
+		}
+
+		if record.Endpoint == endpoint {
+			records = append(records, record)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.WithMessage(err, "Failed to read file")
+	}
+
+	return records, nil
+}
+
+// MethodTrend is the latency trend of an RPC method over runs.
+type MethodTrend struct {
+	Runs      int
+	First     time.Duration // average latency of the first run
+	Last      time.Duration // average latency of the last run
+	SlopeRun  time.Duration // average latency change per run by linear regression
+	ChangePct float64       // change of last run against first run in percentage
+}
+
+// MarshalJSON implements the json.Marshaler interface to output human readable durations.
+func (t MethodTrend) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Runs                  int
+		First, Last, SlopeRun string
+		ChangePct             float64
+	}{t.Runs, t.First.String(), t.Last.String(), t.SlopeRun.String(), t.ChangePct})
+}
+
+// TrendResult is the trends of an endpoint over the recent runs.
+type TrendResult struct {
+	Endpoint  string
+	Runs      []HistoryRecord
+	ErrorRate []float64 // errors per epoch of each run
+	Methods   map[string]MethodTrend
+}
+
+// AnalyzeTrend analyzes the latency and error trends of the given runs in order.
+func AnalyzeTrend(endpoint string, runs []HistoryRecord) TrendResult {
+	result := TrendResult{
+		Endpoint: endpoint,
+		Runs:     runs,
+		Methods:  make(map[string]MethodTrend),
+	}
+
+	samples := make(map[string][]float64)
+	var methods []string
+
+	for i, run := range runs {
+		var rate float64
+		if run.NumEpochs > 0 {
+			rate = float64(run.NumErrors) / float64(run.NumEpochs)
+		}
+		result.ErrorRate = append(result.ErrorRate, rate)
+
+		for method, latency := range run.Latencies {
+			if _, ok := samples[method]; !ok {
+				methods = append(methods, method)
+			}
+
+			// pad runs in which the method was not invoked
+			for len(samples[method]) < i {
+				samples[method] = append(samples[method], -1)
+			}
+
+			samples[method] = append(samples[method], float64(latency.Avg))
+		}
+	}
+
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		var xs, ys []float64
+		for i, v := range samples[method] {
+			if v >= 0 {
+				xs, ys = append(xs, float64(i)), append(ys, v)
+			}
+		}
+
+		trend := MethodTrend{
+			Runs:     len(ys),
+			First:    time.Duration(ys[0]),
+			Last:     time.Duration(ys[len(ys)-1]),
+			SlopeRun: time.Duration(slope(xs, ys)),
+		}
+
+		if ys[0] > 0 {
+			trend.ChangePct = (ys[len(ys)-1] - ys[0]) / ys[0] * 100
+		}
+
+		result.Methods[method] = trend
+	}
+
+	return result
+}
+
+// slope returns the slope of linear regression, or 0 if not enough samples.
+func slope(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXY += xs[i] * ys[i]
+		sumXX += xs[i] * xs[i]
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+
+	return (n*sumXY - sumX*sumY) / denominator
+}
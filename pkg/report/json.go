@@ -0,0 +1,28 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// WriteJSON writes the value in indented JSON format to the given file, or stdout if file is empty.
+func WriteJSON(file string, v any) error {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return errors.WithMessage(err, "Failed to marshal")
+	}
+
+	if file == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if err = os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+		return errors.WithMessage(err, "Failed to write file")
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Manifest describes a test run along with its result, so that results stored long-term remain
+// interpretable and reproducible.
+type Manifest struct {
+	Command   string
+	Args      []string
+	Flags     map[string]string
+	Version   string
+	Revision  string `json:",omitempty"`
+	GoVersion string
+
+	Start time.Time
+	End   time.Time
+
+	Endpoint string
+	ChainId  uint64 `json:",omitempty"`
+
+	Summary any
+}
+
+// NewManifest creates a manifest at the beginning of run, along with the tool version from build info.
+func NewManifest(command string, args []string, flags map[string]string, endpoint string) Manifest {
+	manifest := Manifest{
+		Command:   command,
+		Args:      args,
+		Flags:     flags,
+		GoVersion: runtime.Version(),
+		Start:     time.Now(),
+		Endpoint:  endpoint,
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		manifest.Version = info.Main.Version
+
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				manifest.Revision = setting.Value
+			}
+		}
+	}
+
+	return manifest
+}
+
+// Write writes manifest along with the result of run to file.
+func (manifest *Manifest) Write(file string, summary any) error {
+	manifest.End = time.Now()
+	manifest.Summary = summary
+
+	return WriteJSON(file, manifest)
+}
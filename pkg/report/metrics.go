@@ -0,0 +1,59 @@
+package report
+
+// MetricDesc describes a metric exported by the tool, which is labeled with endpoint besides the labels.
+type MetricDesc struct {
+	Name   string
+	Help   string
+	Unit   string // Grafana unit, e.g. s for seconds and short for counts
+	Labels []string
+}
+
+// metric names
+const (
+	MetricEpochs               = "gotest_epochs"
+	MetricBlocks               = "gotest_blocks"
+	MetricTransactions         = "gotest_transactions"
+	MetricLogs                 = "gotest_logs"
+	MetricTraces               = "gotest_traces"
+	MetricErrors               = "gotest_errors"
+	MetricRetried              = "gotest_retried_epochs"
+	MetricRecovered            = "gotest_recovered_epochs"
+	MetricRpcRequests          = "gotest_rpc_requests"
+	MetricRpcLatency           = "gotest_rpc_latency_seconds"
+	MetricVerificationChecks   = "gotest_verification_checks"
+	MetricVerificationFailures = "gotest_verification_failures"
+	MetricDuration             = "gotest_duration_seconds"
+	MetricLastRun              = "gotest_last_run_timestamp_seconds"
+)
+
+// Metrics is the catalog of metrics exported by the tool for each test run.
+var Metrics = []MetricDesc{
+	{MetricEpochs, "Number of epochs tested", "short", nil},
+	{MetricBlocks, "Number of blocks in tested epochs", "short", nil},
+	{MetricTransactions, "Number of transactions in tested epochs", "short", nil},
+	{MetricLogs, "Number of logs in tested epochs", "short", nil},
+	{MetricTraces, "Number of traces in tested epochs", "short", nil},
+	{MetricErrors, "Number of epochs failed persistently", "short", nil},
+	{MetricRetried, "Number of epochs failed and retried", "short", nil},
+	{MetricRecovered, "Number of epochs recovered in retry", "short", nil},
+	{MetricRpcRequests, "Number of RPC requests by method", "short", []string{"method"}},
+	{MetricRpcLatency, "RPC latency quantiles by method", "s", []string{"method", "quantile"}},
+	{MetricVerificationChecks, "Number of verification checks by name", "short", []string{"name"}},
+	{MetricVerificationFailures, "Number of verification failures by name", "short", []string{"name"}},
+	{MetricDuration, "Duration of test run", "s", nil},
+	{MetricLastRun, "Unix time of the last test run", "dateTimeAsIso", nil},
+}
+
+// Label is a metric label of name and value.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Metric is a sample of metric in catalog, of which labels are ordered as endpoint followed by the labels
+// of metric description.
+type Metric struct {
+	Name   string
+	Labels []Label
+	Value  float64
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// FormatOpenMetrics formats metrics as gauges in OpenMetrics text format, grouped in catalog order.
+func FormatOpenMetrics(metrics []Metric) []byte {
+	var buf bytes.Buffer
+
+	for _, desc := range Metrics {
+		var found bool
+
+		for _, metric := range metrics {
+			if metric.Name != desc.Name {
+				continue
+			}
+
+			if !found {
+				fmt.Fprintf(&buf, "# HELP %v %v\n", desc.Name, desc.Help)
+				fmt.Fprintf(&buf, "# TYPE %v gauge\n", desc.Name)
+				found = true
+			}
+
+			var labels []string
+			for _, label := range metric.Labels {
+				labels = append(labels, fmt.Sprintf(`%v="%v"`, label.Name, labelValueReplacer.Replace(label.Value)))
+			}
+
+			fmt.Fprintf(&buf, "%v{%v} %v\n", metric.Name, strings.Join(labels, ","), metric.Value)
+		}
+	}
+
+	buf.WriteString("# EOF\n")
+
+	return buf.Bytes()
+}
+
+// WriteTextfile writes metrics in OpenMetrics text format to file atomically, so that the node_exporter
+// textfile collector never reads a partial file.
+func WriteTextfile(file string, metrics []Metric) error {
+	temp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*")
+	if err != nil {
+		return errors.WithMessage(err, "Failed to create temp file")
+	}
+
+	_, err = temp.Write(FormatOpenMetrics(metrics))
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		// temp file is created with 0600
+		err = os.Chmod(temp.Name(), 0644)
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), file)
+	}
+
+	if err != nil {
+		os.Remove(temp.Name())
+		return errors.WithMessage(err, "Failed to write file")
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package report
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// SqlColumn is a column of SQL table, of which type is portable across SQLite and PostgreSQL,
+// e.g. BIGINT, DOUBLE PRECISION and TEXT.
+type SqlColumn struct {
+	Name string
+	Type string
+}
+
+// SqlTable is a SQL table created automatically if not exists.
+type SqlTable struct {
+	Name    string
+	Columns []SqlColumn
+}
+
+// sqlBatchSize is the number of pending rows, beyond which rows are committed in a transaction.
+const sqlBatchSize = 500
+
+// SqlSink inserts rows into a database via database/sql, in which rows are committed in batches by
+// prepared statements, so that they are visible during the test run and kept if the run is aborted.
+type SqlSink struct {
+	db          *sql.DB
+	placeholder func(i int) string // placeholder of the i-th parameter starting from 1
+
+	tables     []SqlTable
+	pending    map[string][][]any // rows to insert by table
+	numPending int
+
+	NumRows int
+}
+
+// NewSqliteSink creates a sink to insert rows into the SQLite database file.
+func NewSqliteSink(file string, tables []SqlTable) (*SqlSink, error) {
+	db, err := sql.Open("sqlite3", file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Failed to open database")
+	}
+
+	return newSqlSink(db, func(int) string { return "?" }, tables)
+}
+
+// NewPostgresSink creates a sink to insert rows into the PostgreSQL database of DSN in URL or key=value
+// format, e.g. postgres://user@host:5432/db, in which the password could be provided via PGPASSWORD or
+// PGPASSFILE rather than DSN, so as not to be exposed in command line arguments.
+func NewPostgresSink(dsn string, tables []SqlTable) (*SqlSink, error) {
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Invalid DSN")
+	}
+
+	db := stdlib.OpenDB(*config)
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, errors.WithMessage(err, "Failed to connect database")
+	}
+
+	return newSqlSink(db, func(i int) string { return fmt.Sprintf("$%v", i) }, tables)
+}
+
+func newSqlSink(db *sql.DB, placeholder func(int) string, tables []SqlTable) (*SqlSink, error) {
+	for _, table := range tables {
+		var columns []string
+		for _, column := range table.Columns {
+			columns = append(columns, sqlName(column.Name)+" "+column.Type)
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v (%v)", sqlName(table.Name), strings.Join(columns, ", "))); err != nil {
+			db.Close()
+			return nil, errors.WithMessagef(err, "Failed to create table %v", table.Name)
+		}
+	}
+
+	return &SqlSink{
+		db:          db,
+		placeholder: placeholder,
+		tables:      tables,
+		pending:     make(map[string][][]any),
+	}, nil
+}
+
+// sqlName quotes identifier, since column names may be keywords, e.g. from and to.
+func sqlName(name string) string {
+	return `"` + name + `"`
+}
+
+// table returns the table of name if any.
+func (sink *SqlSink) table(name string) (SqlTable, bool) {
+	for _, table := range sink.tables {
+		if table.Name == name {
+			return table, true
+		}
+	}
+
+	return SqlTable{}, false
+}
+
+// Insert inserts a row of column values into table in batch, which is committed by Checkpoint or Close.
+func (sink *SqlSink) Insert(table string, values ...any) error {
+	t, ok := sink.table(table)
+	if !ok {
+		return errors.Errorf("Unknown table %v", table)
+	}
+
+	if len(values) != len(t.Columns) {
+		return errors.Errorf("Expected %v values of table %v but got %v", len(t.Columns), table, len(values))
+	}
+
+	sink.pending[table] = append(sink.pending[table], values)
+	sink.numPending++
+	sink.NumRows++
+
+	return nil
+}
+
+// Checkpoint commits the pending rows if the batch is full. It should be called at boundaries of related
+// rows, e.g. per epoch, so that related rows are never split across transactions.
+func (sink *SqlSink) Checkpoint() error {
+	if sink.numPending < sqlBatchSize {
+		return nil
+	}
+
+	return sink.commit()
+}
+
+// commit inserts the pending rows of all tables in a transaction.
+func (sink *SqlSink) commit() error {
+	if sink.numPending == 0 {
+		return nil
+	}
+
+	tx, err := sink.db.Begin()
+	if err != nil {
+		return errors.WithMessage(err, "Failed to begin transaction")
+	}
+
+	for _, table := range sink.tables {
+		if err = sink.insert(tx, table); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.WithMessage(err, "Failed to commit transaction")
+	}
+
+	sink.pending = make(map[string][][]any)
+	sink.numPending = 0
+
+	return nil
+}
+
+// insert inserts the pending rows of table by a prepared statement.
+func (sink *SqlSink) insert(tx *sql.Tx, table SqlTable) error {
+	rows := sink.pending[table.Name]
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var columns, placeholders []string
+	for i, column := range table.Columns {
+		columns = append(columns, sqlName(column.Name))
+		placeholders = append(placeholders, sink.placeholder(i+1))
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v)", sqlName(table.Name), strings.Join(columns, ", "), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return errors.WithMessagef(err, "Failed to prepare statement of table %v", table.Name)
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err = stmt.Exec(row...); err != nil {
+			return errors.WithMessagef(err, "Failed to insert into table %v", table.Name)
+		}
+	}
+
+	return nil
+}
+
+// Close commits the pending rows and closes the database.
+func (sink *SqlSink) Close() error {
+	err := sink.commit()
+
+	if closeErr := sink.db.Close(); err == nil && closeErr != nil {
+		err = errors.WithMessage(closeErr, "Failed to close database")
+	}
+
+	return err
+}
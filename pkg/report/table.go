@@ -0,0 +1,122 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Style is the ANSI SGR code to color text on terminal.
+type Style string
+
+const (
+	StyleNone   Style = ""
+	StyleBold   Style = "1"
+	StyleRed    Style = "31"
+	StyleGreen  Style = "32"
+	StyleYellow Style = "33"
+)
+
+// Cell is a table cell with optional style.
+type Cell struct {
+	Text  string
+	Style Style
+}
+
+// Text returns a cell without style.
+func Text(format string, a ...any) Cell {
+	return Cell{Text: fmt.Sprintf(format, a...)}
+}
+
+// Styled returns a cell with the given style.
+func Styled(style Style, format string, a ...any) Cell {
+	return Cell{Text: fmt.Sprintf(format, a...), Style: style}
+}
+
+// Table renders rows in aligned columns, in which the first column is left aligned and others are right
+// aligned. Column widths exclude escape codes, so that colored cells are aligned as well.
+type Table struct {
+	Title  string
+	header []string
+	rows   [][]Cell
+}
+
+func NewTable(title string, header ...string) *Table {
+	return &Table{Title: title, header: header}
+}
+
+func (t *Table) AddRow(cells ...Cell) {
+	t.rows = append(t.rows, cells)
+}
+
+// Len returns the number of rows.
+func (t *Table) Len() int {
+	return len(t.rows)
+}
+
+// Write renders the table, and colors the title, header and styled cells if required.
+func (t *Table) Write(w io.Writer, color bool) {
+	var widths []int
+	measure := func(i int, text string) {
+		for len(widths) <= i {
+			widths = append(widths, 0)
+		}
+		widths[i] = max(widths[i], utf8.RuneCountInString(text))
+	}
+
+	for i, text := range t.header {
+		measure(i, text)
+	}
+
+	for _, row := range t.rows {
+		for i, cell := range row {
+			measure(i, cell.Text)
+		}
+	}
+
+	if t.Title != "" {
+		fmt.Fprintln(w, paint(t.Title, StyleBold, color))
+	}
+
+	writeRow := func(cells []Cell) {
+		var sb strings.Builder
+		for i, cell := range cells {
+			padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell.Text))
+			text := paint(cell.Text, cell.Style, color)
+
+			switch {
+			case i == 0 && i == len(cells)-1:
+				sb.WriteString(text)
+			case i == 0:
+				sb.WriteString(text + padding)
+			default:
+				sb.WriteString("  " + padding + text)
+			}
+		}
+		fmt.Fprintln(w, "  "+sb.String())
+	}
+
+	if len(t.header) > 0 {
+		cells := make([]Cell, len(t.header))
+		for i, text := range t.header {
+			cells[i] = Cell{Text: text, Style: StyleBold}
+		}
+		writeRow(cells)
+	}
+
+	for _, row := range t.rows {
+		writeRow(row)
+	}
+
+	fmt.Fprintln(w)
+}
+
+// paint wraps the text with ANSI escape codes of style if color enabled.
+func paint(text string, style Style, color bool) string {
+	if !color || style == StyleNone {
+		return text
+	}
+
+	return fmt.Sprintf("\x1b[%vm%v\x1b[0m", style, text)
+}
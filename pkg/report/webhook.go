@@ -0,0 +1,32 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PostWebhook posts the payload in JSON format to the webhook URL.
+func PostWebhook(url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.WithMessage(err, "Failed to marshal payload")
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.WithMessage(err, "Failed to post webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("Unexpected status %v", resp.Status)
+	}
+
+	return nil
+}
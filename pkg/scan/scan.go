@@ -0,0 +1,271 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+	"github.com/boqiu/go-test/pkg/fetch"
+	"github.com/boqiu/go-test/pkg/stats"
+)
+
+// ClientPool provides clients to query epochs, e.g. a pool of endpoints with circuit breakers.
+type ClientPool interface {
+	Client() *sdk.Client
+}
+
+// Options is the options to scan epochs.
+type Options struct {
+	Client *sdk.Client      // client to query epochs
+	Shards []*sdk.Client    // clients to shard threads across if any
+	Pool   ClientPool       // pool of endpoints to query epochs if any, which overrides Client and Shards
+	Raw    *fetch.RawClient // raw client to decode receipts and traces in streaming mode
+
+	EpochFrom uint64
+	NumEpochs uint64
+	Epochs    []uint64 // epochs to scan rather than continuous epochs if any
+
+	Stream   bool          // whether to stream receipts and traces via raw client without retaining them
+	Jitter   time.Duration // maximum random delay before each RPC request
+	Fetch    fetch.Option
+	Parallel parallel.SerialOption
+
+	FailFast bool // whether to stop on the first failure
+	Retry    bool // whether to retry failed epochs later by RetryFailed, rather than failing persistently
+}
+
+// Epoch is the epoch fetched by worker to check.
+type Epoch struct {
+	Task    int
+	Number  uint64
+	Client  *sdk.Client      // client that fetched the epoch data, to query more against the same endpoint
+	Raw     *fetch.RawClient // raw client if any
+	Data    *fetch.EpochData
+	Summary Summary
+}
+
+// Result is the result of an epoch to collect in order.
+type Result[T any] struct {
+	Task    int
+	Epoch   uint64
+	Summary Summary
+	Value   T     // result of Check
+	Err     error // not nil if epoch failed
+	Retry   bool  // whether the failed epoch will be retried later
+	Retried bool  // whether the epoch is retried
+}
+
+// Checker runs checks against epochs scanned. Check is invoked by concurrent workers out of epoch order,
+// and Collect is invoked sequentially in epoch order, except for epochs retried at the end.
+type Checker[T any] interface {
+	// Check runs checks against the fetched epoch, of which result is passed to Collect.
+	Check(epoch *Epoch) (T, error)
+
+	// Collect aggregates the result of an epoch, either succeeded or failed.
+	Collect(result *Result[T]) error
+}
+
+// FailFastError is the failure of epoch to stop scan in fail-fast mode.
+type FailFastError struct {
+	Epoch uint64
+	Err   error
+}
+
+func (e *FailFastError) Error() string {
+	return fmt.Sprintf("Stopped on failure of epoch %v: %v", e.Epoch, e.Err.Error())
+}
+
+func (e *FailFastError) Cause() error  { return e.Err }
+func (e *FailFastError) Unwrap() error { return e.Err }
+
+// Stat is the statistics of epochs scanned.
+type Stat struct {
+	NumBlocks int
+	NumTxs    int
+	NumLogs   int
+	NumTraces int
+
+	NumEmptyEpochs int // epochs without transactions
+	NumEmptyBlocks int // blocks without transactions
+
+	BlocksPerEpoch stats.Distribution // helps to explain latency variance and tune parallelism
+
+	NumErrors    int              // persistent failures
+	NumRetried   int              // failures to retry later
+	NumRecovered int              // failures recovered in retry
+	ErrorCodes   stats.ErrorStats `json:",omitempty"` // all errors encountered including recovered ones
+
+	Latencies stats.LatencyStats
+	Workers   stats.WorkerStats
+}
+
+func NewStat() *Stat {
+	return &Stat{
+		ErrorCodes: make(stats.ErrorStats),
+		Latencies:  make(stats.LatencyStats),
+	}
+}
+
+// add aggregates the summary of a succeeded epoch.
+func (stat *Stat) add(summary *Summary) {
+	stat.NumBlocks += summary.NumBlocks
+	stat.NumEmptyBlocks += summary.NumEmptyBlocks
+	if summary.NumTxs == 0 {
+		stat.NumEmptyEpochs++
+	}
+	stat.BlocksPerEpoch.Add(summary.NumBlocks)
+	stat.NumTxs += summary.NumTxs
+	stat.NumLogs += summary.NumLogs
+	stat.NumTraces += summary.NumTraces
+	stat.Latencies.Add(summary.Latency)
+}
+
+// Scanner queries epochs in parallel, and collects the results of checker in epoch order.
+type Scanner[T any] struct {
+	stat    *Stat
+	opts    Options
+	checker Checker[T]
+
+	failedTasks []int // tasks to retry at the end
+
+	retrying  bool
+	retryPool ClientPool
+	retryRaw  *fetch.RawClient
+}
+
+func New[T any](stat *Stat, opts Options, checker Checker[T]) *Scanner[T] {
+	if len(opts.Epochs) > 0 {
+		opts.NumEpochs = uint64(len(opts.Epochs))
+	}
+
+	return &Scanner[T]{stat: stat, opts: opts, checker: checker}
+}
+
+// epochOf returns the epoch number of the given task.
+func (s *Scanner[T]) epochOf(task int) uint64 {
+	if len(s.opts.Epochs) > 0 {
+		return s.opts.Epochs[task]
+	}
+
+	return s.opts.EpochFrom + uint64(task)
+}
+
+// Run scans all epochs, and returns error if scan aborted, e.g. failed to collect or in fail-fast mode.
+func (s *Scanner[T]) Run(ctx context.Context) error {
+	return parallel.Serial(ctx, s, int(s.opts.NumEpochs), s.opts.Parallel)
+}
+
+// NumFailed returns the number of failed epochs to retry.
+func (s *Scanner[T]) NumFailed() int {
+	return len(s.failedTasks)
+}
+
+// RetryFailed retries the epochs failed in Run once serially with the given clients, e.g. with a longer
+// RPC timeout, so that only persistent failures are counted.
+func (s *Scanner[T]) RetryFailed(ctx context.Context, pool ClientPool, raw *fetch.RawClient) error {
+	tasks := s.failedTasks
+	s.failedTasks = nil
+	s.retrying, s.retryPool, s.retryRaw = true, pool, raw
+
+	for _, task := range tasks {
+		value, err := s.ParallelDo(ctx, 0, task)
+
+		if err = s.ParallelCollect(ctx, &parallel.Result[Result[T]]{
+			Task:  task,
+			Value: value,
+			Err:   err,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Scanner[T]) ParallelDo(ctx context.Context, routine, task int) (Result[T], error) {
+	client, raw := s.opts.Client, s.opts.Raw
+	if s.retrying {
+		client, raw = s.retryPool.Client(), s.retryRaw
+	} else if s.opts.Pool != nil {
+		client = s.opts.Pool.Client()
+	} else if len(s.opts.Shards) > 0 {
+		client = s.opts.Shards[routine%len(s.opts.Shards)]
+	}
+
+	start := time.Now()
+	result, err := s.queryEpoch(client, raw, task)
+	result.Summary.Elapsed = time.Since(start)
+
+	return result, err
+}
+
+// queryEpoch queries data of the epoch for the task, and runs checks against the epoch.
+func (s *Scanner[T]) queryEpoch(client *sdk.Client, raw *fetch.RawClient, task int) (Result[T], error) {
+	epochNumber := s.epochOf(task)
+
+	var data fetch.EpochData
+	var err error
+	fetchStart := time.Now()
+	if s.opts.Stream {
+		data, err = fetch.QueryEpochDataStream(client, raw, epochNumber, stats.NewMethodLatency(s.opts.Jitter), s.opts.Fetch)
+	} else {
+		data, err = fetch.QueryEpochData(client, epochNumber, stats.NewMethodLatency(s.opts.Jitter), s.opts.Fetch)
+	}
+	if err != nil {
+		return Result[T]{}, err
+	}
+
+	epoch := Epoch{
+		Task:    task,
+		Number:  epochNumber,
+		Client:  client,
+		Raw:     raw,
+		Data:    &data,
+		Summary: Summarize(&data),
+	}
+	epoch.Summary.Fetch = time.Since(fetchStart)
+
+	value, err := s.checker.Check(&epoch)
+	if err != nil {
+		return Result[T]{}, err
+	}
+
+	return Result[T]{Summary: epoch.Summary, Value: value}, nil
+}
+
+func (s *Scanner[T]) ParallelCollect(ctx context.Context, result *parallel.Result[Result[T]]) error {
+	if !s.retrying {
+		s.stat.Workers.Add(result.Routine, result.Value.Summary.Elapsed, result.Err != nil)
+	}
+
+	value := result.Value
+	value.Task, value.Epoch, value.Err, value.Retried = result.Task, s.epochOf(result.Task), result.Err, s.retrying
+
+	if result.Err != nil {
+		s.stat.ErrorCodes.Add(result.Err)
+
+		if s.opts.FailFast {
+			return &FailFastError{value.Epoch, result.Err}
+		}
+
+		if s.opts.Retry && !s.retrying {
+			s.failedTasks = append(s.failedTasks, result.Task)
+			s.stat.NumRetried++
+			value.Retry = true
+		} else {
+			s.stat.NumErrors++
+		}
+
+		return s.checker.Collect(&value)
+	}
+
+	if s.retrying {
+		s.stat.NumRecovered++
+	}
+
+	s.stat.add(&value.Summary)
+
+	return s.checker.Collect(&value)
+}
@@ -0,0 +1,196 @@
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	sdk "github.com/Conflux-Chain/go-conflux-sdk"
+	"github.com/Conflux-Chain/go-conflux-util/parallel"
+	"github.com/pkg/errors"
+)
+
+// testServer responds empty epochs, except that cfx_getBlocksByEpoch of the given epochs fails for the
+// given times, or always if negative.
+func testServer(t *testing.T, failures map[string]int) *httptest.Server {
+	var mu sync.Mutex
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Id     json.RawMessage
+			Method string
+			Params []any
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+
+		result := `[]`
+		switch req.Method {
+		case "cfx_getStatus":
+			result = `{"chainId":"0x1","networkId":"0x1"}`
+		case "cfx_getBlocksByEpoch":
+			epoch, _ := req.Params[0].(string)
+
+			mu.Lock()
+			n := failures[epoch]
+			if n > 0 {
+				failures[epoch] = n - 1
+			}
+			mu.Unlock()
+
+			if n != 0 {
+				w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.Id) + `,"error":{"code":-32000,"message":"injected"}}`))
+				return
+			}
+		}
+
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.Id) + `,"result":` + result + `}`))
+	}))
+}
+
+// testChecker fails Check of the given epoch, and records the results collected.
+type testChecker struct {
+	fail    uint64
+	results []Result[uint64]
+}
+
+func (checker *testChecker) Check(epoch *Epoch) (uint64, error) {
+	if epoch.Number == checker.fail {
+		return 0, errors.New("check failed")
+	}
+
+	return epoch.Number * 2, nil
+}
+
+func (checker *testChecker) Collect(result *Result[uint64]) error {
+	checker.results = append(checker.results, *result)
+	return nil
+}
+
+// testCollected is the brief of a collected result.
+type testCollected struct {
+	Epoch   uint64
+	Value   uint64
+	Failed  bool
+	Retry   bool
+	Retried bool
+}
+
+func TestScanner(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		opts      Options
+		failures  map[string]int
+		checkFail uint64
+		collected []testCollected
+		failFast  uint64 // epoch failed in fail-fast mode if any
+		stat      Stat   // counts of stat to compare
+	}{
+		{
+			name: "continuous epochs",
+			opts: Options{EpochFrom: 10, NumEpochs: 3},
+			collected: []testCollected{
+				{Epoch: 10, Value: 20}, {Epoch: 11, Value: 22}, {Epoch: 12, Value: 24},
+			},
+			stat: Stat{NumEmptyEpochs: 3},
+		},
+		{
+			name:      "epochs from file",
+			opts:      Options{EpochFrom: 3, NumEpochs: 100, Epochs: []uint64{3, 7, 9}},
+			collected: []testCollected{{Epoch: 3, Value: 6}, {Epoch: 7, Value: 14}, {Epoch: 9, Value: 18}},
+			stat:      Stat{NumEmptyEpochs: 3},
+		},
+		{
+			name:      "persistent failures",
+			opts:      Options{EpochFrom: 10, NumEpochs: 3},
+			failures:  map[string]int{"0xb": -1},
+			checkFail: 12,
+			collected: []testCollected{{Epoch: 10, Value: 20}, {Epoch: 11, Failed: true}, {Epoch: 12, Failed: true}},
+			stat:      Stat{NumEmptyEpochs: 1, NumErrors: 2},
+		},
+		{
+			name:     "recovered in retry",
+			opts:     Options{EpochFrom: 10, NumEpochs: 3, Retry: true},
+			failures: map[string]int{"0xa": 1, "0xb": -1},
+			collected: []testCollected{
+				{Epoch: 10, Failed: true, Retry: true},
+				{Epoch: 11, Failed: true, Retry: true},
+				{Epoch: 12, Value: 24},
+				{Epoch: 10, Value: 20, Retried: true},
+				{Epoch: 11, Failed: true, Retried: true},
+			},
+			stat: Stat{NumEmptyEpochs: 2, NumErrors: 1, NumRetried: 2, NumRecovered: 1},
+		},
+		{
+			name:      "fail fast",
+			opts:      Options{EpochFrom: 10, NumEpochs: 3, FailFast: true, Retry: true},
+			failures:  map[string]int{"0xb": 1},
+			collected: []testCollected{{Epoch: 10, Value: 20}},
+			failFast:  11,
+			stat:      Stat{NumEmptyEpochs: 1},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			server := testServer(t, c.failures)
+			defer server.Close()
+
+			client, err := sdk.NewClient(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			c.opts.Client = client
+			c.opts.Parallel = parallel.SerialOption{Routines: 2}
+
+			stat := NewStat()
+			checker := testChecker{fail: c.checkFail}
+			scanner := New(stat, c.opts, &checker)
+
+			err = scanner.Run(context.Background())
+
+			var failFastErr *FailFastError
+			if c.failFast > 0 {
+				if !errors.As(err, &failFastErr) || failFastErr.Epoch != c.failFast {
+					t.Fatalf("Expected fail-fast error of epoch %v, got %v", c.failFast, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if err = scanner.RetryFailed(context.Background(), &testPool{client}, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			var collected []testCollected
+			for _, v := range checker.results {
+				collected = append(collected, testCollected{v.Epoch, v.Value, v.Err != nil, v.Retry, v.Retried})
+			}
+
+			if !reflect.DeepEqual(collected, c.collected) {
+				t.Errorf("Expected collected %v, got %v", c.collected, collected)
+			}
+
+			actual := Stat{
+				NumEmptyEpochs: stat.NumEmptyEpochs,
+				NumErrors:      stat.NumErrors,
+				NumRetried:     stat.NumRetried,
+				NumRecovered:   stat.NumRecovered,
+			}
+			if !reflect.DeepEqual(actual, c.stat) {
+				t.Errorf("Expected stat %+v, got %+v", c.stat, actual)
+			}
+		})
+	}
+}
+
+// testPool always returns the same client.
+type testPool struct {
+	client *sdk.Client
+}
+
+func (pool *testPool) Client() *sdk.Client { return pool.client }
@@ -0,0 +1,68 @@
+package scan
+
+import (
+	"time"
+
+	"github.com/boqiu/go-test/pkg/fetch"
+	"github.com/boqiu/go-test/pkg/stats"
+)
+
+// Summary is the counts and timing of an epoch, which is small enough to pass to the collector rather
+// than full blocks, receipts and traces.
+type Summary struct {
+	NumBlocks int
+	NumTxs    int
+	NumLogs   int
+	NumTraces int
+
+	NumEmptyBlocks int
+
+	NumReceipts      int
+	NumTxsGasCovered int
+
+	Latency stats.MethodLatency
+
+	Fetch   time.Duration // time to fetch blocks, receipts and traces
+	Elapsed time.Duration // time to execute the task, which is available on error too
+}
+
+// Summarize counts the blocks, transactions, receipts, logs and traces of epoch.
+func Summarize(data *fetch.EpochData) Summary {
+	summary := Summary{
+		NumBlocks: len(data.Blocks),
+		Latency:   data.Latency,
+	}
+
+	for _, block := range data.Blocks {
+		summary.NumTxs += len(block.Transactions)
+
+		if len(block.Transactions) == 0 {
+			summary.NumEmptyBlocks++
+		}
+	}
+
+	for _, blockReceipts := range data.Receipts {
+		for _, receipt := range blockReceipts {
+			summary.NumReceipts++
+			summary.NumLogs += len(receipt.Logs)
+
+			if receipt.GasCoveredBySponsor {
+				summary.NumTxsGasCovered++
+			}
+		}
+	}
+
+	for _, blockTraces := range data.Traces {
+		if blockTraces != nil {
+			summary.NumTraces += len(blockTraces.TransactionTraces)
+		}
+	}
+
+	if data.Counts != nil {
+		summary.NumReceipts += data.Counts.Receipts
+		summary.NumLogs += data.Counts.Logs
+		summary.NumTraces += data.Counts.Traces
+	}
+
+	return summary
+}
@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cron is a cron-style schedule of 5 fields: minute, hour, day of month, month and day of week, in which
+// each field supports *, */step, values, ranges and steps of range, e.g. "0 */6 * * *" or "30 9 * * 1-5".
+type Cron struct {
+	spec   string
+	fields [5]uint64 // bitset of allowed values of each field
+
+	anyDom, anyDow bool // whether day of month or day of week is unrestricted
+}
+
+var cronBounds = [5]struct{ min, max int }{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week, 0 is Sunday, and 7 is accepted as Sunday as well
+}
+
+// ParseCron parses the cron-style schedule.
+func ParseCron(spec string) (*Cron, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronBounds) {
+		return nil, errors.Errorf("Expected 5 fields, but got %v", len(parts))
+	}
+
+	cron := Cron{spec: spec}
+
+	for i, part := range parts {
+		bounds := cronBounds[i]
+		max := bounds.max
+		if i == 4 {
+			max = 7
+		}
+
+		bits, err := parseCronField(part, bounds.min, max)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "Invalid field %v", part)
+		}
+
+		cron.fields[i] = bits
+	}
+
+	// Sunday as 7
+	if cron.fields[4]&(1<<7) != 0 {
+		cron.fields[4] |= 1
+	}
+
+	// as Vixie cron, a field starting with * is unrestricted even with step, e.g. */2
+	cron.anyDom = strings.HasPrefix(parts[2], "*")
+	cron.anyDow = strings.HasPrefix(parts[4], "*")
+
+	return &cron, nil
+}
+
+// parseCronField parses the comma separated list of a field into bitset.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, errors.Errorf("Invalid step %v", stepPart)
+			}
+		}
+
+		from, to := min, max
+		if rangePart != "*" {
+			lo, hi, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			if from, err = strconv.Atoi(lo); err != nil {
+				return 0, errors.Errorf("Invalid value %v", lo)
+			}
+
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(hi); err != nil {
+					return 0, errors.Errorf("Invalid value %v", hi)
+				}
+			} else if hasStep {
+				to = max
+			}
+		}
+
+		if from < min || to > max || from > to {
+			return 0, errors.Errorf("Value out of range [%v, %v]", min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+func (cron *Cron) String() string {
+	return cron.spec
+}
+
+// Next returns the next time after t that matches the schedule in the location of t.
+func (cron *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// a matching time is found within 5 years if any, e.g. Feb 29
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		if !cron.has(3, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !cron.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !cron.has(1, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !cron.has(0, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (cron *Cron) has(field, value int) bool {
+	return cron.fields[field]&(1<<value) != 0
+}
+
+// matchDay follows the cron convention that if both day of month and day of week are restricted,
+// either of them matches, otherwise both of them should match, e.g. "*/2 * 1" for Mondays of odd days.
+func (cron *Cron) matchDay(t time.Time) bool {
+	dom, dow := cron.has(2, t.Day()), cron.has(4, int(t.Weekday()))
+
+	if cron.anyDom || cron.anyDow {
+		return dom && dow
+	}
+
+	return dom || dow
+}
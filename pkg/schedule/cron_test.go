@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// Friday
+	from := time.Date(2026, 10, 16, 10, 17, 42, 0, time.UTC)
+
+	for _, tc := range []struct {
+		spec     string
+		expected string
+	}{
+		{"* * * * *", "2026-10-16 10:18"},
+		{"*/15 * * * *", "2026-10-16 10:30"},
+		{"0 */6 * * *", "2026-10-16 12:00"},
+		{"17 10 * * *", "2026-10-17 10:17"},
+		{"5,50 9-11 * * *", "2026-10-16 10:50"},
+		{"0 0 1 * *", "2026-11-01 00:00"},
+		{"0 0 1 1 *", "2027-01-01 00:00"},
+		{"30 9 * * 1-5", "2026-10-19 09:30"},
+		{"0 12 * * 0", "2026-10-18 12:00"},
+		{"0 12 * * 7", "2026-10-18 12:00"}, // Sunday as 7
+		{"0 0 29 2 *", "2028-02-29 00:00"},
+		{"10-30/10 * * * *", "2026-10-16 10:20"},
+		{"0 3/8 * * *", "2026-10-16 11:00"},
+
+		// either day of month or day of week matches if both restricted
+		{"0 0 20 * 0", "2026-10-18 00:00"},
+		{"0 0 17 * 1", "2026-10-17 00:00"},
+
+		// both match if a field starts with *, even with step
+		{"0 0 */2 * 1", "2026-10-19 00:00"},  // Monday of odd day
+		{"0 0 13 * */2", "2026-12-13 00:00"}, // 13th on Sunday, Tuesday, Thursday or Saturday
+	} {
+		cron, err := ParseCron(tc.spec)
+		if err != nil {
+			t.Errorf("Failed to parse %v: %v", tc.spec, err)
+			continue
+		}
+
+		if actual := cron.Next(from).Format("2006-01-02 15:04"); actual != tc.expected {
+			t.Errorf("Expected next time of %v to be %v, but got %v", tc.spec, tc.expected, actual)
+		}
+	}
+}
+
+func TestCronNextNever(t *testing.T) {
+	cron, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next := cron.Next(time.Now()); !next.IsZero() {
+		t.Fatalf("Expected no time matches, but got %v", next)
+	}
+}
+
+func TestParseCronError(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"1,,2 * * * *",
+	} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("Expected error to parse %q", spec)
+		}
+	}
+}
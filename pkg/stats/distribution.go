@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"cmp"
+	"encoding/json"
+	"strconv"
+)
+
+// Distribution collects small non-negative counts, e.g. blocks per epoch, to report percentiles and
+// histogram of exact values.
+type Distribution struct {
+	samples []int
+}
+
+func (d *Distribution) Add(count int) {
+	d.samples = append(d.samples, count)
+}
+
+// DistributionSummary is the statistics of collected counts.
+type DistributionSummary struct {
+	Count     int
+	Min       int
+	Median    int
+	P95       int
+	Max       int
+	Histogram map[string]int `json:",omitempty"` // number of samples by value
+}
+
+func (d Distribution) Summary() DistributionSummary {
+	if len(d.samples) == 0 {
+		return DistributionSummary{}
+	}
+
+	histogram := make(map[string]int)
+	for _, v := range d.samples {
+		histogram[strconv.Itoa(v)]++
+	}
+
+	percentiles := NewPercentiles(d.samples, cmp.Compare[int])
+
+	return DistributionSummary{
+		Count:     percentiles.Len(),
+		Min:       percentiles.Min(),
+		Median:    percentiles.At(50),
+		P95:       percentiles.At(95),
+		Max:       percentiles.Max(),
+		Histogram: histogram,
+	}
+}
+
+func (d Distribution) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Summary())
+}
@@ -0,0 +1,103 @@
+package stats
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// rpcError is implemented by errors responded from fullnode, e.g. execution reverted.
+type rpcError interface {
+	ErrorCode() int
+}
+
+// IsRpcError returns true if the error is responded from fullnode rather than network or client.
+func IsRpcError(err error) bool {
+	var e rpcError
+	return errors.As(err, &e)
+}
+
+// RpcErrorCode returns the JSON-RPC error code if the error is responded from fullnode, otherwise 0.
+func RpcErrorCode(err error) int {
+	var e rpcError
+	if errors.As(err, &e) {
+		return e.ErrorCode()
+	}
+
+	return 0
+}
+
+// methodError is the error of an RPC method, which is transparent except that the method is attached.
+type methodError struct {
+	method string
+	err    error
+}
+
+func (e *methodError) Error() string { return e.err.Error() }
+func (e *methodError) Cause() error  { return e.err }
+func (e *methodError) Unwrap() error { return e.err }
+
+// RpcMethod returns the RPC method that failed with the given error, or empty if unknown.
+func RpcMethod(err error) string {
+	var e *methodError
+	if errors.As(err, &e) {
+		return e.method
+	}
+
+	return ""
+}
+
+// ErrorGroup is a group of errors with the same JSON-RPC error code and message.
+type ErrorGroup struct {
+	Code    int `json:",omitempty"` // 0 if not responded from fullnode, e.g. network error
+	Message string
+	Count   int
+}
+
+// ErrorStats counts errors grouped by JSON-RPC error code and message, in which hex values and numbers in
+// message are masked so that errors of the same kind are grouped together.
+type ErrorStats map[ErrorGroup]int
+
+var (
+	hexPattern    = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	numberPattern = regexp.MustCompile(`\b\d+\b`)
+)
+
+// Add counts the error by JSON-RPC error code and the message of root cause.
+func (s ErrorStats) Add(err error) {
+	message := errors.Cause(err).Error()
+	message = hexPattern.ReplaceAllString(message, "0x…")
+	message = numberPattern.ReplaceAllString(message, "N")
+
+	s[ErrorGroup{Code: RpcErrorCode(err), Message: message}]++
+}
+
+// Groups returns error groups in descending order of count.
+func (s ErrorStats) Groups() []ErrorGroup {
+	groups := make([]ErrorGroup, 0, len(s))
+	for group, count := range s {
+		group.Count = count
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+
+		if groups[i].Code != groups[j].Code {
+			return groups[i].Code < groups[j].Code
+		}
+
+		return groups[i].Message < groups[j].Message
+	})
+
+	return groups
+}
+
+// MarshalJSON outputs error groups in descending order of count.
+func (s ErrorStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Groups())
+}
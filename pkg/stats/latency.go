@@ -0,0 +1,145 @@
+package stats
+
+import (
+	"cmp"
+	"encoding/json"
+	"math/rand/v2"
+	"time"
+)
+
+// MethodLatency records the latency of RPC methods invoked within a single task, of which a method may be
+// invoked many times, e.g. per block.
+type MethodLatency struct {
+	Samples map[string][]time.Duration
+
+	// Jitter is the maximum random delay before each measured RPC, so as to avoid synchronized bursts from
+	// concurrent workers. Disabled if 0.
+	Jitter time.Duration
+}
+
+// NewMethodLatency creates a MethodLatency that delays each measured RPC by a random jitter.
+func NewMethodLatency(jitter time.Duration) MethodLatency {
+	return MethodLatency{
+		Samples: make(map[string][]time.Duration),
+		Jitter:  jitter,
+	}
+}
+
+// Record records a latency sample of method.
+func (ml MethodLatency) Record(method string, latency time.Duration) {
+	ml.Samples[method] = append(ml.Samples[method], latency)
+}
+
+// Max returns the maximum latency of method, or false if never recorded.
+func (ml MethodLatency) Max(method string) (time.Duration, bool) {
+	samples, ok := ml.Samples[method]
+	if !ok {
+		return 0, false
+	}
+
+	var result time.Duration
+	for _, v := range samples {
+		result = max(result, v)
+	}
+
+	return result, true
+}
+
+// Measure invokes the given RPC and records its latency by method name if succeeded, otherwise
+// attaches the method name to the returned error. The RPC is delayed by a random jitter if configured,
+// which is excluded from latency.
+func (ml MethodLatency) Measure(method string, rpc func() error) error {
+	if ml.Jitter > 0 {
+		time.Sleep(rand.N(ml.Jitter))
+	}
+
+	start := time.Now()
+	if err := rpc(); err != nil {
+		return &methodError{method, err}
+	}
+
+	ml.Record(method, time.Since(start))
+
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface to output samples by method.
+func (ml MethodLatency) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ml.Samples)
+}
+
+// LatencyStat collects latency samples to report statistics.
+type LatencyStat struct {
+	samples []time.Duration
+}
+
+func (stat *LatencyStat) Add(latency time.Duration) {
+	stat.samples = append(stat.samples, latency)
+}
+
+// LatencySummary is the statistics of collected latency samples.
+type LatencySummary struct {
+	Count int
+	Min   time.Duration
+	Avg   time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+func (stat *LatencyStat) Summary() LatencySummary {
+	if len(stat.samples) == 0 {
+		return LatencySummary{}
+	}
+
+	var total time.Duration
+	for _, v := range stat.samples {
+		total += v
+	}
+
+	percentiles := NewPercentiles(stat.samples, cmp.Compare[time.Duration])
+
+	return LatencySummary{
+		Count: percentiles.Len(),
+		Min:   percentiles.Min(),
+		Avg:   total / time.Duration(percentiles.Len()),
+		P50:   percentiles.At(50),
+		P90:   percentiles.At(90),
+		P99:   percentiles.At(99),
+		Max:   percentiles.Max(),
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface to output human readable durations.
+func (stat *LatencyStat) MarshalJSON() ([]byte, error) {
+	summary := stat.Summary()
+
+	return json.Marshal(struct {
+		Count                        int
+		Min, Avg, P50, P90, P99, Max string
+	}{
+		summary.Count,
+		summary.Min.String(),
+		summary.Avg.String(),
+		summary.P50.String(),
+		summary.P90.String(),
+		summary.P99.String(),
+		summary.Max.String(),
+	})
+}
+
+// LatencyStats collects latency samples by RPC method.
+type LatencyStats map[string]*LatencyStat
+
+func (stats LatencyStats) Add(latency MethodLatency) {
+	for method, samples := range latency.Samples {
+		if _, ok := stats[method]; !ok {
+			stats[method] = &LatencyStat{}
+		}
+
+		for _, v := range samples {
+			stats[method].Add(v)
+		}
+	}
+}
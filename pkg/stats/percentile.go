@@ -0,0 +1,38 @@
+package stats
+
+import "slices"
+
+// Percentiles is the sorted copy of samples to look up percentiles by nearest rank.
+type Percentiles[T any] struct {
+	sorted []T
+}
+
+// NewPercentiles sorts a copy of samples by the given comparison function, which returns a negative number
+// if a < b, zero if a == b and a positive number if a > b.
+func NewPercentiles[T any](samples []T, cmp func(a, b T) int) Percentiles[T] {
+	sorted := slices.Clone(samples)
+	slices.SortFunc(sorted, cmp)
+
+	return Percentiles[T]{sorted}
+}
+
+// Len returns the number of samples.
+func (p Percentiles[T]) Len() int {
+	return len(p.sorted)
+}
+
+// At returns the percentile in [0, 100], i.e. the minimum for 0 and the maximum for 100, in which the lower
+// sample is returned if the percentile falls between two samples. Samples must not be empty.
+func (p Percentiles[T]) At(percent int) T {
+	return p.sorted[(len(p.sorted)-1)*percent/100]
+}
+
+// Min returns the minimum sample.
+func (p Percentiles[T]) Min() T {
+	return p.sorted[0]
+}
+
+// Max returns the maximum sample.
+func (p Percentiles[T]) Max() T {
+	return p.sorted[len(p.sorted)-1]
+}
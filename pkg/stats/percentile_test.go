@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPercentiles(t *testing.T) {
+	samples := make([]int, 100)
+	for i := range samples {
+		samples[i] = 100 - i // 100 ... 1
+	}
+
+	percentiles := NewPercentiles(samples, cmp.Compare[int])
+
+	for _, tc := range []struct {
+		percent  int
+		expected int
+	}{
+		{0, 1},
+		{1, 1},
+		{50, 50},
+		{90, 90},
+		{95, 95},
+		{99, 99},
+		{100, 100},
+	} {
+		if actual := percentiles.At(tc.percent); actual != tc.expected {
+			t.Errorf("Expected P%v to be %v, but got %v", tc.percent, tc.expected, actual)
+		}
+	}
+
+	if percentiles.Len() != 100 || percentiles.Min() != 1 || percentiles.Max() != 100 {
+		t.Errorf("Unexpected len %v, min %v or max %v", percentiles.Len(), percentiles.Min(), percentiles.Max())
+	}
+
+	if samples[0] != 100 || samples[99] != 1 {
+		t.Errorf("Samples should not be sorted in place")
+	}
+}
+
+func TestPercentilesFewSamples(t *testing.T) {
+	one := NewPercentiles([]int{7}, cmp.Compare[int])
+	if one.Min() != 7 || one.At(50) != 7 || one.At(99) != 7 || one.Max() != 7 {
+		t.Errorf("Unexpected percentiles of single sample")
+	}
+
+	// lower sample if falls between two samples
+	two := NewPercentiles([]int{9, 3}, cmp.Compare[int])
+	if two.Min() != 3 || two.At(50) != 3 || two.At(99) != 3 || two.Max() != 9 {
+		t.Errorf("Unexpected percentiles of two samples")
+	}
+}
+
+func TestLatencySummary(t *testing.T) {
+	if summary := new(LatencyStat).Summary(); summary != (LatencySummary{}) {
+		t.Fatalf("Expected empty summary, but got %+v", summary)
+	}
+
+	var stat LatencyStat
+	for _, v := range []int{40, 10, 30, 20} {
+		stat.Add(time.Duration(v) * time.Millisecond)
+	}
+
+	expected := LatencySummary{
+		Count: 4,
+		Min:   10 * time.Millisecond,
+		Avg:   25 * time.Millisecond,
+		P50:   20 * time.Millisecond,
+		P90:   30 * time.Millisecond,
+		P99:   30 * time.Millisecond,
+		Max:   40 * time.Millisecond,
+	}
+
+	if summary := stat.Summary(); summary != expected {
+		t.Fatalf("Expected summary %+v, but got %+v", expected, summary)
+	}
+}
+
+func TestDistributionSummary(t *testing.T) {
+	var d Distribution
+	for _, v := range []int{2, 1, 1, 3, 1} {
+		d.Add(v)
+	}
+
+	summary := d.Summary()
+	if summary.Count != 5 || summary.Min != 1 || summary.Median != 1 || summary.P95 != 2 || summary.Max != 3 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+
+	if !slices.Equal([]int{summary.Histogram["1"], summary.Histogram["2"], summary.Histogram["3"]}, []int{3, 1, 1}) {
+		t.Errorf("Unexpected histogram %v", summary.Histogram)
+	}
+}
@@ -0,0 +1,33 @@
+package stats
+
+import "time"
+
+// RawStat compares the latency of raw client against SDK client.
+type RawStat struct {
+	Latencies LatencyStats   // latency of raw client without decoding
+	Decode    LatencyStats   // time to decode raw responses into SDK types
+	Protocols map[string]int `json:",omitempty"` // number of responses by negotiated HTTP protocol
+
+	CompressedBytes   uint64 // response bytes received on the wire
+	DecompressedBytes uint64 // response bytes after decompression
+}
+
+func NewRawStat() *RawStat {
+	return &RawStat{
+		Latencies: make(LatencyStats),
+		Decode:    make(LatencyStats),
+	}
+}
+
+// Overhead returns the average latency of SDK client minus that of raw client by method.
+func (stat *RawStat) Overhead(sdkLatencies LatencyStats) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+
+	for method, raw := range stat.Latencies {
+		if latency, ok := sdkLatencies[method]; ok {
+			result[method] = latency.Summary().Avg - raw.Summary().Avg
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// retryCounts is the attempts and successes of an RPC method in the main pass and in retry.
+type retryCounts struct {
+	attempts  int
+	successes int
+	retries   int
+	recovered int
+}
+
+// RetryStats tracks attempts versus successes by RPC method, so as to tune retry settings with data.
+type RetryStats struct {
+	mu      sync.Mutex
+	methods map[string]*retryCounts
+}
+
+// Add records a request of method, which is either a first try or a retry.
+func (s *RetryStats) Add(method string, retry bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.methods == nil {
+		s.methods = make(map[string]*retryCounts)
+	}
+
+	counts, ok := s.methods[method]
+	if !ok {
+		counts = &retryCounts{}
+		s.methods[method] = counts
+	}
+
+	if retry {
+		counts.retries++
+		if err == nil {
+			counts.recovered++
+		}
+	} else {
+		counts.attempts++
+		if err == nil {
+			counts.successes++
+		}
+	}
+}
+
+// RetrySummary is the retry budget accounting of an RPC method.
+type RetrySummary struct {
+	Attempts        int     // first tries
+	FirstTrySuccess float64 // ratio of first tries succeeded
+	Retries         int     // retries consumed
+	RetrySuccess    float64 `json:",omitempty"` // ratio of retries succeeded
+	RetryRatio      float64 `json:",omitempty"` // retries per first try
+}
+
+// Summaries returns the retry budget accounting by RPC method.
+func (s *RetryStats) Summaries() map[string]RetrySummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summaries := make(map[string]RetrySummary, len(s.methods))
+	for method, counts := range s.methods {
+		summary := RetrySummary{
+			Attempts: counts.attempts,
+			Retries:  counts.retries,
+		}
+
+		if counts.attempts > 0 {
+			summary.FirstTrySuccess = float64(counts.successes) / float64(counts.attempts)
+			summary.RetryRatio = float64(counts.retries) / float64(counts.attempts)
+		}
+
+		if counts.retries > 0 {
+			summary.RetrySuccess = float64(counts.recovered) / float64(counts.retries)
+		}
+
+		summaries[method] = summary
+	}
+
+	return summaries
+}
+
+func (s *RetryStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Summaries())
+}
@@ -0,0 +1,83 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NearTimeoutRatio is the ratio of timeout budget, above which requests are regarded as approaching timeout.
+const NearTimeoutRatio = 0.8
+
+const numTimeoutBuckets = 10
+
+// TimeoutHistogram tracks where in the timeout window requests completed, so as to guide choosing the
+// right RPC timeout.
+type TimeoutHistogram struct {
+	mu sync.Mutex
+
+	requests    int
+	nearTimeout int // completed above NearTimeoutRatio of budget
+	timedOut    int
+	buckets     [numTimeoutBuckets]int // completed requests by decile of budget
+}
+
+// Add records a request that took elapsed time within the timeout budget.
+func (h *TimeoutHistogram) Add(elapsed, budget time.Duration, err error) {
+	if budget <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.requests++
+
+	if IsTimeout(err) {
+		h.timedOut++
+		return
+	}
+
+	ratio := float64(elapsed) / float64(budget)
+	if ratio > NearTimeoutRatio {
+		h.nearTimeout++
+	}
+
+	h.buckets[min(int(ratio*numTimeoutBuckets), numTimeoutBuckets-1)]++
+}
+
+func (h *TimeoutHistogram) MarshalJSON() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buckets := make(map[string]int)
+	for i, count := range h.buckets {
+		buckets[fmt.Sprintf("%v%%-%v%%", i*100/numTimeoutBuckets, (i+1)*100/numTimeoutBuckets)] = count
+	}
+
+	return json.Marshal(struct {
+		Requests    int
+		NearTimeout int
+		TimedOut    int
+		Buckets     map[string]int
+	}{h.requests, h.nearTimeout, h.timedOut, buckets})
+}
+
+// IsTimeout returns true if the error is caused by timeout.
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
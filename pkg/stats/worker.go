@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WorkerStat is the statistics of tasks executed by a routine.
+type WorkerStat struct {
+	NumTasks  int
+	NumErrors int
+	Busy      time.Duration
+	MaxTask   time.Duration // the slowest task
+}
+
+// MarshalJSON implements the json.Marshaler interface to output durations in human readable format.
+func (stat *WorkerStat) MarshalJSON() ([]byte, error) {
+	var avg time.Duration
+	if stat.NumTasks > 0 {
+		avg = stat.Busy / time.Duration(stat.NumTasks)
+	}
+
+	return json.Marshal(struct {
+		NumTasks  int
+		NumErrors int
+		Busy      string
+		AvgTask   string
+		MaxTask   string
+	}{stat.NumTasks, stat.NumErrors, stat.Busy.String(), avg.String(), stat.MaxTask.String()})
+}
+
+// WorkerStats is the statistics of tasks by routine index, so as to reveal skew between routines,
+// e.g. one routine stuck on a slow connection.
+type WorkerStats []*WorkerStat
+
+func (stats *WorkerStats) Add(routine int, elapsed time.Duration, failed bool) {
+	for len(*stats) <= routine {
+		*stats = append(*stats, &WorkerStat{})
+	}
+
+	stat := (*stats)[routine]
+	stat.NumTasks++
+	stat.Busy += elapsed
+	stat.MaxTask = max(stat.MaxTask, elapsed)
+
+	if failed {
+		stat.NumErrors++
+	}
+}